@@ -0,0 +1,388 @@
+package xmlstruct
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	mathrand "math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/net/html/charset"
+)
+
+// anonymizeAttempts is the number of synthetic values generated for a value
+// before giving up on finding one with the same kind.
+const anonymizeAttempts = 16
+
+// anonymizeBoolValues are the boolean values accepted by strconv.ParseBool,
+// in pairs of true and false values with the same format.
+var anonymizeBoolValues = [][2]string{
+	{"t", "f"},
+	{"T", "F"},
+	{"true", "false"},
+	{"TRUE", "FALSE"},
+	{"True", "False"},
+}
+
+// anonymizeEscaper escapes text and attribute values. Unlike xml.EscapeText,
+// it does not escape newlines, so that the layout of documents is preserved.
+var anonymizeEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	"\"", "&quot;",
+	"\r", "&#xD;",
+)
+
+var (
+	anonymizeTimeMin = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	anonymizeTimeMax = time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+)
+
+// WithAnonymizationKey sets the secret key used by AnonymizeDocument to derive
+// synthetic values. Anonymizing with the same key maps the same observed value
+// to the same synthetic value, so that anonymized corpora are stable across
+// runs. By default, a random key is chosen for each generator, so that values
+// are consistent across documents anonymized by the same generator only.
+func WithAnonymizationKey(anonymizationKey []byte) GeneratorOption {
+	return func(g *Generator) {
+		g.anonymizationKey = anonymizationKey
+	}
+}
+
+// WithAnonymizationEnumThreshold sets the maximum number of distinct values of
+// an attribute or chardata for AnonymizeDocument to treat them as the members
+// of an enumeration, like status codes, and keep them rather than synthesizing
+// values. The values must all be known, so WithValueExamples must be set to at
+// least enumThreshold, and each value must occur at least twice on average.
+// The default of zero never keeps observed values.
+func WithAnonymizationEnumThreshold(enumThreshold int) GeneratorOption {
+	return func(g *Generator) {
+		g.anonymizeEnumThreshold = enumThreshold
+	}
+}
+
+// AnonymizeDocument writes the XML document read from r to w with its
+// attribute values and chardata replaced by synthetic values, so that realistic
+// fixtures can be committed publicly. Synthetic values preserve the kind of
+// the value that they replace, so that they infer the same model: numbers keep
+// their lengths and formats, times are formatted with g's time layout, and
+// other strings keep their lengths, whitespace, and punctuation. Observed
+// values are only kept for enumerations, as set with
+// WithAnonymizationEnumThreshold. Equal values are replaced by equal synthetic
+// values, so keys and references to them remain consistent. Comments,
+// directives such as DOCTYPEs, and processing instructions other than the XML
+// declaration are removed.
+//
+// The synthetic values are derived from the document's values with the key
+// set with WithAnonymizationKey. AnonymizeDocument should be called after the
+// corpus has been observed, so that g's model is complete.
+func (g *Generator) AnonymizeDocument(w io.Writer, r io.Reader) error {
+	g.mu.Lock()
+	if g.anonymizationKey == nil {
+		g.anonymizationKey = make([]byte, sha256.Size)
+		if _, err := rand.Read(g.anonymizationKey); err != nil {
+			g.mu.Unlock()
+			return err
+		}
+	}
+	g.mu.Unlock()
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	a := &anonymizer{
+		generator: g,
+		options:   g.observeOptions(),
+		synthetic: make(map[string]string),
+	}
+	return a.anonymize(w, r)
+}
+
+// An anonymizer anonymizes XML documents using a generator's model.
+type anonymizer struct {
+	generator *Generator
+	options   observeOptions
+	synthetic map[string]string
+}
+
+// An anonymizeScope is the state of an open element while anonymizing.
+type anonymizeScope struct {
+	element    *element
+	path       []xml.Name
+	namespaces map[string]string
+}
+
+// anonymize writes the XML document read from r to w with its values
+// anonymized.
+func (a *anonymizer) anonymize(w io.Writer, r io.Reader) error {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Entity = a.generator.entities
+
+	scopes := []*anonymizeScope{{
+		namespaces: map[string]string{"xml": "http://www.w3.org/XML/1998/namespace"},
+	}}
+	for {
+		token, err := decoder.RawToken()
+		switch {
+		case errors.Is(err, io.EOF):
+			return nil
+		case err != nil:
+			return &DecodeError{
+				Offset: decoder.InputOffset(),
+				Err:    err,
+			}
+		}
+
+		parent := scopes[len(scopes)-1]
+		switch token := token.(type) {
+		case xml.StartElement:
+			scope := a.startElementScope(parent, token)
+			scopes = append(scopes, scope)
+			if _, err := fmt.Fprintf(w, "<%s", qualifiedName(token.Name)); err != nil {
+				return err
+			}
+			for _, attr := range token.Attr {
+				attrValue := attr.Value
+				if !isNamespaceDeclaration(attr.Name) {
+					var v *value
+					if scope.element != nil {
+						v = scope.element.attrValues[a.options.pathNameFunc(scope.path, a.resolveName(scope, attr.Name, false))]
+					}
+					attrValue = a.anonymizeValue(attrValue, v)
+				}
+				if _, err := fmt.Fprintf(w, " %s=\"", qualifiedName(attr.Name)); err != nil {
+					return err
+				}
+				if _, err := anonymizeEscaper.WriteString(w, attrValue); err != nil {
+					return err
+				}
+				if _, err := io.WriteString(w, "\""); err != nil {
+					return err
+				}
+			}
+			if _, err := io.WriteString(w, ">"); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			if len(scopes) > 1 {
+				scopes = scopes[:len(scopes)-1]
+			}
+			if _, err := fmt.Fprintf(w, "</%s>", qualifiedName(token.Name)); err != nil {
+				return err
+			}
+		case xml.CharData:
+			charData := string(token)
+			if strings.TrimSpace(charData) != "" {
+				var v *value
+				if parent.element != nil {
+					v = &parent.element.charDataValue
+				}
+				charData = a.anonymizeValue(charData, v)
+			}
+			if _, err := anonymizeEscaper.WriteString(w, charData); err != nil {
+				return err
+			}
+		case xml.Comment, xml.Directive:
+			// Comments may contain anything, and DOCTYPEs may contain system
+			// identifiers and entity values, so remove them. Entity references
+			// are already replaced in the written chardata.
+		case xml.ProcInst:
+			// Other processing instructions may contain URLs, so remove them.
+			if token.Target == "xml" {
+				// The document is written as UTF-8.
+				if _, err := io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?>`); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// startElementScope returns the scope of startElement, a child of parent.
+func (a *anonymizer) startElementScope(parent *anonymizeScope, startElement xml.StartElement) *anonymizeScope {
+	scope := &anonymizeScope{
+		namespaces: parent.namespaces,
+	}
+	copied := false
+	for _, attr := range startElement.Attr {
+		if !isNamespaceDeclaration(attr.Name) {
+			continue
+		}
+		if !copied {
+			scope.namespaces = maps.Clone(parent.namespaces)
+			copied = true
+		}
+		if attr.Name.Space == "xmlns" {
+			scope.namespaces[attr.Name.Local] = attr.Value
+		} else {
+			scope.namespaces[""] = attr.Value
+		}
+	}
+
+	name := a.resolveName(scope, startElement.Name, true)
+	switch {
+	case parent.element == nil && len(parent.path) == 0:
+		scope.element = a.generator.typeElements[a.options.pathNameFunc(nil, name)]
+	case parent.element != nil:
+		scope.element = parent.element.childElements[a.options.pathNameFunc(parent.path, name)]
+	}
+	scope.path = append(slices.Clip(parent.path), name)
+	return scope
+}
+
+// resolveName returns name with its prefix resolved to a namespace in scope,
+// as the names of the tokens returned by xml.Decoder.Token are, unless the
+// generator uses raw tokens. Unprefixed element names are in the default
+// namespace, unprefixed attribute names are not in a namespace.
+func (a *anonymizer) resolveName(scope *anonymizeScope, name xml.Name, isElementName bool) xml.Name {
+	if a.options.useRawToken {
+		return name
+	}
+	switch {
+	case name.Space == "" && !isElementName:
+		return name
+	case name.Space == "xmlns":
+		return name
+	}
+	if space, ok := scope.namespaces[name.Space]; ok {
+		name.Space = space
+	}
+	return name
+}
+
+// anonymizeValue returns the synthetic value of s, a value of v, which may be
+// nil if s is not in g's model.
+func (a *anonymizer) anonymizeValue(s string, v *value) string {
+	trimmed := strings.TrimSpace(s)
+	start := strings.Index(s, trimmed)
+	prefix, suffix := s[:start], s[start+len(trimmed):]
+
+	if a.enum(v) {
+		if _, ok := v.examples[a.options.valueCollation.key(trimmed)]; ok {
+			return s
+		}
+	}
+
+	if synthetic, ok := a.synthetic[trimmed]; ok {
+		return prefix + synthetic + suffix
+	}
+	kind := a.valueKind(trimmed)
+	synthetic := trimmed
+	for attempt := range anonymizeAttempts {
+		synthetic = a.syntheticValue(trimmed, kind, attempt)
+		if a.valueKind(synthetic) == kind {
+			break
+		}
+	}
+	a.synthetic[trimmed] = synthetic
+	return prefix + synthetic + suffix
+}
+
+// enum returns whether the observed values of v are the members of an
+// enumeration, which are not sensitive and so are kept. The values must all be
+// known, there must be no more than the enum threshold of them, and each must
+// occur at least twice on average, so that near-unique values like identifiers
+// and names are never kept, however few of them were observed.
+func (a *anonymizer) enum(v *value) bool {
+	threshold := a.generator.anonymizeEnumThreshold
+	return v != nil && threshold > 0 && v.examples != nil && !v.examplesIncomplete &&
+		len(v.examples) <= threshold && v.observations >= 2*len(v.examples)
+}
+
+// valueKind returns the kind of s when observed on its own.
+func (a *anonymizer) valueKind(s string) valueKind {
+	options := a.options
+	options.valueExamples = 0
+	options.exampleTags = false
+	var v value
+	v.observe(s, &options)
+	return v.kind()
+}
+
+// syntheticValue returns a synthetic value for s, whose kind is kind.
+func (a *anonymizer) syntheticValue(s string, kind valueKind, attempt int) string {
+	rng := a.rand("value", s, attempt)
+	switch kind {
+	case boolValueKind:
+		for _, boolValues := range anonymizeBoolValues {
+			if s == boolValues[0] || s == boolValues[1] {
+				return boolValues[rng.IntN(2)]
+			}
+		}
+		return s
+	case timeValueKind:
+		t, err := time.Parse(a.options.timeLayout, s)
+		if err != nil {
+			return s
+		}
+		syntheticTime := time.Unix(anonymizeTimeMin+rng.Int64N(anonymizeTimeMax-anonymizeTimeMin), 0).In(t.Location())
+		return syntheticTime.Format(a.options.timeLayout)
+	default:
+		// Numbers keep their signs, separators, and exponents, strings keep
+		// their whitespace and punctuation.
+		number := kind == intValueKind || kind == float64ValueKind
+		runes := []rune(s)
+		leadingDigit := true
+		for i, r := range runes {
+			switch {
+			case '0' <= r && r <= '9':
+				// Keep the first digit of numbers non-zero, so that they keep
+				// their lengths and do not gain leading zeros.
+				if leadingDigit && r != '0' {
+					runes[i] = rune('1' + rng.IntN(9))
+				} else {
+					runes[i] = rune('0' + rng.IntN(10))
+				}
+				leadingDigit = false
+			case number:
+				leadingDigit = strings.ContainsRune("+-eE", r)
+			case unicode.IsUpper(r):
+				runes[i] = rune('A' + rng.IntN(26))
+				leadingDigit = true
+			case unicode.IsLetter(r):
+				runes[i] = rune('a' + rng.IntN(26))
+				leadingDigit = true
+			default:
+				leadingDigit = true
+			}
+		}
+		return string(runes)
+	}
+}
+
+// rand returns a random number generator derived from the anonymization key,
+// domain, s, and attempt.
+func (a *anonymizer) rand(domain, s string, attempt int) *mathrand.Rand {
+	mac := hmac.New(sha256.New, a.generator.anonymizationKey)
+	mac.Write([]byte(domain))
+	mac.Write([]byte{0})
+	mac.Write([]byte(s))
+	mac.Write(binary.BigEndian.AppendUint64(nil, uint64(attempt)))
+	var seed [32]byte
+	copy(seed[:], mac.Sum(nil))
+	return mathrand.New(mathrand.NewChaCha8(seed))
+}
+
+// isNamespaceDeclaration returns whether name is the name of a namespace
+// declaration attribute.
+func isNamespaceDeclaration(name xml.Name) bool {
+	return name.Space == "xmlns" || name.Space == "" && name.Local == "xmlns"
+}
+
+// qualifiedName returns name as it appears in a document, with its prefix.
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
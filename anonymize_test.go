@@ -0,0 +1,108 @@
+package xmlstruct_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestAnonymizeDocument(t *testing.T) {
+	t.Parallel()
+
+	document := joinLines(
+		`<?xml version="1.0" encoding="ISO-8859-1"?>`,
+		`<!DOCTYPE customers SYSTEM "https://internal.example.com/customers.dtd">`,
+		`<?xml-stylesheet href="https://internal.example.com/style.xsl"?>`,
+		`<!-- Customer export -->`,
+		`<customers xmlns:x="urn:x">`,
+		`  <customer id="1042" status="active" vip="true">`,
+		`    <name>Alice O'Brien</name>`,
+		`    <balance>-1234.56</balance>`,
+		`    <since>2019-03-04T05:06:07Z</since>`,
+		`    <x:code>AB-7</x:code>`,
+		`  </customer>`,
+		`  <customer id="2077" status="active" vip="false">`,
+		`    <name>Bob Caf`+"\xe9"+`</name>`,
+		`    <balance>0.5</balance>`,
+		`    <since>2020-11-12T13:14:15Z</since>`,
+		`    <x:code>CD-8</x:code>`,
+		`  </customer>`,
+		`  <referral from="1042" to="2077"/>`,
+		`</customers>`,
+	)
+
+	newGenerator := func() *xmlstruct.Generator {
+		return xmlstruct.NewGenerator(
+			xmlstruct.WithAnonymizationEnumThreshold(2),
+			xmlstruct.WithAnonymizationKey([]byte("secret")),
+			xmlstruct.WithValueExamples(2),
+		)
+	}
+
+	generator := newGenerator()
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(document)))
+	expected, err := generator.Generate()
+	assert.NoError(t, err)
+
+	anonymized := &bytes.Buffer{}
+	assert.NoError(t, generator.AnonymizeDocument(anonymized, strings.NewReader(document)))
+	anonymizedStr := anonymized.String()
+	for _, s := range []string{"internal.example.com", "Customer export", "1042", "2077", "Alice", "Bob", "1234.56", "2019-03-04", "AB-7"} {
+		assert.False(t, strings.Contains(anonymizedStr, s), s)
+	}
+	assert.True(t, strings.HasPrefix(anonymizedStr, `<?xml version="1.0" encoding="UTF-8"?>`))
+	assert.Equal(t, 2, strings.Count(anonymizedStr, `status="active"`))
+
+	// The anonymized document infers the same model, except for the examples.
+	anonymizedGenerator := newGenerator()
+	assert.NoError(t, anonymizedGenerator.ObserveReader(strings.NewReader(anonymizedStr)))
+	actual, err := anonymizedGenerator.Generate()
+	assert.NoError(t, err)
+	assert.Equal(t, stripExamples(string(expected)), stripExamples(string(actual)))
+
+	// Anonymizing with the same key is deterministic.
+	generatorAgain := newGenerator()
+	assert.NoError(t, generatorAgain.ObserveReader(strings.NewReader(document)))
+	anonymizedAgain := &bytes.Buffer{}
+	assert.NoError(t, generatorAgain.AnonymizeDocument(anonymizedAgain, strings.NewReader(document)))
+	assert.Equal(t, anonymizedStr, anonymizedAgain.String())
+}
+
+func TestAnonymizeDocumentNearUniqueValues(t *testing.T) {
+	t.Parallel()
+
+	document := joinLines(
+		`<people>`,
+		`  <p ssn="123-45-6789">Alice Smith</p>`,
+		`  <p ssn="987-65-4321">Bob Jones</p>`,
+		`  <p ssn="123-45-6789">Alice Smith</p>`,
+		`</people>`,
+	)
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithAnonymizationEnumThreshold(10),
+		xmlstruct.WithValueExamples(10),
+	)
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(document)))
+
+	anonymized := &bytes.Buffer{}
+	assert.NoError(t, generator.AnonymizeDocument(anonymized, strings.NewReader(document)))
+	for _, s := range []string{"123-45-6789", "987-65-4321", "Alice", "Smith", "Bob", "Jones"} {
+		assert.False(t, strings.Contains(anonymized.String(), s), s)
+	}
+}
+
+// stripExamples returns source with its examples comments removed.
+func stripExamples(source string) string {
+	lines := strings.Split(source, "\n")
+	for i, line := range lines {
+		if before, _, ok := strings.Cut(line, " // Examples:"); ok {
+			lines[i] = before
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,122 @@
+package xmlstruct
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+const (
+	anyElementName    = "AnyElement"
+	anyFieldName      = "Any"
+	anyAttrsFieldName = "AnyAttrs"
+)
+
+// writeAnyFields writes the catch-all fields of the struct generated for e,
+// with path path, to w.
+func (e *element) writeAnyFields(w io.Writer, path string, fields []*field, options *generateOptions, indentPrefix string) error {
+	anyAttrs, anyElements := options.anyContent(e, path)
+	if !anyAttrs && !anyElements {
+		return nil
+	}
+	anyAttrsName, anyName := anyFieldNames(fields)
+	options.importPackageNames["encoding/xml"] = ""
+	if anyAttrs {
+		fmt.Fprintf(w, "%s\t%s []xml.Attr `xml:\",any,attr\"`\n", indentPrefix, anyAttrsName)
+	}
+	if anyElements {
+		fmt.Fprintf(w, "%s\t%s []%s `xml:\",any\"`\n", indentPrefix, anyName, options.anyElementTypeName())
+	}
+	return nil
+}
+
+// writeDeepCopyAnyFields writes statements that deep copy the catch-all fields
+// of the struct generated for e, with path path and fields fields, from in to
+// out.
+func writeDeepCopyAnyFields(w io.Writer, e *element, path string, fields []*field, in, out, indent string, depth int, options *generateOptions) error {
+	anyAttrs, anyElements := options.anyContent(e, path)
+	if !anyAttrs && !anyElements {
+		return nil
+	}
+	if depth >= len(deepCopyIndexVars) {
+		return fmt.Errorf("%s: too deeply nested", in)
+	}
+	anyAttrsName, anyName := anyFieldNames(fields)
+	options.importPackageNames["slices"] = ""
+	if anyAttrs {
+		fmt.Fprintf(w, "%sif %s.%s != nil {\n", indent, in, anyAttrsName)
+		fmt.Fprintf(w, "%s\t%s.%s = slices.Clone(%s.%s)\n", indent, out, anyAttrsName, in, anyAttrsName)
+		fmt.Fprintf(w, "%s}\n", indent)
+	}
+	if anyElements {
+		i := deepCopyIndexVars[depth]
+		fmt.Fprintf(w, "%sif %s.%s != nil {\n", indent, in, anyName)
+		fmt.Fprintf(w, "%s\t%s.%s = slices.Clone(%s.%s)\n", indent, out, anyName, in, anyName)
+		fmt.Fprintf(w, "%s\tfor %s := range %s.%s {\n", indent, i, in, anyName)
+		fmt.Fprintf(w, "%s\t\t%s.%s[%s].Attrs = slices.Clone(%s.%s[%s].Attrs)\n", indent, out, anyName, i, in, anyName, i)
+		fmt.Fprintf(w, "%s\t}\n", indent)
+		fmt.Fprintf(w, "%s}\n", indent)
+	}
+	return nil
+}
+
+// anyFieldNames returns the names of the catch-all fields for attributes and
+// child elements of a struct with fields fields. Underscores are appended to
+// the names until they do not collide with the names of fields, for example
+// of a field for an any child element.
+func anyFieldNames(fields []*field) (anyAttrsName, anyName string) {
+	fieldNames := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		fieldNames[field.name] = struct{}{}
+	}
+	uniqueName := func(name string) string {
+		for {
+			if _, ok := fieldNames[name]; !ok {
+				fieldNames[name] = struct{}{}
+				return name
+			}
+			name += "_"
+		}
+	}
+	return uniqueName(anyAttrsFieldName), uniqueName(anyFieldName)
+}
+
+// anyContent returns whether the struct generated for e, with path path, has
+// catch-all fields for attributes and child elements. Structs for elements with
+// rare child elements, which are excluded from their fields, have a catch-all
+// field for child elements.
+func (options *generateOptions) anyContent(e *element, path string) (anyAttrs, anyElements bool) {
+	for _, matcher := range options.anyAttrs {
+		if matcher(path) {
+			anyAttrs = true
+			break
+		}
+	}
+	for _, matcher := range options.anyElements {
+		if matcher(path) {
+			anyElements = true
+			break
+		}
+	}
+	if !anyElements && e.hasRareChildElements(options) {
+		anyElements = true
+	}
+	return
+}
+
+// anyElementTypeName returns the name of the type of elements that are caught
+// by catch-all fields, declaring it if needed.
+func (options *generateOptions) anyElementTypeName() string {
+	typeName := options.exportTypeNameFunc(xml.Name{Local: anyElementName})
+	if _, ok := options.declarations[typeName]; !ok {
+		options.declarations[typeName] = fmt.Sprintf(""+
+			"// %s is an element that was not observed, with its attributes and\n"+
+			"// undecoded contents.\n"+
+			"type %s struct {\n"+
+			"\tXMLName  xml.Name\n"+
+			"\tAttrs    []xml.Attr `xml:\",any,attr\"`\n"+
+			"\tInnerXML string     `xml:\",innerxml\"`\n"+
+			"}\n", typeName, typeName)
+	}
+	return typeName
+}
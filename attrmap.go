@@ -0,0 +1,83 @@
+package xmlstruct
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	attrMapName      = "AttrMap"
+	attrMapFieldName = "Attrs"
+)
+
+// hasAttrMap returns whether the attributes of e are represented by a map
+// rather than by a field for each attribute.
+func (e *element) hasAttrMap(options *generateOptions) bool {
+	return options.attrMapThreshold > 0 && len(e.attrValues) > options.attrMapThreshold
+}
+
+// attrMapGoFieldType returns the Go type of an attribute map field and adds the
+// declaration of the map type and its methods to options.
+func (options *generateOptions) attrMapGoFieldType() goFieldType {
+	typeName := options.exportTypeNameFunc(xml.Name{Local: attrMapName})
+	if _, ok := options.declarations[typeName]; !ok {
+		options.importPackageNames["encoding/xml"] = ""
+		options.importPackageNames["sort"] = ""
+		declarationBuilder := &strings.Builder{}
+		fmt.Fprintf(declarationBuilder, "// %s is a map of attribute values keyed by their local names.\n", typeName)
+		fmt.Fprintf(declarationBuilder, "type %s map[string]string\n", typeName)
+		fmt.Fprintf(declarationBuilder, "\n")
+		fmt.Fprintf(declarationBuilder, "// UnmarshalXMLAttr implements encoding/xml.UnmarshalerAttr. Namespace\n")
+		fmt.Fprintf(declarationBuilder, "// declarations are ignored.\n")
+		fmt.Fprintf(declarationBuilder, "func (m *%s) UnmarshalXMLAttr(attr xml.Attr) error {\n", typeName)
+		fmt.Fprintf(declarationBuilder, "\tif attr.Name.Space == \"xmlns\" || attr.Name.Space == \"\" && attr.Name.Local == \"xmlns\" {\n")
+		fmt.Fprintf(declarationBuilder, "\t\treturn nil\n")
+		fmt.Fprintf(declarationBuilder, "\t}\n")
+		fmt.Fprintf(declarationBuilder, "\tif *m == nil {\n")
+		fmt.Fprintf(declarationBuilder, "\t\t*m = make(%s)\n", typeName)
+		fmt.Fprintf(declarationBuilder, "\t}\n")
+		fmt.Fprintf(declarationBuilder, "\t(*m)[attr.Name.Local] = attr.Value\n")
+		fmt.Fprintf(declarationBuilder, "\treturn nil\n")
+		fmt.Fprintf(declarationBuilder, "}\n")
+		fmt.Fprintf(declarationBuilder, "\n")
+		fmt.Fprintf(declarationBuilder, "// MarshalXMLAttr implements encoding/xml.MarshalerAttr. encoding/xml cannot\n")
+		fmt.Fprintf(declarationBuilder, "// marshal a map as several attributes, so nothing is marshaled here. Instead,\n")
+		fmt.Fprintf(declarationBuilder, "// named types with an %s field marshal its attributes in their MarshalXML\n", typeName)
+		fmt.Fprintf(declarationBuilder, "// method.\n")
+		fmt.Fprintf(declarationBuilder, "func (m %s) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {\n", typeName)
+		fmt.Fprintf(declarationBuilder, "\treturn xml.Attr{}, nil\n")
+		fmt.Fprintf(declarationBuilder, "}\n")
+		fmt.Fprintf(declarationBuilder, "\n")
+		fmt.Fprintf(declarationBuilder, "// appendAttrs appends m's attributes, in key order, to attrs.\n")
+		fmt.Fprintf(declarationBuilder, "func (m %s) appendAttrs(attrs []xml.Attr) []xml.Attr {\n", typeName)
+		fmt.Fprintf(declarationBuilder, "\tkeys := make([]string, 0, len(m))\n")
+		fmt.Fprintf(declarationBuilder, "\tfor key := range m {\n")
+		fmt.Fprintf(declarationBuilder, "\t\tkeys = append(keys, key)\n")
+		fmt.Fprintf(declarationBuilder, "\t}\n")
+		fmt.Fprintf(declarationBuilder, "\tsort.Strings(keys)\n")
+		fmt.Fprintf(declarationBuilder, "\tfor _, key := range keys {\n")
+		fmt.Fprintf(declarationBuilder, "\t\tattrs = append(attrs, xml.Attr{Name: xml.Name{Local: key}, Value: m[key]})\n")
+		fmt.Fprintf(declarationBuilder, "\t}\n")
+		fmt.Fprintf(declarationBuilder, "\treturn attrs\n")
+		fmt.Fprintf(declarationBuilder, "}\n")
+		options.declarations[typeName] = declarationBuilder.String()
+	}
+	return goFieldType{
+		name:    typeName,
+		mapType: true,
+	}
+}
+
+// writeAttrMapMarshalXMLMethod writes a MarshalXML method for the named type
+// typeName, whose attributes are represented by an attribute map, to w.
+func writeAttrMapMarshalXMLMethod(w io.Writer, typeName string) {
+	fmt.Fprintf(w, "\n// MarshalXML implements encoding/xml.Marshaler. It marshals the attributes in\n")
+	fmt.Fprintf(w, "// x.%s, which encoding/xml cannot marshal.\n", attrMapFieldName)
+	fmt.Fprintf(w, "func (x %s) MarshalXML(e *xml.Encoder, start xml.StartElement) error {\n", typeName)
+	fmt.Fprintf(w, "\ttype plain %s\n", typeName)
+	fmt.Fprintf(w, "\tstart.Attr = x.%s.appendAttrs(start.Attr)\n", attrMapFieldName)
+	fmt.Fprintf(w, "\treturn e.EncodeElement(plain(x), start)\n")
+	fmt.Fprintf(w, "}\n")
+}
@@ -0,0 +1,172 @@
+package xmlstruct
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WithBenchmarks sets whether to record the largest observed occurrence of each
+// top level element, so that GenerateBenchmarks can generate benchmarks that
+// decode and encode it. The samples are recorded after conversion to UTF-8 and
+// before any token filters are applied. Samples contain the observed values
+// verbatim, so they are not recorded if WithValueSanitizer is set.
+func WithBenchmarks(benchmarks bool) GeneratorOption {
+	return func(g *Generator) {
+		g.benchmarks = benchmarks
+	}
+}
+
+// GenerateBenchmarks returns the Go source of a _test.go file with a
+// BenchmarkDecode and a BenchmarkEncode function for each top level element
+// that has a sample recorded with WithBenchmarks, and the samples, as files in
+// testdata relative to the directory of the _test.go file. The benchmarks read
+// the largest observed occurrence of the element from its sample file, decode
+// it into its generated type, and encode it again, so that changes to the
+// performance of the generated types, for example after changing how fields
+// are generated, can be measured over time. It returns an error if
+// WithValueSanitizer is set, as no samples are recorded, or if the package
+// name is empty, as the benchmarks must be in the package of the types.
+func (g *Generator) GenerateBenchmarks() ([]byte, []EmittedFile, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.generateBenchmarks(g.generateOptions())
+}
+
+// generateBenchmarks returns the Go source of the benchmarks and their sample
+// files using options.
+func (g *Generator) generateBenchmarks(options *generateOptions) ([]byte, []EmittedFile, error) {
+	if g.valueSanitizer != nil {
+		return nil, nil, errors.New("benchmarks: samples are not recorded when a value sanitizer is set")
+	}
+	if options.packageName == "" {
+		return nil, nil, errors.New("benchmarks: package name is empty")
+	}
+
+	buildConstraint, err := g.buildConstraint()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sourceBuilder := &strings.Builder{}
+	writeSourceHeader(sourceBuilder, options.header, buildConstraint)
+	fmt.Fprintf(sourceBuilder, "package %s\n", options.packageName)
+
+	var sampleElements []*element
+	for _, typeElement := range g.sortedTypeElements(options) {
+		if typeElement.root && len(typeElement.sample) != 0 {
+			sampleElements = append(sampleElements, typeElement)
+		}
+	}
+	if len(sampleElements) != 0 {
+		fmt.Fprintf(sourceBuilder, "\nimport (\n")
+		fmt.Fprintf(sourceBuilder, "\t\"encoding/xml\"\n")
+		fmt.Fprintf(sourceBuilder, "\t\"os\"\n")
+		fmt.Fprintf(sourceBuilder, "\t\"testing\"\n")
+		fmt.Fprintf(sourceBuilder, ")\n")
+		fmt.Fprintf(sourceBuilder, "\n// readBenchmarkSample returns the contents of the sample file name.\n")
+		fmt.Fprintf(sourceBuilder, "func readBenchmarkSample(b *testing.B, name string) []byte {\n")
+		fmt.Fprintf(sourceBuilder, "\tb.Helper()\n")
+		fmt.Fprintf(sourceBuilder, "\tsample, err := os.ReadFile(name)\n")
+		fmt.Fprintf(sourceBuilder, "\tif err != nil {\n")
+		fmt.Fprintf(sourceBuilder, "\t\tb.Fatal(err)\n")
+		fmt.Fprintf(sourceBuilder, "\t}\n")
+		fmt.Fprintf(sourceBuilder, "\treturn sample\n")
+		fmt.Fprintf(sourceBuilder, "}\n")
+	}
+	sampleFiles := make([]EmittedFile, 0, len(sampleElements))
+	for _, sampleElement := range sampleElements {
+		typeName := options.exportTypeNameFunc(sampleElement.name)
+		sampleFilename := "testdata/benchmark" + typeName + ".xml"
+		sampleFiles = append(sampleFiles, EmittedFile{
+			Name:     sampleFilename,
+			Contents: sampleElement.sample,
+		})
+		fmt.Fprintf(sourceBuilder, "\n// BenchmarkDecode%s decodes the largest observed %s.\n", typeName, sampleElement.name.Local)
+		fmt.Fprintf(sourceBuilder, "func BenchmarkDecode%s(b *testing.B) {\n", typeName)
+		fmt.Fprintf(sourceBuilder, "\tsample := readBenchmarkSample(b, %q)\n", sampleFilename)
+		fmt.Fprintf(sourceBuilder, "\tb.SetBytes(int64(len(sample)))\n")
+		fmt.Fprintf(sourceBuilder, "\tb.ReportAllocs()\n")
+		fmt.Fprintf(sourceBuilder, "\tb.ResetTimer()\n")
+		fmt.Fprintf(sourceBuilder, "\tfor i := 0; i < b.N; i++ {\n")
+		fmt.Fprintf(sourceBuilder, "\t\tvar v %s\n", typeName)
+		fmt.Fprintf(sourceBuilder, "\t\tif err := xml.Unmarshal(sample, &v); err != nil {\n")
+		fmt.Fprintf(sourceBuilder, "\t\t\tb.Fatal(err)\n")
+		fmt.Fprintf(sourceBuilder, "\t\t}\n")
+		fmt.Fprintf(sourceBuilder, "\t}\n")
+		fmt.Fprintf(sourceBuilder, "}\n")
+		fmt.Fprintf(sourceBuilder, "\n// BenchmarkEncode%s encodes the largest observed %s.\n", typeName, sampleElement.name.Local)
+		fmt.Fprintf(sourceBuilder, "func BenchmarkEncode%s(b *testing.B) {\n", typeName)
+		fmt.Fprintf(sourceBuilder, "\tsample := readBenchmarkSample(b, %q)\n", sampleFilename)
+		fmt.Fprintf(sourceBuilder, "\tvar v %s\n", typeName)
+		fmt.Fprintf(sourceBuilder, "\tif err := xml.Unmarshal(sample, &v); err != nil {\n")
+		fmt.Fprintf(sourceBuilder, "\t\tb.Fatal(err)\n")
+		fmt.Fprintf(sourceBuilder, "\t}\n")
+		fmt.Fprintf(sourceBuilder, "\tb.SetBytes(int64(len(sample)))\n")
+		fmt.Fprintf(sourceBuilder, "\tb.ReportAllocs()\n")
+		fmt.Fprintf(sourceBuilder, "\tb.ResetTimer()\n")
+		fmt.Fprintf(sourceBuilder, "\tfor i := 0; i < b.N; i++ {\n")
+		fmt.Fprintf(sourceBuilder, "\t\tif _, err := xml.Marshal(&v); err != nil {\n")
+		fmt.Fprintf(sourceBuilder, "\t\t\tb.Fatal(err)\n")
+		fmt.Fprintf(sourceBuilder, "\t\t}\n")
+		fmt.Fprintf(sourceBuilder, "\t}\n")
+		fmt.Fprintf(sourceBuilder, "}\n")
+	}
+	return g.formatModuleSource(sourceBuilder.String()), sampleFiles, nil
+}
+
+// observeSample records sample as the sample of e if it is larger than e's
+// current sample.
+func (e *element) observeSample(sample []byte) {
+	if len(sample) > len(e.sample) {
+		e.sample = bytes.Clone(sample)
+	}
+}
+
+// A sampleRecorder records the input of an XML decoder so that the bytes of
+// elements can be recovered from the decoder's input offsets. When the decoder
+// switches to a charset reader, the recorder switches from recording the raw
+// input to recording the converted input, so that offsets remain consistent.
+type sampleRecorder struct {
+	buffer    bytes.Buffer
+	offset    int64 // The input offset of the start of buffer.
+	converted bool
+}
+
+// A sampleRecorderWriter is an io.Writer that records to a sampleRecorder
+// while it is recording converted or raw input, matching converted.
+type sampleRecorderWriter struct {
+	recorder  *sampleRecorder
+	converted bool
+}
+
+func (w sampleRecorderWriter) Write(p []byte) (int, error) {
+	if w.recorder.converted == w.converted {
+		w.recorder.buffer.Write(p)
+	}
+	return len(p), nil
+}
+
+// rawReader returns a reader that records r.
+func (s *sampleRecorder) rawReader(r io.Reader) io.Reader {
+	return io.TeeReader(r, sampleRecorderWriter{recorder: s})
+}
+
+// convertedReader returns a reader that records r, which continues the input
+// at offset after conversion to another charset.
+func (s *sampleRecorder) convertedReader(r io.Reader, offset int64) io.Reader {
+	s.buffer.Truncate(int(offset - s.offset))
+	s.converted = true
+	return io.TeeReader(r, sampleRecorderWriter{recorder: s, converted: true})
+}
+
+// sample returns the recorded input between the offsets start and end, and
+// discards all recorded input before end.
+func (s *sampleRecorder) sample(start, end int64) []byte {
+	sample := s.buffer.Bytes()[start-s.offset : end-s.offset]
+	s.buffer.Next(int(end - s.offset))
+	s.offset = end
+	return sample
+}
@@ -0,0 +1,137 @@
+package xmlstruct_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestGenerateBenchmarks(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithBenchmarks(true),
+		xmlstruct.WithPackageName("orders"),
+	)
+	assert.NoError(t, generator.ObserveFragments(strings.NewReader(joinLines(
+		`<order><id>1</id></order>`,
+		`<order><id>2</id><note>"x"</note></order>`,
+		`<order><id>3</id></order>`,
+	))))
+
+	actual, sampleFiles, err := generator.GenerateBenchmarks()
+	assert.NoError(t, err)
+	assert.Equal(t, joinLines(
+		xmlstruct.DefaultHeader,
+		"",
+		"package orders",
+		"",
+		"import (",
+		"\t\"encoding/xml\"",
+		"\t\"os\"",
+		"\t\"testing\"",
+		")",
+		"",
+		"// readBenchmarkSample returns the contents of the sample file name.",
+		"func readBenchmarkSample(b *testing.B, name string) []byte {",
+		"\tb.Helper()",
+		"\tsample, err := os.ReadFile(name)",
+		"\tif err != nil {",
+		"\t\tb.Fatal(err)",
+		"\t}",
+		"\treturn sample",
+		"}",
+		"",
+		"// BenchmarkDecodeOrder decodes the largest observed order.",
+		"func BenchmarkDecodeOrder(b *testing.B) {",
+		"\tsample := readBenchmarkSample(b, \"testdata/benchmarkOrder.xml\")",
+		"\tb.SetBytes(int64(len(sample)))",
+		"\tb.ReportAllocs()",
+		"\tb.ResetTimer()",
+		"\tfor i := 0; i < b.N; i++ {",
+		"\t\tvar v Order",
+		"\t\tif err := xml.Unmarshal(sample, &v); err != nil {",
+		"\t\t\tb.Fatal(err)",
+		"\t\t}",
+		"\t}",
+		"}",
+		"",
+		"// BenchmarkEncodeOrder encodes the largest observed order.",
+		"func BenchmarkEncodeOrder(b *testing.B) {",
+		"\tsample := readBenchmarkSample(b, \"testdata/benchmarkOrder.xml\")",
+		"\tvar v Order",
+		"\tif err := xml.Unmarshal(sample, &v); err != nil {",
+		"\t\tb.Fatal(err)",
+		"\t}",
+		"\tb.SetBytes(int64(len(sample)))",
+		"\tb.ReportAllocs()",
+		"\tb.ResetTimer()",
+		"\tfor i := 0; i < b.N; i++ {",
+		"\t\tif _, err := xml.Marshal(&v); err != nil {",
+		"\t\t\tb.Fatal(err)",
+		"\t\t}",
+		"\t}",
+		"}",
+	), string(actual))
+	assert.Equal(t, []xmlstruct.EmittedFile{
+		{
+			Name:     "testdata/benchmarkOrder.xml",
+			Contents: []byte(`<order><id>2</id><note>"x"</note></order>`),
+		},
+	}, sampleFiles)
+}
+
+func TestGenerateBenchmarksEmptyPackageName(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithBenchmarks(true),
+		xmlstruct.WithPackageName(""),
+	)
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(`<a/>`)))
+
+	_, _, err := generator.GenerateBenchmarks()
+	assert.EqualError(t, err, "benchmarks: package name is empty")
+}
+
+func TestGenerateBenchmarksCharset(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithBenchmarks(true),
+	)
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(
+		"<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n<a>caf\xe9</a>",
+	)))
+
+	_, sampleFiles, err := generator.GenerateBenchmarks()
+	assert.NoError(t, err)
+	assert.Equal(t, []xmlstruct.EmittedFile{
+		{
+			Name:     "testdata/benchmarkA.xml",
+			Contents: []byte("<a>café</a>"),
+		},
+	}, sampleFiles)
+}
+
+func TestGenerateBenchmarksValueSanitizer(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithBenchmarks(true),
+		xmlstruct.WithValueSanitizer(func(path, value string) string {
+			return "redacted"
+		}),
+	)
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(`<a><ssn>123-45-6789</ssn></a>`)))
+
+	_, _, err := generator.GenerateBenchmarks()
+	assert.Error(t, err)
+
+	state := &strings.Builder{}
+	assert.NoError(t, generator.SaveState(state))
+	assert.False(t, strings.Contains(state.String(), `"sample"`))
+}
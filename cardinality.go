@@ -0,0 +1,53 @@
+package xmlstruct
+
+// Unbounded is the maximum number of occurrences of a field that can occur any
+// number of times, like maxOccurs="unbounded" in XML Schema.
+const Unbounded = -1
+
+// A CardinalityPrecedence determines the cardinality of fields whose declared
+// cardinality, set with WithCardinality, disagrees with their observed
+// cardinality.
+type CardinalityPrecedence int
+
+const (
+	// CardinalityPrecedenceUnion makes fields optional if they are declared or
+	// observed to be optional, and repeated if they are declared or observed
+	// to be repeated, so that every declared and observed document can be
+	// decoded.
+	CardinalityPrecedenceUnion CardinalityPrecedence = iota
+	// CardinalityPrecedenceDeclared uses the declared cardinality.
+	CardinalityPrecedenceDeclared
+	// CardinalityPrecedenceObserved uses the observed cardinality, ignoring
+	// declared cardinalities.
+	CardinalityPrecedenceObserved
+)
+
+// A cardinalityDeclaration declares the cardinality of the fields matched by
+// matcher.
+type cardinalityDeclaration struct {
+	minOccurs int
+	maxOccurs int
+	matcher   FieldMatcher
+}
+
+// cardinality returns whether the field with path fieldPath, which was
+// observed to be optional and repeated, is optional and repeated, according to
+// options' cardinality declarations and precedence.
+func (options *generateOptions) cardinality(fieldPath string, optional, repeated bool) (bool, bool) {
+	for _, cardinalityDeclaration := range options.cardinalities {
+		if !cardinalityDeclaration.matcher(fieldPath) {
+			continue
+		}
+		declaredOptional := cardinalityDeclaration.minOccurs == 0
+		declaredRepeated := cardinalityDeclaration.maxOccurs == Unbounded || cardinalityDeclaration.maxOccurs > 1
+		switch options.cardinalityPrecedence {
+		case CardinalityPrecedenceDeclared:
+			return declaredOptional, declaredRepeated
+		case CardinalityPrecedenceObserved:
+			return optional, repeated
+		default:
+			return optional || declaredOptional, repeated || declaredRepeated
+		}
+	}
+	return optional, repeated
+}
@@ -0,0 +1,135 @@
+package xmlstruct
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// A CheckpointStore stores checkpoints of the model of a Generator, in the
+// format written by SaveState, outside the Generator's process, for example in
+// a file or in an embedded key/value database, so that observation of a large
+// corpus can be interrupted and resumed. Checkpoints do not reduce the memory
+// used by the Generator: the whole model is always held in memory, and every
+// checkpoint writes the whole model.
+type CheckpointStore interface {
+	// Load returns the model saved most recently. It returns an error for which
+	// errors.Is(err, fs.ErrNotExist) is true if no model has been saved.
+	Load() (io.ReadCloser, error)
+	// Save saves the model written by write.
+	Save(write func(io.Writer) error) error
+}
+
+// A FileCheckpointStore is a CheckpointStore that stores the model in a single
+// file.
+type FileCheckpointStore struct {
+	name string
+}
+
+// NewFileCheckpointStore returns a new FileCheckpointStore that stores the
+// model in the file name.
+func NewFileCheckpointStore(name string) *FileCheckpointStore {
+	return &FileCheckpointStore{
+		name: name,
+	}
+}
+
+// Load implements CheckpointStore.Load.
+func (s *FileCheckpointStore) Load() (io.ReadCloser, error) {
+	return os.Open(s.name)
+}
+
+// Save implements CheckpointStore.Save. The model is written to a temporary file
+// that then replaces s's file, so that the previous model is kept if saving is
+// interrupted.
+func (s *FileCheckpointStore) Save(write func(io.Writer) error) error {
+	file, err := os.CreateTemp(filepath.Dir(s.name), filepath.Base(s.name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), s.name)
+}
+
+// WithCheckpointStore sets the store in which the Generator checkpoints its
+// model. The model is saved after every interval observed XML documents,
+// streams of fragments, elements, or Go sources, as set with
+// WithCheckpointInterval, and when SaveCheckpoint is called, and is restored
+// from the store with LoadCheckpoint. Snapshots do not share the store.
+func WithCheckpointStore(checkpointStore CheckpointStore) GeneratorOption {
+	return func(g *Generator) {
+		g.checkpointStore = checkpointStore
+	}
+}
+
+// WithCheckpointInterval sets the number of observations after which the model
+// is saved to the store set with WithCheckpointStore. Each checkpoint writes
+// the whole model, so checkpointing after every observation of a large corpus
+// is slow.
+func WithCheckpointInterval(checkpointInterval int) GeneratorOption {
+	return func(g *Generator) {
+		g.checkpointInterval = checkpointInterval
+	}
+}
+
+// SaveCheckpoint saves g's model to the store set with WithCheckpointStore if
+// it has changed since it was last saved. It should be called when observation
+// is complete, or interrupted, so that the last observations are not lost. It
+// does nothing if g does not have a store.
+func (g *Generator) SaveCheckpoint() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.checkpointStore == nil || g.unsavedObservations == 0 {
+		return nil
+	}
+	return g.saveCheckpoint()
+}
+
+// LoadCheckpoint replaces the model of g with the model saved in the store set
+// with WithCheckpointStore, like LoadState. It does nothing if g does not have
+// a store or if no model has been saved.
+func (g *Generator) LoadCheckpoint() error {
+	if g.checkpointStore == nil {
+		return nil
+	}
+	readCloser, err := g.checkpointStore.Load()
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil
+	case err != nil:
+		return err
+	}
+	defer readCloser.Close()
+	return g.LoadState(readCloser)
+}
+
+// checkpoint records an observation and saves g's model to its store, if it
+// has one, every g.checkpointInterval observations. g.mu must be held.
+func (g *Generator) checkpoint() error {
+	if g.checkpointStore == nil {
+		return nil
+	}
+	g.unsavedObservations++
+	if g.unsavedObservations < g.checkpointInterval {
+		return nil
+	}
+	return g.saveCheckpoint()
+}
+
+// saveCheckpoint saves g's model to its store. g.mu must be held.
+func (g *Generator) saveCheckpoint() error {
+	if err := g.checkpointStore.Save(g.saveState); err != nil {
+		return err
+	}
+	g.unsavedObservations = 0
+	return nil
+}
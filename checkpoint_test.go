@@ -0,0 +1,71 @@
+package xmlstruct_test
+
+import (
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestFileCheckpointStore(t *testing.T) {
+	t.Parallel()
+
+	checkpointStore := xmlstruct.NewFileCheckpointStore(filepath.Join(t.TempDir(), "checkpoint.json"))
+
+	emptyGenerator := xmlstruct.NewGenerator(xmlstruct.WithCheckpointStore(checkpointStore))
+	assert.NoError(t, emptyGenerator.LoadCheckpoint())
+	assert.Equal(t, xmlstruct.NewGenerator().Fingerprint(), emptyGenerator.Fingerprint())
+
+	generator := xmlstruct.NewGenerator(xmlstruct.WithCheckpointStore(checkpointStore))
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(`<a x="1"><b>2</b></a>`)))
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(`<a><c/></a>`)))
+	assert.NoError(t, generator.SaveCheckpoint())
+
+	loadedGenerator := xmlstruct.NewGenerator(xmlstruct.WithCheckpointStore(checkpointStore))
+	assert.NoError(t, loadedGenerator.LoadCheckpoint())
+	assert.Equal(t, generator.Fingerprint(), loadedGenerator.Fingerprint())
+
+	assert.NoError(t, loadedGenerator.ObserveReader(strings.NewReader(`<a><d/></a>`)))
+	assert.NoError(t, loadedGenerator.SaveCheckpoint())
+	resumedGenerator := xmlstruct.NewGenerator(xmlstruct.WithCheckpointStore(checkpointStore))
+	assert.NoError(t, resumedGenerator.LoadCheckpoint())
+	assert.Equal(t, loadedGenerator.Fingerprint(), resumedGenerator.Fingerprint())
+	assert.NotEqual(t, generator.Fingerprint(), resumedGenerator.Fingerprint())
+}
+
+func TestCheckpointInterval(t *testing.T) {
+	t.Parallel()
+
+	checkpointStore := &countingCheckpointStore{}
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithCheckpointStore(checkpointStore),
+		xmlstruct.WithCheckpointInterval(2),
+	)
+	for range 5 {
+		assert.NoError(t, generator.ObserveReader(strings.NewReader(`<a/>`)))
+	}
+	assert.Equal(t, 2, checkpointStore.saves)
+	assert.NoError(t, generator.SaveCheckpoint())
+	assert.Equal(t, 3, checkpointStore.saves)
+	assert.NoError(t, generator.SaveCheckpoint())
+	assert.Equal(t, 3, checkpointStore.saves)
+}
+
+// A countingCheckpointStore is a CheckpointStore that counts saves.
+type countingCheckpointStore struct {
+	saves int
+}
+
+func (s *countingCheckpointStore) Load() (io.ReadCloser, error) {
+	return nil, fs.ErrNotExist
+}
+
+func (s *countingCheckpointStore) Save(write func(io.Writer) error) error {
+	s.saves++
+	return write(io.Discard)
+}
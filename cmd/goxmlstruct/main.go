@@ -2,36 +2,221 @@
 package main
 
 import (
+	"bytes"
+	"encoding/xml"
+	"errors"
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/twpayne/go-xmlstruct"
 )
 
 var (
+	anonymizationEnumThreshold   = flag.Int("anonymization-enum-threshold", xmlstruct.DefaultAnonymizationEnumThreshold, "maximum number of distinct values that -anonymize-corpus keeps as enumeration members")
+	anonymizationKeyFile         = flag.String("anonymization-key-file", "", "file containing the secret key from which -anonymize-corpus derives synthetic values")
+	anonymizeCorpus              = flag.String("anonymize-corpus", "", "write copies of the input files with synthetic values to this directory")
+	attrMapThreshold             = flag.Int("attr-map-threshold", xmlstruct.DefaultAttrMapThreshold, "maximum number of distinct attributes of an element before they are decoded into a map")
+	benchmarks                   = flag.Bool("benchmarks", xmlstruct.DefaultBenchmarks, "generate decode and encode benchmarks of the largest observed documents, writing them to a _test.go file next to -output and their samples to testdata")
+	cardinalityPrecedence        = flag.String("cardinality-precedence", "union", "precedence of declared and observed cardinalities: union, declared, observed")
 	charDataFieldName            = flag.String("char-data-field-name", xmlstruct.DefaultCharDataFieldName, "char data field name")
+	charDataFieldNameFromElement = flag.Bool("char-data-field-name-from-element", xmlstruct.DefaultCharDataFieldNameFromElement, "name char data fields after their element")
+	checkpointFile               = flag.String("checkpoint", "", "load the model from this checkpoint file, if it exists, and checkpoint it periodically and after observing all inputs, without reading stdin if there are no input files")
+	checkpointInterval           = flag.Int("checkpoint-interval", xmlstruct.DefaultCheckpointInterval, "number of inputs observed between checkpoints")
+	charsetPrecedence            = flag.String("charset-precedence", "declaration", "source of the charset of documents with both a byte order mark and an XML declaration: declaration, bom")
+	commonFieldExtraction        = flag.Int("common-field-extraction", xmlstruct.DefaultCommonFieldExtraction, "minimum number of types sharing attributes to extract them into an embedded struct")
 	compactTypes                 = flag.Bool("compact-types", xmlstruct.DefaultCompactTypes, "create compact types")
+	decimalSeparator             = flag.String("decimal-separator", ".", "decimal separator in numbers")
+	deepCopyGenMarkers           = flag.Bool("deep-copy-gen-markers", xmlstruct.DefaultDeepCopyGenMarkers, "add +k8s:deepcopy-gen markers to types")
+	deepCopyMethods              = flag.Bool("deep-copy-methods", xmlstruct.DefaultDeepCopyMethods, "generate DeepCopyInto and DeepCopy methods")
+	deprecatedBuildTag           = flag.String("deprecated-build-tag", "", "exclude deprecated fields unless built with this build tag, writing them to a separate file next to -output")
+	disallowExponents            = flag.Bool("disallow-exponents", false, "do not infer numbers in scientific notation")
+	emitter                      = flag.String("emitter", xmlstruct.DefaultEmitter, "emitter of the output, one of "+strings.Join(xmlstruct.Emitters(), ", ")+", writing its files to the -output directory unless it writes a single file")
+	exampleTags                  = flag.Bool("example-tags", xmlstruct.DefaultExampleTags, "add example tags with observed values")
 	formatSource                 = flag.Bool("format-source", xmlstruct.DefaultFormatSource, "format source")
+	fallbackCharsets             = flag.String("fallback-charsets", "", "comma-separated charsets with which to decode bytes that are not valid UTF-8, for example windows-1252")
+	fragments                    = flag.Bool("fragments", false, "observe concatenated XML fragments")
+	generatedFileSuffix          = flag.String("generated-file-suffix", xmlstruct.DefaultGeneratedFileSuffix, "suffix of generated file names in a Go module")
 	header                       = flag.String("header", xmlstruct.DefaultHeader, "header")
+	htmlEntities                 = flag.Bool("html-entities", false, "define HTML entities like &nbsp;")
+	identityConstraints          = flag.Bool("identity-constraints", false, "report candidate key and reference attributes")
 	ignoreNamespaces             = flag.Bool("ignore-namespaces", true, "ignore namespaces")
 	imports                      = flag.Bool("imports", xmlstruct.DefaultImports, "generate import statements")
+	indexFuncs                   = flag.Bool("index-funcs", xmlstruct.DefaultIndexFuncs, "generate functions that index named types by their key attributes")
 	intType                      = flag.String("int-type", xmlstruct.DefaultIntType, "int type")
+	keyUniquenessThreshold       = flag.Float64("key-uniqueness-threshold", xmlstruct.DefaultKeyUniquenessThreshold, "minimum fraction of distinct values of a candidate key attribute")
+	lowerCaseNames               = flag.Bool("lower-case-names", false, "convert element and attribute names to lower case before observation")
+	metadata                     = flag.Bool("metadata", false, "write "+xmlstruct.MetadataFilename+" next to the generated code")
+	minOccurrenceRate            = flag.Float64("min-occurrence-rate", xmlstruct.DefaultMinOccurrenceRate, "minimum fraction of documents in which an element must occur to be generated")
+	minimizeCorpus               = flag.Bool("minimize-corpus", false, "print a minimal subset of the input files that exercises the same model")
+	modulePath                   = flag.String("module-path", "", "generate a Go module with this module path in the output directory")
 	namedRoot                    = flag.Bool("named-root", xmlstruct.DefaultNamedRoot, "create an XMLName field for the root element")
 	namedTypes                   = flag.Bool("named-types", xmlstruct.DefaultNamedTypes, "create named types for all elements")
 	noEmptyElements              = flag.Bool("no-empty-elements", !xmlstruct.DefaultEmptyElements, "use type string instead of struct{} for empty elements")
 	noExport                     = flag.Bool("no-export", false, "create unexported types")
 	output                       = flag.String("output", "", "output filename")
 	packageName                  = flag.String("package-name", "main", "package name")
+	pointerPolicy                = flag.String("pointer-policy", "", "comma-separated fields that use pointers, overriding -use-pointers-for-optional-fields: optional-scalars, optional-structs, repeated-elements, required-structs, generic-optional-scalars, sql-null-optional-scalars, package-optional-scalars")
+	preserveLeadingZeros         = flag.Bool("preserve-leading-zeros", xmlstruct.DefaultPreserveLeadingZeros, "treat numbers with leading zeros as strings")
 	preserveOrder                = flag.Bool("preserve-order", xmlstruct.DefaultPreserveOrder, "preserve order of types and fields")
+	preserveWhitespaceCharData   = flag.Bool("preserve-whitespace-char-data", xmlstruct.DefaultPreserveWhitespaceCharData, "observe chardata that is only whitespace in elements without child elements")
+	reservedIdentifierSuffix     = flag.String("reserved-identifier-suffix", "", "suffix for Go keywords and predeclared identifiers instead of a prefix")
+	resolveEntities              = flag.Bool("resolve-entities", false, "define the entities declared in DOCTYPEs, reading external entities from the files named by their system identifiers")
+	rootRegistry                 = flag.Bool("root-registry", xmlstruct.DefaultRootRegistry, "generate a Roots registry of top level element types and a DecodeAny function")
+	thousandsSeparator           = flag.String("thousands-separator", "", "thousands separator in numbers")
+	stripBOM                     = flag.Bool("strip-bom", xmlstruct.DefaultStripBOM, "remove byte order marks at the start of documents")
 	timeLayout                   = flag.String("time-layout", "2006-01-02T15:04:05Z", "time layout")
+	timeZonePolicy               = flag.String("time-zone-policy", "none", "type of times whose layout has no time zone offset: none, utc, local, raw")
 	topLevelAttributes           = flag.Bool("top-level-attributes", xmlstruct.DefaultTopLevelAttributes, "include top level attributes")
+	typeConfidenceThreshold      = flag.Float64("type-confidence-threshold", xmlstruct.DefaultTypeConfidenceThreshold, "minimum fraction of values of a more specific type than string for the type conflict policy to apply")
+	typeConflictPolicy           = flag.String("type-conflict-policy", "string", "type of values that are not all of the same type: string, keep, tolerant")
+	typeFiles                    = flag.Bool("type-files", false, "write each type to its own file in the -output directory, rewriting only changed files and removing stale ones")
 	typesOnly                    = flag.Bool("types-only", false, "generate structs only, without header, package, or imports")
 	usePointersForOptionalFields = flag.Bool("use-pointers-for-optional-fields", xmlstruct.DefaultUsePointersForOptionalFields, "use pointers for optional fields")
 	useRawToken                  = flag.Bool("use-raw-token", xmlstruct.DefaultUseRawToken, "use encoding/xml.Decoder.RawToken")
+	valueCollation               = flag.String("value-collation", "", "comma-separated example value collation: case-insensitive, numeric")
+	valueExamples                = flag.Int("value-examples", xmlstruct.DefaultValueExamples, "number of example values in comments")
+	verbose                      = flag.Bool("verbose", false, "log observation timings, skipped elements, and inference decisions to stderr")
 )
 
 func run() error {
+	var anyContent []xmlstruct.GeneratorOption
+	flag.Func("any-attrs", "add a catch-all field for unobserved attributes to elements matching a path pattern", func(s string) error {
+		anyContent = append(anyContent, xmlstruct.WithAnyAttrs(xmlstruct.MatchPath(s)))
+		return nil
+	})
+	flag.Func("any-elements", "add a catch-all field for unobserved child elements to elements matching a path pattern", func(s string) error {
+		anyContent = append(anyContent, xmlstruct.WithAnyElements(xmlstruct.MatchPath(s)))
+		return nil
+	})
+	var buildTags []string
+	flag.Func("build-tag", "add a build constraint expression to the generated source", func(s string) error {
+		buildTags = append(buildTags, s)
+		return nil
+	})
+	var cardinalities []xmlstruct.GeneratorOption
+	flag.Func("cardinality", "declare the cardinality of fields matching a path pattern, as pattern=min:max where max can be unbounded", func(s string) error {
+		pattern, minAndMaxOccurs, ok := strings.Cut(s, "=")
+		if !ok {
+			return fmt.Errorf("%s: missing =", s)
+		}
+		minOccursStr, maxOccursStr, ok := strings.Cut(minAndMaxOccurs, ":")
+		if !ok {
+			return fmt.Errorf("%s: missing :", s)
+		}
+		minOccurs, err := strconv.Atoi(minOccursStr)
+		if err != nil {
+			return err
+		}
+		maxOccurs := xmlstruct.Unbounded
+		if maxOccursStr != "unbounded" {
+			maxOccurs, err = strconv.Atoi(maxOccursStr)
+			if err != nil {
+				return err
+			}
+		}
+		cardinalities = append(cardinalities, xmlstruct.WithCardinality(minOccurs, maxOccurs, xmlstruct.MatchPath(pattern)))
+		return nil
+	})
+	var deprecations []xmlstruct.GeneratorOption
+	flag.Func("deprecated", "mark fields matching a path pattern as deprecated, as pattern=message", func(s string) error {
+		pattern, message, ok := strings.Cut(s, "=")
+		if !ok {
+			return fmt.Errorf("%s: missing =", s)
+		}
+		deprecations = append(deprecations, xmlstruct.WithDeprecated(xmlstruct.MatchPath(pattern), message))
+		return nil
+	})
+	entities := make(map[string]string)
+	flag.Func("entity", "define an entity, as name=value", func(s string) error {
+		name, value, ok := strings.Cut(s, "=")
+		if !ok {
+			return fmt.Errorf("%s: missing =", s)
+		}
+		entities[name] = value
+		return nil
+	})
+	var elementFilters []xmlstruct.GeneratorOption
+	for _, elementFilterFlag := range []struct {
+		name             string
+		usage            string
+		newElementFilter func(string, string, string) xmlstruct.ElementFilter
+	}{
+		{
+			name:             "only-element-with-attr",
+			usage:            "observe only elements with an attribute value, as element@attribute=value",
+			newElementFilter: xmlstruct.OnlyElementsWithAttr,
+		},
+		{
+			name:             "skip-element-with-attr",
+			usage:            "skip elements with an attribute value, as element@attribute=value",
+			newElementFilter: xmlstruct.SkipElementsWithAttr,
+		},
+	} {
+		flag.Func(elementFilterFlag.name, elementFilterFlag.usage, func(s string) error {
+			elementName, attrNameAndValue, ok := strings.Cut(s, "@")
+			if !ok {
+				return fmt.Errorf("%s: missing @", s)
+			}
+			attrName, attrValue, ok := strings.Cut(attrNameAndValue, "=")
+			if !ok {
+				return fmt.Errorf("%s: missing =", s)
+			}
+			elementFilter := elementFilterFlag.newElementFilter(elementName, attrName, attrValue)
+			elementFilters = append(elementFilters, xmlstruct.WithElementFilter(elementFilter))
+			return nil
+		})
+	}
+	var tokenFilters []xmlstruct.TokenFilter
+	flag.Func("rename-namespace", "rename a namespace before observation, as old=new", func(s string) error {
+		oldSpace, newSpace, ok := strings.Cut(s, "=")
+		if !ok {
+			return fmt.Errorf("%s: missing =", s)
+		}
+		tokenFilters = append(tokenFilters, xmlstruct.RenameNamespace(oldSpace, newSpace))
+		return nil
+	})
+	var redactExamples []xmlstruct.FieldMatcher
+	flag.Func("redact-example", "omit the example tags of fields matching a path pattern", func(s string) error {
+		redactExamples = append(redactExamples, xmlstruct.MatchPath(s))
+		return nil
+	})
+	var redactValues []xmlstruct.FieldMatcher
+	flag.Func("redact-value", "observe the values of attributes and chardata matching a path pattern as empty strings", func(s string) error {
+		redactValues = append(redactValues, xmlstruct.MatchPath(s))
+		return nil
+	})
+	var importMappings []xmlstruct.GeneratorOption
+	flag.Func("import-mapping", "map fields matching a path pattern to an imported type, as pattern=importpath:pkg.Type", func(s string) error {
+		pattern, importPathAndGoType, ok := strings.Cut(s, "=")
+		if !ok {
+			return fmt.Errorf("%s: missing =", s)
+		}
+		importPath, goType, ok := strings.Cut(importPathAndGoType, ":")
+		if !ok {
+			return fmt.Errorf("%s: missing :", s)
+		}
+		importMappings = append(importMappings, xmlstruct.WithImportMapping(importPath, goType, xmlstruct.MatchPath(pattern)))
+		return nil
+	})
+	mapElements := make(map[string]string)
+	flag.Func("map-element", "generate a map for an element keyed by an attribute, as element=attribute", func(s string) error {
+		elementName, keyAttrName, ok := strings.Cut(s, "=")
+		if !ok {
+			return fmt.Errorf("%s: missing =", s)
+		}
+		mapElements[elementName] = keyAttrName
+		return nil
+	})
+	var seedGoFiles []string
+	flag.Func("seed-go-file", "seed the generated types from the struct types in a Go source file, for example a previous output", func(s string) error {
+		seedGoFiles = append(seedGoFiles, s)
+		return nil
+	})
 	flag.Parse()
 
 	nameFunc := xmlstruct.IdentityNameFunc
@@ -39,6 +224,118 @@ func run() error {
 		nameFunc = xmlstruct.IgnoreNamespaceNameFunc
 	}
 
+	var precedence xmlstruct.CardinalityPrecedence
+	switch *cardinalityPrecedence {
+	case "union":
+		precedence = xmlstruct.CardinalityPrecedenceUnion
+	case "declared":
+		precedence = xmlstruct.CardinalityPrecedenceDeclared
+	case "observed":
+		precedence = xmlstruct.CardinalityPrecedenceObserved
+	default:
+		return fmt.Errorf("%s: unknown cardinality precedence", *cardinalityPrecedence)
+	}
+
+	var charsetPrecedenceValue xmlstruct.CharsetPrecedence
+	switch *charsetPrecedence {
+	case "declaration":
+		charsetPrecedenceValue = xmlstruct.CharsetPrecedenceDeclaration
+	case "bom":
+		charsetPrecedenceValue = xmlstruct.CharsetPrecedenceBOM
+	default:
+		return fmt.Errorf("%s: unknown charset precedence", *charsetPrecedence)
+	}
+
+	var fallbackCharsetLabels []string
+	if *fallbackCharsets != "" {
+		fallbackCharsetLabels = strings.Split(*fallbackCharsets, ",")
+	}
+
+	var conflictPolicy xmlstruct.TypeConflictPolicy
+	switch *typeConflictPolicy {
+	case "string":
+		conflictPolicy = xmlstruct.TypeConflictPolicyString
+	case "keep":
+		conflictPolicy = xmlstruct.TypeConflictPolicyKeep
+	case "tolerant":
+		conflictPolicy = xmlstruct.TypeConflictPolicyTolerant
+	default:
+		return fmt.Errorf("%s: unknown type conflict policy", *typeConflictPolicy)
+	}
+
+	var zonePolicy xmlstruct.TimeZonePolicy
+	switch *timeZonePolicy {
+	case "none":
+		zonePolicy = xmlstruct.TimeZonePolicyNone
+	case "utc":
+		zonePolicy = xmlstruct.TimeZonePolicyUTC
+	case "local":
+		zonePolicy = xmlstruct.TimeZonePolicyLocal
+	case "raw":
+		zonePolicy = xmlstruct.TimeZonePolicyRaw
+	default:
+		return fmt.Errorf("%s: unknown time zone policy", *timeZonePolicy)
+	}
+
+	var collation xmlstruct.ValueCollation
+	if *valueCollation != "" {
+		for _, name := range strings.Split(*valueCollation, ",") {
+			switch name {
+			case "case-insensitive":
+				collation |= xmlstruct.ValueCollationCaseInsensitive
+			case "numeric":
+				collation |= xmlstruct.ValueCollationNumeric
+			default:
+				return fmt.Errorf("%s: unknown value collation", name)
+			}
+		}
+	}
+
+	var policy *xmlstruct.PointerPolicy
+	if *pointerPolicy != "" {
+		policy = &xmlstruct.PointerPolicy{}
+		for _, name := range strings.Split(*pointerPolicy, ",") {
+			switch name {
+			case "optional-scalars":
+				policy.OptionalScalars = true
+			case "optional-structs":
+				policy.OptionalStructs = true
+			case "repeated-elements":
+				policy.RepeatedElements = true
+			case "required-structs":
+				policy.RequiredStructs = true
+			case "generic-optional-scalars":
+				policy.OptionalScalarWrapper = xmlstruct.OptionalScalarWrapperGeneric
+			case "sql-null-optional-scalars":
+				policy.OptionalScalarWrapper = xmlstruct.OptionalScalarWrapperSQLNull
+			case "package-optional-scalars":
+				policy.OptionalScalarWrapper = xmlstruct.OptionalScalarWrapperPackage
+			default:
+				return fmt.Errorf("%s: unknown pointer policy", name)
+			}
+		}
+	}
+
+	numberFormat := xmlstruct.NumberFormat{
+		DisallowExponents: *disallowExponents,
+	}
+	for _, separator := range []struct {
+		name  string
+		value string
+		rune  *rune
+	}{
+		{name: "decimal", value: *decimalSeparator, rune: &numberFormat.DecimalSeparator},
+		{name: "thousands", value: *thousandsSeparator, rune: &numberFormat.ThousandsSeparator},
+	} {
+		switch runes := []rune(separator.value); len(runes) {
+		case 0:
+		case 1:
+			*separator.rune = runes[0]
+		default:
+			return fmt.Errorf("%s: invalid %s separator", separator.value, separator.name)
+		}
+	}
+
 	if *typesOnly {
 		*header = ""
 		*imports = false
@@ -46,50 +343,355 @@ func run() error {
 	}
 
 	options := []xmlstruct.GeneratorOption{
+		xmlstruct.WithAnonymizationEnumThreshold(*anonymizationEnumThreshold),
+		xmlstruct.WithAttrMapThreshold(*attrMapThreshold),
+		xmlstruct.WithBenchmarks(*benchmarks),
+		xmlstruct.WithBuildTags(buildTags...),
+		xmlstruct.WithCardinalityPrecedence(precedence),
+		xmlstruct.WithCharsetPrecedence(charsetPrecedenceValue),
 		xmlstruct.WithCharDataFieldName(*charDataFieldName),
+		xmlstruct.WithCharDataFieldNameFromElement(*charDataFieldNameFromElement),
+		xmlstruct.WithCommonFieldExtraction(*commonFieldExtraction),
 		xmlstruct.WithCompactTypes(*compactTypes),
+		xmlstruct.WithDeepCopyGenMarkers(*deepCopyGenMarkers),
+		xmlstruct.WithDeepCopyMethods(*deepCopyMethods),
+		xmlstruct.WithDeprecatedBuildTag(*deprecatedBuildTag),
 		xmlstruct.WithEmptyElements(!*noEmptyElements),
+		xmlstruct.WithExampleTags(*exampleTags),
+		xmlstruct.WithFallbackCharsets(fallbackCharsetLabels...),
 		xmlstruct.WithFormatSource(*formatSource),
+		xmlstruct.WithGeneratedFileSuffix(*generatedFileSuffix),
 		xmlstruct.WithHeader(*header),
+		xmlstruct.WithIdentityConstraints(*identityConstraints || *indexFuncs),
 		xmlstruct.WithImports(*imports),
+		xmlstruct.WithIndexFuncs(*indexFuncs),
 		xmlstruct.WithIntType(*intType),
+		xmlstruct.WithKeyUniquenessThreshold(*keyUniquenessThreshold),
+		xmlstruct.WithMapElements(mapElements),
+		xmlstruct.WithMinOccurrenceRate(*minOccurrenceRate),
 		xmlstruct.WithNamedRoot(*namedRoot),
 		xmlstruct.WithNamedTypes(*namedTypes),
 		xmlstruct.WithNameFunc(nameFunc),
+		xmlstruct.WithNumberFormat(numberFormat),
 		xmlstruct.WithPackageName(*packageName),
+		xmlstruct.WithPreserveLeadingZeros(*preserveLeadingZeros),
 		xmlstruct.WithPreserveOrder(*preserveOrder),
+		xmlstruct.WithPreserveWhitespaceCharData(*preserveWhitespaceCharData),
+		xmlstruct.WithRootRegistry(*rootRegistry),
+		xmlstruct.WithStripBOM(*stripBOM),
 		xmlstruct.WithTimeLayout(*timeLayout),
+		xmlstruct.WithTimeZonePolicy(zonePolicy),
 		xmlstruct.WithTopLevelAttributes(*topLevelAttributes),
+		xmlstruct.WithTypeConfidenceThreshold(*typeConfidenceThreshold),
+		xmlstruct.WithTypeConflictPolicy(conflictPolicy),
 		xmlstruct.WithUsePointersForOptionalFields(*usePointersForOptionalFields),
 		xmlstruct.WithUseRawToken(*useRawToken),
+		xmlstruct.WithValueCollation(collation),
+		xmlstruct.WithValueExamples(*valueExamples),
+	}
+	options = append(options, importMappings...)
+	options = append(options, deprecations...)
+	options = append(options, elementFilters...)
+	if *lowerCaseNames {
+		tokenFilters = append(tokenFilters, xmlstruct.LowerCaseNames())
+	}
+	options = append(options, xmlstruct.WithTokenFilters(tokenFilters...))
+	if *htmlEntities {
+		for name, value := range xml.HTMLEntity {
+			if _, ok := entities[name]; !ok {
+				entities[name] = value
+			}
+		}
+	}
+	options = append(options, xmlstruct.WithEntities(entities))
+	if *verbose {
+		options = append(options, xmlstruct.WithLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		}))))
+	}
+	if *resolveEntities {
+		options = append(options, xmlstruct.WithEntityResolver(func(_, _, systemID string) (string, error) {
+			data, err := os.ReadFile(systemID)
+			return string(data), err
+		}))
+	}
+	options = append(options, cardinalities...)
+	options = append(options, anyContent...)
+	if len(redactExamples) > 0 {
+		options = append(options, xmlstruct.WithExampleRedactFunc(func(path, example string) string {
+			for _, redactExample := range redactExamples {
+				if redactExample(path) {
+					return ""
+				}
+			}
+			return example
+		}))
+	}
+	if len(redactValues) > 0 {
+		options = append(options, xmlstruct.WithValueSanitizer(func(path, value string) string {
+			for _, redactValue := range redactValues {
+				if redactValue(path) {
+					return ""
+				}
+			}
+			return value
+		}))
+	}
+	if policy != nil {
+		options = append(options, xmlstruct.WithPointerPolicy(*policy))
+	}
+	if *reservedIdentifierSuffix != "" {
+		options = append(options, xmlstruct.WithSanitizeIdentifierFunc(xmlstruct.SuffixSanitizeIdentifierFunc(*reservedIdentifierSuffix)))
 	}
 	if *noExport {
 		options = append(options, xmlstruct.WithExportTypeNameFunc(xmlstruct.DefaultUnexportNameFunc))
 	}
+	if *checkpointFile != "" {
+		options = append(options,
+			xmlstruct.WithCheckpointStore(xmlstruct.NewFileCheckpointStore(*checkpointFile)),
+			xmlstruct.WithCheckpointInterval(*checkpointInterval),
+		)
+	}
+	if *anonymizationKeyFile != "" {
+		anonymizationKey, err := os.ReadFile(*anonymizationKeyFile)
+		if err != nil {
+			return err
+		}
+		options = append(options, xmlstruct.WithAnonymizationKey(anonymizationKey))
+	}
+
 	generator := xmlstruct.NewGenerator(options...)
 
-	if flag.NArg() == 0 {
-		if err := generator.ObserveReader(os.Stdin); err != nil {
+	if *minimizeCorpus {
+		names, err := generator.MinimizeCorpus(flag.Args())
+		if err != nil {
 			return err
 		}
-	} else {
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return nil
+	}
+
+	if *checkpointFile != "" {
+		if err := generator.LoadCheckpoint(); err != nil {
+			return fmt.Errorf("%s: %w", *checkpointFile, err)
+		}
+	}
+
+	for _, seedGoFile := range seedGoFiles {
+		if err := generator.ObserveGoFile(seedGoFile); err != nil {
+			return err
+		}
+	}
+
+	observeReader, observeFile := generator.ObserveReader, generator.ObserveFile
+	if *fragments {
+		observeReader, observeFile = generator.ObserveFragments, generator.ObserveFragmentsFile
+	}
+	switch {
+	case flag.NArg() == 0 && *checkpointFile == "":
+		if err := observeReader(os.Stdin); err != nil {
+			return err
+		}
+	default:
 		for _, arg := range flag.Args() {
-			if err := generator.ObserveFile(arg); err != nil {
+			if err := observeFile(arg); err != nil {
 				return err
 			}
 		}
 	}
+	if err := generator.SaveCheckpoint(); err != nil {
+		return fmt.Errorf("%s: %w", *checkpointFile, err)
+	}
 
-	source, err := generator.Generate()
+	if *anonymizeCorpus != "" {
+		return anonymize(generator, flag.Args(), *anonymizeCorpus)
+	}
+
+	typeConflicts, err := generator.TypeConflicts()
 	if err != nil {
 		return err
 	}
+	for _, typeConflict := range typeConflicts {
+		if typeConflict.Confidence >= *typeConfidenceThreshold {
+			fmt.Fprintf(os.Stderr, "warning: %s: %d of %d values are %s, generating %s\n", typeConflict.Path, typeConflict.Conforming, typeConflict.Observations, typeConflict.Type, typeConflict.GoType)
+		}
+	}
+
+	if *identityConstraints {
+		if err := reportIdentityConstraints(generator); err != nil {
+			return err
+		}
+	}
+
+	if *metadata && *output == "" {
+		return errors.New("-metadata requires -output")
+	}
+
+	if *emitter != xmlstruct.DefaultEmitter {
+		return emit(generator, *emitter, *output)
+	}
+
+	if *modulePath != "" {
+		if *output == "" {
+			return errors.New("-module-path requires -output")
+		}
+		if err := generator.GenerateModule(*output, *modulePath); err != nil {
+			return err
+		}
+		if *metadata {
+			return writeMetadata(generator, *output)
+		}
+		return nil
+	}
+
+	if *typeFiles {
+		if *output == "" {
+			return errors.New("-type-files requires -output")
+		}
+		if _, err := generator.GenerateTypeFiles(*output); err != nil {
+			return err
+		}
+		if *metadata {
+			return writeMetadata(generator, *output)
+		}
+		return nil
+	}
 
 	if *output == "" {
-		_, err := os.Stdout.Write(source)
+		return generator.GenerateTo(os.Stdout)
+	}
+	if err := writeGeneratedFile(generator, *output); err != nil {
+		return err
+	}
+	if *deprecatedBuildTag != "" {
+		deprecatedSource, err := generator.GenerateWithDeprecatedFields()
+		if err != nil {
+			return err
+		}
+		deprecatedOutput := strings.TrimSuffix(*output, ".go") + "_" + *deprecatedBuildTag + ".go"
+		if err := os.WriteFile(deprecatedOutput, deprecatedSource, 0o666); err != nil {
+			return err
+		}
+	}
+	if *benchmarks {
+		benchmarksSource, sampleFiles, err := generator.GenerateBenchmarks()
+		if err != nil {
+			return err
+		}
+		benchmarksOutput := strings.TrimSuffix(*output, ".go") + "_test.go"
+		if err := os.WriteFile(benchmarksOutput, benchmarksSource, 0o666); err != nil {
+			return err
+		}
+		for _, sampleFile := range sampleFiles {
+			sampleFilename := filepath.Join(filepath.Dir(*output), filepath.FromSlash(sampleFile.Name))
+			if err := os.MkdirAll(filepath.Dir(sampleFilename), 0o777); err != nil {
+				return err
+			}
+			if err := os.WriteFile(sampleFilename, sampleFile.Contents, 0o666); err != nil {
+				return err
+			}
+		}
+	}
+	if *metadata {
+		return writeMetadata(generator, filepath.Dir(*output))
+	}
+	return nil
+}
+
+// anonymize writes anonymized copies of the files names to the directory dir.
+func anonymize(generator *xmlstruct.Generator, names []string, dir string) error {
+	if len(names) == 0 {
+		return errors.New("-anonymize-corpus requires input files")
+	}
+	if err := os.MkdirAll(dir, 0o777); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := anonymizeFile(generator, name, filepath.Join(dir, filepath.Base(name))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// anonymizeFile writes an anonymized copy of the file name to output.
+func anonymizeFile(generator *xmlstruct.Generator, name, output string) error {
+	input, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+	buffer := &bytes.Buffer{}
+	if err := generator.AnonymizeDocument(buffer, input); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return os.WriteFile(output, buffer.Bytes(), 0o666)
+}
+
+// emit runs the emitter name on generator's model. A single file is written to
+// stdout if output is empty, otherwise all files are written to the directory
+// output.
+func emit(generator *xmlstruct.Generator, name, output string) error {
+	files, err := generator.Emit(name)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		if len(files) != 1 {
+			return fmt.Errorf("%s: -emitter with multiple files requires -output", name)
+		}
+		_, err := os.Stdout.Write(files[0].Contents)
+		return err
+	}
+	for _, file := range files {
+		fileName := filepath.Join(output, filepath.FromSlash(file.Name))
+		if err := os.MkdirAll(filepath.Dir(fileName), 0o777); err != nil {
+			return err
+		}
+		if err := os.WriteFile(fileName, file.Contents, 0o666); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeGeneratedFile writes the source generated by generator to the file
+// name, removing the file if generation fails.
+func writeGeneratedFile(generator *xmlstruct.Generator, name string) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := generator.GenerateTo(file); err != nil {
+		file.Close()
+		os.Remove(name)
+		return err
+	}
+	return file.Close()
+}
+
+func reportIdentityConstraints(generator *xmlstruct.Generator) error {
+	identityConstraints, err := generator.IdentityConstraints()
+	if err != nil {
+		return err
+	}
+	for _, key := range identityConstraints.Keys {
+		fmt.Fprintf(os.Stderr, "key: %s: %d of %d values are distinct\n", key.Path, key.Distinct, key.Observations)
+	}
+	for _, keyRef := range identityConstraints.KeyRefs {
+		fmt.Fprintf(os.Stderr, "keyref: %s: refers to %s, %d of %d values are dangling\n", keyRef.Path, keyRef.KeyPath, keyRef.Dangling, keyRef.Observations)
+	}
+	return nil
+}
+
+func writeMetadata(generator *xmlstruct.Generator, dir string) error {
+	data, err := generator.GenerateMetadata()
+	if err != nil {
 		return err
 	}
-	return os.WriteFile(*output, source, 0o666)
+	return os.WriteFile(filepath.Join(dir, xmlstruct.MetadataFilename), data, 0o666)
 }
 
 func main() {
@@ -0,0 +1,148 @@
+package xmlstruct
+
+import (
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// commonAttrsName is the name of the generated struct type that contains the
+// attributes common to many struct types, before it is exported.
+const commonAttrsName = "CommonAttrs"
+
+// commonAttrs describes a group of attributes that is extracted from the
+// struct types that share it into an embedded struct type.
+type commonAttrs struct {
+	typeName  string
+	attrNames map[xml.Name]struct{}
+	elements  map[*element]struct{}
+}
+
+// A commonAttr is an attribute with a Go type that may be shared by several
+// struct types.
+type commonAttr struct {
+	name   xml.Name
+	goType string
+}
+
+// findCommonAttrs finds the largest group of attributes that is shared by at
+// least options.commonFieldExtraction of the struct types generated for
+// typeElements and their descendants, and, if there is one, declares its
+// struct type in options and returns it.
+func findCommonAttrs(typeElements []*element, options *generateOptions) (*commonAttrs, error) {
+	elementAttrs := make(map[*element]map[commonAttr]*field)
+	visited := make(map[*element]bool)
+	var visit func(*element, string) error
+	visit = func(e *element, path string) error {
+		if visited[e] || !e.isStruct(options) {
+			return nil
+		}
+		visited[e] = true
+		fields, err := e.fields(path, options)
+		if err != nil {
+			return err
+		}
+		attrs := make(map[commonAttr]*field)
+		for _, field := range fields {
+			switch field.kind {
+			case attrFieldKind:
+				attr := commonAttr{
+					name:   field.value.name,
+					goType: field.value.goType(field.path, options),
+				}
+				attrs[attr] = field
+			case elemFieldKind:
+				if fieldType := field.goFieldType(options); fieldType.element != nil {
+					if err := visit(fieldType.element, fieldType.path); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		elementAttrs[e] = attrs
+		return nil
+	}
+	for _, typeElement := range typeElements {
+		if err := visit(typeElement, typeElement.name.Local); err != nil {
+			return nil, err
+		}
+	}
+
+	attrCounts := make(map[commonAttr]int)
+	for _, attrs := range elementAttrs {
+		for attr := range attrs {
+			attrCounts[attr]++
+		}
+	}
+	var groupAttrs []commonAttr
+	for attr, count := range attrCounts {
+		if count >= options.commonFieldExtraction {
+			groupAttrs = append(groupAttrs, attr)
+		}
+	}
+	// Sort the attributes from the most to the least shared, so that the least
+	// shared attributes are removed from the group first.
+	slices.SortFunc(groupAttrs, func(a, b commonAttr) int {
+		return cmp.Or(
+			cmp.Compare(attrCounts[b], attrCounts[a]),
+			compareNames(a.name, b.name),
+			strings.Compare(a.goType, b.goType),
+		)
+	})
+
+	for ; len(groupAttrs) != 0; groupAttrs = groupAttrs[:len(groupAttrs)-1] {
+		elements := make(map[*element]struct{})
+	ELEMENT:
+		for e, attrs := range elementAttrs {
+			for _, attr := range groupAttrs {
+				if _, ok := attrs[attr]; !ok {
+					continue ELEMENT
+				}
+			}
+			elements[e] = struct{}{}
+		}
+		if len(elements) < options.commonFieldExtraction {
+			continue
+		}
+
+		common := &commonAttrs{
+			typeName:  options.exportTypeNameFunc(xml.Name{Local: commonAttrsName}),
+			attrNames: make(map[xml.Name]struct{}),
+			elements:  elements,
+		}
+		var fields []*field
+		for _, attr := range groupAttrs {
+			common.attrNames[attr.name] = struct{}{}
+			for e := range elements {
+				fields = append(fields, elementAttrs[e][attr])
+				break
+			}
+		}
+		slices.SortFunc(fields, func(a, b *field) int {
+			return strings.Compare(a.name, b.name)
+		})
+		declarationBuilder := &strings.Builder{}
+		fmt.Fprintf(declarationBuilder, "// %s contains the attributes that are common to many elements.\n", common.typeName)
+		fmt.Fprintf(declarationBuilder, "type %s struct {\n", common.typeName)
+		for _, field := range fields {
+			fmt.Fprintf(declarationBuilder, "\t%s %s `xml:\"%s,attr\"`\n", field.name, field.value.goType(field.path, options), field.value.name.Local)
+		}
+		fmt.Fprintf(declarationBuilder, "}\n")
+		options.declarations[common.typeName] = declarationBuilder.String()
+		return common, nil
+	}
+
+	return nil, nil
+}
+
+// embedsCommonAttrs returns whether e's struct type embeds options'
+// common attributes struct type.
+func (e *element) embedsCommonAttrs(options *generateOptions) bool {
+	if options.commonAttrs == nil {
+		return false
+	}
+	_, ok := options.commonAttrs.elements[e]
+	return ok
+}
@@ -0,0 +1,345 @@
+package xmlstruct
+
+import (
+	"encoding/xml"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"path"
+	"strings"
+)
+
+// conversionNewFuncName is the name of the generated helper function that
+// allocates values of anonymous struct types.
+const conversionNewFuncName = "newConversionValue"
+
+// A converter writes functions that convert the types generated by one
+// Generator to the types generated by another.
+type converter struct {
+	fromAlias          string
+	fromOptions        *generateOptions
+	fromTypes          map[string]*element
+	toAlias            string
+	toOptions          *generateOptions
+	toTypes            map[string]*element
+	funcPrefix         string
+	importPackageNames map[string]string
+	usesNew            bool
+}
+
+// A conversionReport records the fields that are not converted by a
+// conversion function.
+type conversionReport struct {
+	added   []string
+	changed []string
+	removed []string
+}
+
+// GenerateConversions returns Go source in the package packageName with
+// functions that convert the types generated by from, imported from
+// fromImportPath, to the types with the same names generated by to, imported
+// from toImportPath, for example to migrate consumers of a feed from one
+// version to the next. The models of from and to are typically restored from
+// saved states with LoadState.
+//
+// The packages are imported with the last elements of their import paths as
+// their names, which must differ, and the functions are named after them. For
+// example, with the import paths example.com/feed/v1 and example.com/feed/v2,
+// an Item is converted by ConvertV1ToV2Item. Fields with the same name and a
+// compatible type are converted. Fields that were removed, added, or whose type
+// changed are listed in the documentation of each function, and types that
+// were removed or added are listed in a comment.
+func GenerateConversions(packageName string, from *Generator, fromImportPath string, to *Generator, toImportPath string) ([]byte, error) {
+	fromAlias, toAlias := path.Base(fromImportPath), path.Base(toImportPath)
+	for _, alias := range []string{fromAlias, toAlias} {
+		if !token.IsIdentifier(alias) {
+			return nil, fmt.Errorf("%s: invalid package name", alias)
+		}
+	}
+	if fromAlias == toAlias {
+		return nil, fmt.Errorf("%s: duplicate package name", fromAlias)
+	}
+
+	// Snapshot each Generator while holding only its own lock, so that
+	// concurrent conversions in opposite directions cannot deadlock.
+	fromSnapshot := from.Snapshot()
+	toSnapshot := fromSnapshot
+	if to != from {
+		toSnapshot = to.Snapshot()
+	}
+	from, to = fromSnapshot, toSnapshot
+
+	c := &converter{
+		fromAlias:  fromAlias,
+		toAlias:    toAlias,
+		funcPrefix: "Convert" + DefaultExportNameFunc(xml.Name{Local: fromAlias}) + "To" + DefaultExportNameFunc(xml.Name{Local: toAlias}),
+		importPackageNames: map[string]string{
+			fromImportPath: fromAlias,
+			toImportPath:   toAlias,
+		},
+	}
+	var err error
+	c.fromOptions, c.fromTypes, err = from.conversionTypes()
+	if err != nil {
+		return nil, err
+	}
+	c.toOptions, c.toTypes, err = to.conversionTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	funcsBuilder := &strings.Builder{}
+	var removedTypes, addedTypes []string
+	for _, typeName := range sortedKeys(c.fromTypes) {
+		if _, ok := c.toTypes[typeName]; !ok {
+			removedTypes = append(removedTypes, typeName)
+		}
+	}
+	for _, typeName := range sortedKeys(c.toTypes) {
+		if _, ok := c.fromTypes[typeName]; !ok {
+			addedTypes = append(addedTypes, typeName)
+			continue
+		}
+		if err := c.writeConvertFunc(funcsBuilder, typeName); err != nil {
+			return nil, err
+		}
+	}
+	if c.usesNew {
+		fmt.Fprintf(funcsBuilder, "\n// %s returns a pointer to a new zero value of the type\n", conversionNewFuncName)
+		fmt.Fprintf(funcsBuilder, "// to which its argument points.\n")
+		fmt.Fprintf(funcsBuilder, "func %s[T any](*T) *T {\n", conversionNewFuncName)
+		fmt.Fprintf(funcsBuilder, "\treturn new(T)\n")
+		fmt.Fprintf(funcsBuilder, "}\n")
+	}
+
+	sourceBuilder := &strings.Builder{}
+	writeSourceHeader(sourceBuilder, to.header, "")
+	fmt.Fprintf(sourceBuilder, "package %s\n", packageName)
+	writeImports(sourceBuilder, c.importPackageNames)
+	if len(removedTypes) != 0 || len(addedTypes) != 0 {
+		sourceBuilder.WriteByte('\n')
+		writeConversionComment(sourceBuilder, "Types", fromAlias, toAlias, removedTypes, addedTypes)
+	}
+	sourceBuilder.WriteString(funcsBuilder.String())
+
+	source := []byte(sourceBuilder.String())
+	if to.formatSource {
+		if formattedSource, err := format.Source(source); err == nil {
+			source = formattedSource
+		}
+	}
+	return source, nil
+}
+
+// conversionTypes returns the options with which g generates Go source and
+// g's named types by name. g must not be shared with other goroutines.
+func (g *Generator) conversionTypes() (*generateOptions, map[string]*element, error) {
+	options := g.generateOptions()
+	// Generate the source to populate options, for example with declarations.
+	if _, err := g.generate(options); err != nil {
+		return nil, nil, err
+	}
+	types := make(map[string]*element)
+	for _, typeElement := range g.sortedTypeElements(options) {
+		types[options.exportTypeNameFunc(typeElement.name)] = typeElement
+	}
+	return options, types, nil
+}
+
+// writeConvertFunc writes the function that converts the type typeName.
+func (c *converter) writeConvertFunc(w io.Writer, typeName string) error {
+	fromElement, toElement := c.fromTypes[typeName], c.toTypes[typeName]
+	funcName := c.funcPrefix + typeName
+	bodyBuilder := &strings.Builder{}
+	report := &conversionReport{}
+	switch fromStruct, toStruct := fromElement.isStruct(c.fromOptions), toElement.isStruct(c.toOptions); {
+	case fromStruct && toStruct:
+		if err := c.writeConvertFields(bodyBuilder, fromElement, toElement, fromElement.name.Local, toElement.name.Local, "in", "out", "", "\t", 0, report); err != nil {
+			return err
+		}
+	case !fromStruct && !toStruct && fromElement.charDataValue.goType(fromElement.name.Local, c.fromOptions) == toElement.charDataValue.goType(toElement.name.Local, c.toOptions):
+		fmt.Fprintf(bodyBuilder, "\tout = %s.%s(in)\n", c.toAlias, typeName)
+	default:
+		report.changed = append(report.changed, typeName)
+	}
+
+	fmt.Fprintf(w, "\n// %s converts in, a %s.%s, to a %s.%s.\n", funcName, c.fromAlias, typeName, c.toAlias, typeName)
+	if len(report.removed) != 0 || len(report.added) != 0 || len(report.changed) != 0 {
+		fmt.Fprintf(w, "//\n")
+		writeConversionComment(w, "Fields", c.fromAlias, c.toAlias, report.removed, report.added)
+		if len(report.changed) != 0 {
+			fmt.Fprintf(w, "// Fields whose type changed, which are not converted: %s.\n", strings.Join(report.changed, ", "))
+		}
+	}
+	fmt.Fprintf(w, "func %s(in %s.%s) %s.%s {\n", funcName, c.fromAlias, typeName, c.toAlias, typeName)
+	fmt.Fprintf(w, "\tvar out %s.%s\n", c.toAlias, typeName)
+	fmt.Fprintf(w, "%s", bodyBuilder.String())
+	fmt.Fprintf(w, "\treturn out\n")
+	fmt.Fprintf(w, "}\n")
+	return nil
+}
+
+// writeConvertFields writes statements that convert the fields of the struct
+// generated for fromElement, with path fromPath, in in, to the fields of the
+// struct generated for toElement, with path toPath, in out. prefix is the
+// prefix of the field names in report.
+func (c *converter) writeConvertFields(w io.Writer, fromElement, toElement *element, fromPath, toPath, in, out, prefix, indent string, depth int, report *conversionReport) error {
+	fromFields, err := fromElement.fields(fromPath, c.fromOptions)
+	if err != nil {
+		return err
+	}
+	toFields, err := toElement.fields(toPath, c.toOptions)
+	if err != nil {
+		return err
+	}
+	fromFieldsByName := make(map[string]*field, len(fromFields))
+	for _, fromField := range fromFields {
+		fromFieldsByName[fromField.name] = fromField
+	}
+	toFieldNames := make(map[string]struct{}, len(toFields))
+	for _, toField := range toFields {
+		toFieldNames[toField.name] = struct{}{}
+		fromField, ok := fromFieldsByName[toField.name]
+		if !ok {
+			report.added = append(report.added, prefix+toField.name)
+			continue
+		}
+		fromType, toType := fromField.goFieldType(c.fromOptions), toField.goFieldType(c.toOptions)
+		ok, err := c.writeConvertValue(w, fromType, toType, in+"."+fromField.name, out+"."+toField.name, prefix+toField.name+".", indent, depth, report)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			report.changed = append(report.changed, prefix+toField.name)
+		}
+	}
+	for _, fromField := range fromFields {
+		if _, ok := toFieldNames[fromField.name]; !ok {
+			report.removed = append(report.removed, prefix+fromField.name)
+		}
+	}
+	return nil
+}
+
+// writeConvertValue writes statements that convert in, of type fromType, to
+// out, of type toType. Single values are converted to slices with one element
+// and values are converted to pointers, but not the reverse. It returns false
+// if the types are not compatible, in which case nothing is written.
+func (c *converter) writeConvertValue(w io.Writer, fromType, toType goFieldType, in, out, prefix, indent string, depth int, report *conversionReport) (bool, error) {
+	switch {
+	case fromType.mapType != toType.mapType:
+		return false, nil
+	case !fromType.slice && toType.slice:
+		elemBuilder := &strings.Builder{}
+		toElemType := toType
+		toElemType.slice = false
+		if ok, err := c.writeConvertValue(elemBuilder, fromType, toElemType, in, out+"[0]", prefix, indent, depth, report); !ok || err != nil {
+			return ok, err
+		}
+		c.importPackageNames["slices"] = ""
+		fmt.Fprintf(w, "%s%s = slices.Grow(%s, 1)[:1]\n", indent, out, out)
+		fmt.Fprintf(w, "%s", elemBuilder.String())
+		return true, nil
+	case fromType.slice != toType.slice:
+		return false, nil
+	case !fromType.pointer && toType.pointer:
+		elemBuilder := &strings.Builder{}
+		toElemType := toType
+		toElemType.pointer = false
+		elemOut := "*" + out
+		if toElemType.element != nil && !toElemType.named {
+			elemOut = out
+		}
+		if ok, err := c.writeConvertValue(elemBuilder, fromType, toElemType, in, elemOut, prefix, indent, depth, report); !ok || err != nil {
+			return ok, err
+		}
+		c.usesNew = true
+		fmt.Fprintf(w, "%s%s = %s(%s)\n", indent, out, conversionNewFuncName, out)
+		fmt.Fprintf(w, "%s", elemBuilder.String())
+		return true, nil
+	case fromType.pointer != toType.pointer:
+		return false, nil
+	case fromType.slice:
+		if depth >= len(deepCopyIndexVars) {
+			return false, fmt.Errorf("%s: too deeply nested", in)
+		}
+		c.importPackageNames["slices"] = ""
+		i := deepCopyIndexVars[depth]
+		elemBuilder := &strings.Builder{}
+		fromElemType, toElemType := fromType, toType
+		fromElemType.slice, toElemType.slice = false, false
+		if ok, err := c.writeConvertValue(elemBuilder, fromElemType, toElemType, in+"["+i+"]", out+"["+i+"]", prefix, indent+"\t\t", depth+1, report); !ok || err != nil {
+			return ok, err
+		}
+		if elemBuilder.String() == indent+"\t\t"+out+"["+i+"] = "+in+"["+i+"]\n" {
+			fmt.Fprintf(w, "%s%s = slices.Clone(%s)\n", indent, out, in)
+			return true, nil
+		}
+		fmt.Fprintf(w, "%sif %s != nil {\n", indent, in)
+		fmt.Fprintf(w, "%s\t%s = slices.Grow(%s, len(%s))[:len(%s)]\n", indent, out, out, in, in)
+		fmt.Fprintf(w, "%s\tfor %s := range %s {\n", indent, i, in)
+		fmt.Fprintf(w, "%s", elemBuilder.String())
+		fmt.Fprintf(w, "%s\t}\n", indent)
+		fmt.Fprintf(w, "%s}\n", indent)
+		return true, nil
+	case fromType.pointer:
+		elemBuilder := &strings.Builder{}
+		fromElemType, toElemType := fromType, toType
+		fromElemType.pointer, toElemType.pointer = false, false
+		elemIn, elemOut := "*"+in, "*"+out
+		if toElemType.element != nil && !toElemType.named {
+			// Fields of pointers to structs are dereferenced implicitly.
+			elemIn, elemOut = in, out
+		}
+		if ok, err := c.writeConvertValue(elemBuilder, fromElemType, toElemType, elemIn, elemOut, prefix, indent+"\t", depth, report); !ok || err != nil {
+			return ok, err
+		}
+		c.usesNew = true
+		fmt.Fprintf(w, "%sif %s != nil {\n", indent, in)
+		fmt.Fprintf(w, "%s\t%s = %s(%s)\n", indent, out, conversionNewFuncName, out)
+		fmt.Fprintf(w, "%s", elemBuilder.String())
+		fmt.Fprintf(w, "%s}\n", indent)
+		return true, nil
+	case fromType.named != toType.named || (fromType.element == nil) != (toType.element == nil):
+		return false, nil
+	case fromType.named:
+		if fromType.name != toType.name {
+			return false, nil
+		}
+		fmt.Fprintf(w, "%s%s = %s%s(%s)\n", indent, out, c.funcPrefix, toType.name, in)
+		return true, nil
+	case fromType.element != nil:
+		return true, c.writeConvertFields(w, fromType.element, toType.element, fromType.path, toType.path, in, out, prefix, indent, depth, report)
+	case fromType.name != toType.name:
+		return false, nil
+	}
+
+	declarationName, _, _ := strings.Cut(toType.name, "[")
+	fromDeclaration, fromDeclared := c.fromOptions.declarations[declarationName]
+	toDeclaration, toDeclared := c.toOptions.declarations[declarationName]
+	if fromDeclared != toDeclared || fromDeclaration != toDeclaration {
+		return false, nil
+	}
+	value := in
+	if fromType.mapType {
+		c.importPackageNames["maps"] = ""
+		value = "maps.Clone(" + in + ")"
+	}
+	if toDeclared {
+		value = c.toAlias + "." + toType.name + "(" + value + ")"
+	}
+	fmt.Fprintf(w, "%s%s = %s\n", indent, out, value)
+	return true, nil
+}
+
+// writeConversionComment writes comments listing the names of things of kind
+// that are in fromAlias but not in toAlias, and vice versa.
+func writeConversionComment(w io.Writer, kind, fromAlias, toAlias string, removed, added []string) {
+	if len(removed) != 0 {
+		fmt.Fprintf(w, "// %s of %s that are not in %s: %s.\n", kind, fromAlias, toAlias, strings.Join(removed, ", "))
+	}
+	if len(added) != 0 {
+		fmt.Fprintf(w, "// %s of %s that are not in %s: %s.\n", kind, toAlias, fromAlias, strings.Join(added, ", "))
+	}
+}
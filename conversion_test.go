@@ -0,0 +1,88 @@
+package xmlstruct_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestGenerateConversions(t *testing.T) {
+	t.Parallel()
+
+	v1 := xmlstruct.NewGenerator(xmlstruct.WithNamedTypes(true))
+	assert.NoError(t, v1.ObserveReader(strings.NewReader(`<feed><item id="1" old="x"><tag>a</tag><tag>b</tag></item><gone/></feed>`)))
+	v2 := xmlstruct.NewGenerator(xmlstruct.WithNamedTypes(true))
+	assert.NoError(t, v2.ObserveReader(strings.NewReader(`<feed><item id="a" new="y"><tag>a</tag><tag>c</tag></item><item id="b"/></feed>`)))
+
+	actual, err := xmlstruct.GenerateConversions("conv", v1, "example.com/feed/v1", v2, "example.com/feed/v2")
+	assert.NoError(t, err)
+	assert.Equal(t, joinLines(
+		xmlstruct.DefaultHeader,
+		"",
+		"package conv",
+		"",
+		"import (",
+		"\tv1 \"example.com/feed/v1\"",
+		"\tv2 \"example.com/feed/v2\"",
+		"\t\"slices\"",
+		")",
+		"",
+		"// ConvertV1ToV2Feed converts in, a v1.Feed, to a v2.Feed.",
+		"//",
+		"// Fields of v1 that are not in v2: Gone.",
+		"func ConvertV1ToV2Feed(in v1.Feed) v2.Feed {",
+		"\tvar out v2.Feed",
+		"\tout.Item = slices.Grow(out.Item, 1)[:1]",
+		"\tout.Item[0] = ConvertV1ToV2Item(in.Item)",
+		"\treturn out",
+		"}",
+		"",
+		"// ConvertV1ToV2Item converts in, a v1.Item, to a v2.Item.",
+		"//",
+		"// Fields of v1 that are not in v2: Old.",
+		"// Fields of v2 that are not in v1: New.",
+		"// Fields whose type changed, which are not converted: ID.",
+		"func ConvertV1ToV2Item(in v1.Item) v2.Item {",
+		"\tvar out v2.Item",
+		"\tout.Tag = slices.Clone(in.Tag)",
+		"\treturn out",
+		"}",
+	), string(actual))
+
+	_, err = xmlstruct.GenerateConversions("conv", v1, "example.com/v1/feed", v2, "example.com/v2/feed")
+	assert.EqualError(t, err, "feed: duplicate package name")
+}
+
+func TestGenerateConversionsConcurrent(t *testing.T) {
+	t.Parallel()
+
+	v1 := xmlstruct.NewGenerator(xmlstruct.WithNamedTypes(true))
+	v2 := xmlstruct.NewGenerator(xmlstruct.WithNamedTypes(true))
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			_, err := xmlstruct.GenerateConversions("conv", v1, "example.com/feed/v1", v2, "example.com/feed/v2")
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := xmlstruct.GenerateConversions("conv", v2, "example.com/feed/v2", v1, "example.com/feed/v1")
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, v1.ObserveReader(strings.NewReader(`<feed><item id="1"/></feed>`)))
+		}()
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, v2.ObserveReader(strings.NewReader(`<feed><item id="a"/></feed>`)))
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,190 @@
+package xmlstruct
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// A corpusDocument is a document in a corpus being minimized.
+type corpusDocument struct {
+	features map[string]struct{}
+	members  map[string]map[string]struct{}
+}
+
+// MinimizeCorpus returns a small subset of the XML documents in the files
+// names, in their original order, that exercises every decision that g makes
+// when inferring a model from all of them: every element, attribute, and child
+// element, every kind of value that determines a field's type, and every
+// optional and repeated attribute and child element. The returned documents
+// can be checked in as regression fixtures instead of the whole corpus.
+//
+// Each document is observed on its own with g's options. g's observations are
+// neither used nor modified. The subset is chosen greedily, so it is small but
+// not necessarily the smallest possible.
+func (g *Generator) MinimizeCorpus(names []string) ([]string, error) {
+	documents := make([]*corpusDocument, 0, len(names))
+	allMembers := make(map[string]map[string]struct{})
+	for _, name := range names {
+		document, err := g.observeCorpusDocument(name)
+		if err != nil {
+			return nil, err
+		}
+		for key, members := range document.members {
+			if allMembers[key] == nil {
+				allMembers[key] = make(map[string]struct{})
+			}
+			for member := range members {
+				allMembers[key][member] = struct{}{}
+			}
+		}
+		documents = append(documents, document)
+	}
+
+	// Members that are absent from an element in one document but present in
+	// another are optional, so documents in which they are absent are needed.
+	uncovered := make(map[string]struct{})
+	for _, document := range documents {
+		for key, members := range document.members {
+			for member := range allMembers[key] {
+				if _, ok := members[member]; !ok {
+					document.features[key+"/"+member+" absent"] = struct{}{}
+				}
+			}
+		}
+		for feature := range document.features {
+			uncovered[feature] = struct{}{}
+		}
+	}
+
+	var selected []int
+	for len(uncovered) != 0 {
+		best, bestCount := -1, 0
+		for i, document := range documents {
+			count := 0
+			for feature := range document.features {
+				if _, ok := uncovered[feature]; ok {
+					count++
+				}
+			}
+			if count > bestCount {
+				best, bestCount = i, count
+			}
+		}
+		for feature := range documents[best].features {
+			delete(uncovered, feature)
+		}
+		selected = append(selected, best)
+	}
+	slices.Sort(selected)
+
+	minimizedNames := make([]string, 0, len(selected))
+	for _, i := range selected {
+		minimizedNames = append(minimizedNames, names[i])
+	}
+	return minimizedNames, nil
+}
+
+// observeCorpusDocument returns the features of the XML document in the file
+// name when observed on its own with g's options.
+func (g *Generator) observeCorpusDocument(name string) (*corpusDocument, error) {
+	g.mu.RLock()
+	documentGenerator := g.cloneOptions()
+	g.mu.RUnlock()
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if err := documentGenerator.ObserveReader(file); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	document := &corpusDocument{
+		features: make(map[string]struct{}),
+		members:  make(map[string]map[string]struct{}),
+	}
+	addFeature := func(format string, args ...any) {
+		document.features[fmt.Sprintf(format, args...)] = struct{}{}
+	}
+	addValueFeatures := func(key string, v *value) {
+		for _, kindCount := range []struct {
+			kind  valueKind
+			count int
+		}{
+			{kind: boolValueKind, count: v.boolCount},
+			{kind: intValueKind, count: v.intCount},
+			{kind: float64ValueKind, count: v.float64Count},
+			{kind: timeValueKind, count: v.timeCount},
+			{kind: stringValueKind, count: v.stringCount},
+		} {
+			if kindCount.count > 0 {
+				addFeature("%s kind=%d", key, kindCount.kind)
+			}
+		}
+		if v.optional {
+			addFeature("%s optional", key)
+		}
+		if v.repeated {
+			addFeature("%s repeated", key)
+		}
+	}
+
+	visited := make(map[*element]bool)
+	var addElementFeatures func(string, *element)
+	addElementFeatures = func(key string, e *element) {
+		if visited[e] {
+			return
+		}
+		visited[e] = true
+
+		addFeature("%s root=%t nested=%t", key, e.root, e.nestedCount > 0)
+		members := make(map[string]struct{})
+		for attrName, attrValue := range e.attrValues {
+			member := "@" + corpusNameKey(attrName)
+			members[member] = struct{}{}
+			addValueFeatures(key+"/"+member, attrValue)
+		}
+		if e.charDataValue.observations > 0 {
+			addValueFeatures(key+"/#chardata", &e.charDataValue)
+		}
+		for childName, childElement := range e.childElements {
+			member := corpusNameKey(childName)
+			members[member] = struct{}{}
+			addFeature("%s/%s", key, member)
+			if _, ok := e.optionalChildren[childName]; ok {
+				addFeature("%s/%s optional", key, member)
+			}
+			if _, ok := e.repeatedChildren[childName]; ok {
+				addFeature("%s/%s repeated", key, member)
+			}
+			childKey := key + "/" + member
+			if g.namedTypes {
+				childKey = member
+			}
+			addElementFeatures(childKey, childElement)
+		}
+		if existingMembers, ok := document.members[key]; ok {
+			for member := range members {
+				existingMembers[member] = struct{}{}
+			}
+		} else {
+			document.members[key] = members
+		}
+	}
+	for typeName, typeElement := range documentGenerator.typeElements {
+		addElementFeatures(corpusNameKey(typeName), typeElement)
+	}
+
+	return document, nil
+}
+
+// corpusNameKey returns a string that identifies name.
+func corpusNameKey(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + " " + name.Local
+}
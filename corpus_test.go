@@ -0,0 +1,48 @@
+package xmlstruct_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestMinimizeCorpus(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	var names []string
+	for _, xmlStr := range []string{
+		`<a><b>1</b></a>`,
+		`<a><b>2</b></a>`,
+		`<a><b>x</b><c/></a>`,
+		`<a><c/></a>`,
+		`<a><b>3</b><c/></a>`,
+	} {
+		name := filepath.Join(dir, string(rune('0'+len(names)))+".xml")
+		assert.NoError(t, os.WriteFile(name, []byte(xmlStr), 0o666))
+		names = append(names, name)
+	}
+
+	generator := xmlstruct.NewGenerator()
+	actual, err := generator.MinimizeCorpus(names)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{names[0], names[2], names[3]}, actual)
+
+	for _, name := range names {
+		assert.NoError(t, generator.ObserveFile(name))
+	}
+	expectedSource, err := generator.Generate()
+	assert.NoError(t, err)
+
+	minimizedGenerator := xmlstruct.NewGenerator()
+	for _, name := range actual {
+		assert.NoError(t, minimizedGenerator.ObserveFile(name))
+	}
+	actualSource, err := minimizedGenerator.Generate()
+	assert.NoError(t, err)
+	assert.Equal(t, string(expectedSource), string(actualSource))
+}
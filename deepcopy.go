@@ -0,0 +1,132 @@
+package xmlstruct
+
+import (
+	"fmt"
+	"io"
+)
+
+// deepCopyGenMarker is the marker comment that tells Kubernetes' deepcopy-gen
+// to generate deep copy methods for a type.
+const deepCopyGenMarker = "// +k8s:deepcopy-gen=true"
+
+// deepCopyIndexVars are the names of the index variables used in nested loops.
+var deepCopyIndexVars = []string{"i", "j", "k", "l", "m", "n"}
+
+// writeDeepCopyMethods writes DeepCopyInto and DeepCopy methods for the named
+// type typeName generated for e, in the style of Kubernetes' deepcopy-gen.
+func writeDeepCopyMethods(w io.Writer, typeName string, e *element, options *generateOptions) error {
+	fmt.Fprintf(w, "\n// DeepCopyInto copies the receiver into out. in must be non-nil.\n")
+	fmt.Fprintf(w, "func (in *%s) DeepCopyInto(out *%s) {\n", typeName, typeName)
+	fmt.Fprintf(w, "\t*out = *in\n")
+	if e.isStruct(options) {
+		if err := writeDeepCopyFields(w, e, e.name.Local, "in", "out", "\t", 0, options); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "}\n")
+
+	fmt.Fprintf(w, "\n// DeepCopy returns a deep copy of the receiver.\n")
+	fmt.Fprintf(w, "func (in *%s) DeepCopy() *%s {\n", typeName, typeName)
+	fmt.Fprintf(w, "\tif in == nil {\n")
+	fmt.Fprintf(w, "\t\treturn nil\n")
+	fmt.Fprintf(w, "\t}\n")
+	fmt.Fprintf(w, "\tout := new(%s)\n", typeName)
+	fmt.Fprintf(w, "\tin.DeepCopyInto(out)\n")
+	fmt.Fprintf(w, "\treturn out\n")
+	fmt.Fprintf(w, "}\n")
+	return nil
+}
+
+// writeDeepCopyFields writes statements that deep copy the fields of the
+// struct generated for e, with path path, from in to out, assuming that out is
+// already a shallow copy of in.
+func writeDeepCopyFields(w io.Writer, e *element, path, in, out, indent string, depth int, options *generateOptions) error {
+	fields, err := e.fields(path, options)
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		fieldType := field.goFieldType(options)
+		if err := writeDeepCopyValue(w, fieldType, in+"."+field.name, out+"."+field.name, indent, depth, options); err != nil {
+			return err
+		}
+	}
+	return writeDeepCopyAnyFields(w, e, path, fields, in, out, indent, depth, options)
+}
+
+// writeDeepCopyValue writes statements that deep copy a value of type
+// fieldType from in to out, assuming that out is already a shallow copy of in.
+func writeDeepCopyValue(w io.Writer, fieldType goFieldType, in, out, indent string, depth int, options *generateOptions) error {
+	switch {
+	case !needsDeepCopy(fieldType, options):
+		return nil
+	case fieldType.slice:
+		if depth >= len(deepCopyIndexVars) {
+			return fmt.Errorf("%s: too deeply nested", in)
+		}
+		options.importPackageNames["slices"] = ""
+		fmt.Fprintf(w, "%sif %s != nil {\n", indent, in)
+		fmt.Fprintf(w, "%s\t%s = slices.Clone(%s)\n", indent, out, in)
+		elemType := fieldType
+		elemType.slice = false
+		if needsDeepCopy(elemType, options) {
+			i := deepCopyIndexVars[depth]
+			fmt.Fprintf(w, "%s\tfor %s := range %s {\n", indent, i, in)
+			if err := writeDeepCopyValue(w, elemType, in+"["+i+"]", out+"["+i+"]", indent+"\t\t", depth+1, options); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s\t}\n", indent)
+		}
+		fmt.Fprintf(w, "%s}\n", indent)
+	case fieldType.pointer:
+		fmt.Fprintf(w, "%sif %s != nil {\n", indent, in)
+		if fieldType.named {
+			fmt.Fprintf(w, "%s\t%s = %s.DeepCopy()\n", indent, out, in)
+		} else {
+			fmt.Fprintf(w, "%s\tx := *%s\n", indent, in)
+			fmt.Fprintf(w, "%s\t%s = &x\n", indent, out)
+			if fieldType.element != nil {
+				if err := writeDeepCopyFields(w, fieldType.element, fieldType.path, in, out, indent+"\t", depth, options); err != nil {
+					return err
+				}
+			}
+		}
+		fmt.Fprintf(w, "%s}\n", indent)
+	case fieldType.mapType:
+		options.importPackageNames["maps"] = ""
+		fmt.Fprintf(w, "%sif %s != nil {\n", indent, in)
+		fmt.Fprintf(w, "%s\t%s = maps.Clone(%s)\n", indent, out, in)
+		fmt.Fprintf(w, "%s}\n", indent)
+	case fieldType.named:
+		fmt.Fprintf(w, "%s%s.DeepCopyInto(&%s)\n", indent, in, out)
+	case fieldType.element != nil:
+		return writeDeepCopyFields(w, fieldType.element, fieldType.path, in, out, indent, depth, options)
+	}
+	return nil
+}
+
+// needsDeepCopy returns whether a value of type fieldType contains references
+// that are not copied by a shallow copy.
+func needsDeepCopy(fieldType goFieldType, options *generateOptions) bool {
+	switch {
+	case fieldType.slice || fieldType.pointer || fieldType.named || fieldType.mapType:
+		return true
+	case fieldType.element != nil:
+		if anyAttrs, anyElements := options.anyContent(fieldType.element, fieldType.path); anyAttrs || anyElements {
+			return true
+		}
+		fields, err := fieldType.element.fields(fieldType.path, options)
+		if err != nil {
+			// Report the error when writing the fields.
+			return true
+		}
+		for _, field := range fields {
+			if needsDeepCopy(field.goFieldType(options), options) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
@@ -0,0 +1,446 @@
+package xmlstruct
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// applyDefaultsIndexVars are the names of the index variables used in nested
+// loops in ApplyDefaults methods.
+var applyDefaultsIndexVars = []string{"i", "j", "k", "l", "m", "n"}
+
+// An attrDefault is an attribute default set with WithAttrDefaults.
+type attrDefault struct {
+	pattern string
+	space   string
+	matcher FieldMatcher
+	value   string
+}
+
+// newAttrDefaults returns the attribute defaults in attrDefaults, most specific
+// first.
+func newAttrDefaults(attrDefaults map[string]string) []*attrDefault {
+	result := make([]*attrDefault, 0, len(attrDefaults))
+	for key, value := range attrDefaults {
+		pattern, space := key, ""
+		if elementPattern, attrPattern, ok := cutLast(key, "/@"); ok {
+			componentStart := strings.LastIndexByte(elementPattern, '/') + 1
+			if strings.HasPrefix(elementPattern[componentStart:], "{") {
+				if qualifiedSpace, local, ok := strings.Cut(elementPattern[componentStart+1:], "}"); ok {
+					pattern = elementPattern[:componentStart] + local + "/@" + attrPattern
+					space = qualifiedSpace
+				}
+			}
+		}
+		result = append(result, &attrDefault{
+			pattern: pattern,
+			space:   space,
+			matcher: MatchPath(pattern),
+			value:   value,
+		})
+	}
+	slices.SortFunc(result, func(a, b *attrDefault) int {
+		return cmp.Or(
+			-cmp.Compare(strings.Count(a.pattern, "/"), strings.Count(b.pattern, "/")),
+			-cmp.Compare(a.space, b.space),
+			cmp.Compare(a.pattern, b.pattern),
+		)
+	})
+	return result
+}
+
+// cutLast slices s around the last instance of sep.
+func cutLast(s, sep string) (string, string, bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// attrDefault returns the default value of the attribute of e with path
+// attrPath.
+func (options *generateOptions) attrDefault(e *element, attrPath string) (string, bool) {
+	for _, attrDefault := range options.attrDefaults {
+		if attrDefault.matcher(attrPath) && (attrDefault.space == "" || attrDefault.space == e.name.Space) {
+			return attrDefault.value, true
+		}
+	}
+	return "", false
+}
+
+// attrDefaultsWriter writes UnmarshalXML and ApplyDefaults methods.
+type attrDefaultsWriter struct {
+	options      *generateOptions
+	typeElements map[string]*element
+	needsDefault map[string]bool
+	needsPreset  map[string]bool
+}
+
+// newAttrDefaultsWriter returns a new attrDefaultsWriter for the named types
+// generated for typeElements.
+func newAttrDefaultsWriter(typeElements []*element, options *generateOptions) (*attrDefaultsWriter, error) {
+	w := &attrDefaultsWriter{
+		options:      options,
+		typeElements: make(map[string]*element),
+		needsDefault: make(map[string]bool),
+		needsPreset:  make(map[string]bool),
+	}
+	for _, typeElement := range typeElements {
+		typeName := options.exportTypeNameFunc(typeElement.name)
+		w.typeElements[typeName] = typeElement
+		if !typeElement.isStruct(options) {
+			continue
+		}
+		needsPreset, err := w.elementNeedsPreset(typeElement, typeElement.name.Local)
+		if err != nil {
+			return nil, err
+		}
+		w.needsPreset[typeName] = needsPreset
+	}
+
+	// Find the types that need an ApplyDefaults method, iterating until there
+	// are no more changes to handle recursive types.
+	for changed := true; changed; {
+		changed = false
+		for typeName, typeElement := range w.typeElements {
+			if w.needsDefault[typeName] || !typeElement.isStruct(options) {
+				continue
+			}
+			needsDefault, err := w.elementNeedsDefault(typeElement, typeElement.name.Local)
+			if err != nil {
+				return nil, err
+			}
+			if needsDefault {
+				w.needsDefault[typeName] = true
+				changed = true
+			}
+		}
+	}
+
+	return w, nil
+}
+
+// attrFieldDefault returns the Go expression of the default value of the
+// attribute field f of e and whether f is preset, i.e. its default value is
+// set before decoding because it is neither a pointer nor an optional wrapper.
+// It returns an empty expression if f does not have a default value.
+func (w *attrDefaultsWriter) attrFieldDefault(e *element, f *field) (string, bool, error) {
+	attrDefault, ok := w.options.attrDefault(e, f.path)
+	fieldType := f.goFieldType(w.options)
+	if !ok || fieldType.slice {
+		return "", false, nil
+	}
+	if _, mapped := w.options.mappedGoFieldType(f.path); mapped {
+		return "", false, fmt.Errorf("%s: default for mapped type", f.path)
+	}
+	kind, tolerant := f.value.resolvedKind(w.options)
+	if tolerant {
+		return "", false, fmt.Errorf("%s: default for tolerant type", f.path)
+	}
+	typeName := fieldType.name
+	if fieldType.wrappedName != "" {
+		typeName = fieldType.wrappedName
+	}
+	literal, err := w.literal(kind, typeName, attrDefault)
+	if err != nil {
+		return "", false, fmt.Errorf("%s: %w", f.path, err)
+	}
+	return literal, fieldType.wrappedName == "" && !fieldType.pointer, nil
+}
+
+// elementNeedsDefault returns whether the struct generated for e, with path
+// path, has any fields that need defaults to be applied.
+func (w *attrDefaultsWriter) elementNeedsDefault(e *element, path string) (bool, error) {
+	fields, err := e.fields(path, w.options)
+	if err != nil {
+		return false, err
+	}
+	for _, field := range fields {
+		switch field.kind {
+		case attrFieldKind:
+			literal, preset, err := w.attrFieldDefault(e, field)
+			if err != nil {
+				return false, err
+			}
+			if literal != "" && !preset {
+				return true, nil
+			}
+		case elemFieldKind:
+			fieldType := field.goFieldType(w.options)
+			switch {
+			case fieldType.named && w.needsDefault[fieldType.name]:
+				return true, nil
+			case fieldType.element != nil:
+				needsDefault, err := w.elementNeedsDefault(fieldType.element, fieldType.path)
+				if err != nil {
+					return false, err
+				}
+				if needsDefault {
+					return true, nil
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+// elementNeedsPreset returns whether the struct generated for e, with path
+// path, has any fields whose defaults must be set before decoding. Such fields
+// can only be set in anonymous structs that are neither repeated nor pointers,
+// as other anonymous structs are only created while decoding.
+func (w *attrDefaultsWriter) elementNeedsPreset(e *element, path string) (bool, error) {
+	fields, err := e.fields(path, w.options)
+	if err != nil {
+		return false, err
+	}
+	needsPreset := false
+	for _, field := range fields {
+		switch field.kind {
+		case attrFieldKind:
+			literal, preset, err := w.attrFieldDefault(e, field)
+			if err != nil {
+				return false, err
+			}
+			if literal != "" && preset {
+				needsPreset = true
+			}
+		case elemFieldKind:
+			fieldType := field.goFieldType(w.options)
+			if fieldType.element == nil {
+				continue
+			}
+			elementNeedsPreset, err := w.elementNeedsPreset(fieldType.element, fieldType.path)
+			switch {
+			case err != nil:
+				return false, err
+			case elementNeedsPreset && (fieldType.slice || fieldType.pointer):
+				return false, fmt.Errorf("%s: defaults in repeated or optional anonymous structs require pointers or named types", fieldType.path)
+			case elementNeedsPreset:
+				needsPreset = true
+			}
+		}
+	}
+	return needsPreset, nil
+}
+
+// writeDefaultsMethods writes UnmarshalXML and ApplyDefaults methods to iw for
+// all types that need them.
+func (w *attrDefaultsWriter) writeDefaultsMethods(iw io.Writer, typeElements []*element) error {
+	for _, typeElement := range typeElements {
+		typeName := w.options.exportTypeNameFunc(typeElement.name)
+		if w.needsPreset[typeName] {
+			w.options.importPackageNames["encoding/xml"] = ""
+			fmt.Fprintf(iw, "\n// UnmarshalXML implements encoding/xml.Unmarshaler. It sets attributes that\n")
+			fmt.Fprintf(iw, "// have default values to their default values before decoding.\n")
+			fmt.Fprintf(iw, "func (x *%s) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {\n", typeName)
+			fmt.Fprintf(iw, "\ttype plain %s\n", typeName)
+			if err := w.writeElementPresets(iw, typeElement, typeElement.name.Local, "x"); err != nil {
+				return err
+			}
+			fmt.Fprintf(iw, "\treturn d.DecodeElement((*plain)(x), &start)\n")
+			fmt.Fprintf(iw, "}\n")
+		}
+		if w.needsDefault[typeName] {
+			fmt.Fprintf(iw, "\n// ApplyDefaults sets all unset attributes that have default values to their\n")
+			fmt.Fprintf(iw, "// default values.\n")
+			fmt.Fprintf(iw, "func (x *%s) ApplyDefaults() {\n", typeName)
+			if err := w.writeElementDefaults(iw, typeElement, typeElement.name.Local, "x", "\t", 0); err != nil {
+				return err
+			}
+			fmt.Fprintf(iw, "}\n")
+		}
+	}
+	return nil
+}
+
+// writeElementPresets writes statements that set the fields of the struct
+// generated for e, with path path, accessed with expr, whose defaults are set
+// before decoding.
+func (w *attrDefaultsWriter) writeElementPresets(iw io.Writer, e *element, path, expr string) error {
+	fields, err := e.fields(path, w.options)
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		fieldExpr := expr + "." + field.name
+		switch field.kind {
+		case attrFieldKind:
+			literal, preset, err := w.attrFieldDefault(e, field)
+			if err != nil {
+				return err
+			}
+			if literal != "" && preset {
+				fmt.Fprintf(iw, "\t%s = %s\n", fieldExpr, literal)
+			}
+		case elemFieldKind:
+			fieldType := field.goFieldType(w.options)
+			if fieldType.element == nil || fieldType.slice || fieldType.pointer {
+				continue
+			}
+			if err := w.writeElementPresets(iw, fieldType.element, fieldType.path, fieldExpr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeElementDefaults writes statements that apply defaults to the fields of
+// the struct generated for e, with path path, accessed with expr.
+func (w *attrDefaultsWriter) writeElementDefaults(iw io.Writer, e *element, path, expr, indent string, depth int) error {
+	fields, err := e.fields(path, w.options)
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		fieldExpr := expr + "." + field.name
+		fieldType := field.goFieldType(w.options)
+		switch field.kind {
+		case attrFieldKind:
+			literal, preset, err := w.attrFieldDefault(e, field)
+			if err != nil {
+				return err
+			}
+			switch {
+			case literal == "" || preset:
+				continue
+			case fieldType.wrappedName != "":
+				fmt.Fprintf(iw, "%sif !%s.Valid {\n", indent, fieldExpr)
+				fmt.Fprintf(iw, "%s\t%s.%s = %s\n", indent, fieldExpr, fieldType.wrappedValueField, literal)
+				fmt.Fprintf(iw, "%s\t%s.Valid = true\n", indent, fieldExpr)
+				fmt.Fprintf(iw, "%s}\n", indent)
+			default:
+				fmt.Fprintf(iw, "%sif %s == nil {\n", indent, fieldExpr)
+				fmt.Fprintf(iw, "%s\tv := %s\n", indent, literal)
+				fmt.Fprintf(iw, "%s\t%s = &v\n", indent, fieldExpr)
+				fmt.Fprintf(iw, "%s}\n", indent)
+			}
+		case elemFieldKind:
+			if err := w.writeFieldDefaults(iw, fieldType, fieldExpr, indent, depth); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeFieldDefaults writes statements that apply defaults to the child
+// element field of type fieldType accessed with expr.
+func (w *attrDefaultsWriter) writeFieldDefaults(iw io.Writer, fieldType goFieldType, expr, indent string, depth int) error {
+	switch {
+	case fieldType.named && !w.needsDefault[fieldType.name]:
+		return nil
+	case fieldType.element != nil:
+		needsDefault, err := w.elementNeedsDefault(fieldType.element, fieldType.path)
+		if err != nil || !needsDefault {
+			return err
+		}
+	case !fieldType.named:
+		return nil
+	}
+
+	switch {
+	case fieldType.slice:
+		if depth >= len(applyDefaultsIndexVars) {
+			return fmt.Errorf("%s: too deeply nested", expr)
+		}
+		i := applyDefaultsIndexVars[depth]
+		fmt.Fprintf(iw, "%sfor %s := range %s {\n", indent, i, expr)
+		elemType := fieldType
+		elemType.slice = false
+		if err := w.writeFieldDefaults(iw, elemType, expr+"["+i+"]", indent+"\t", depth+1); err != nil {
+			return err
+		}
+		fmt.Fprintf(iw, "%s}\n", indent)
+	case fieldType.pointer:
+		fmt.Fprintf(iw, "%sif %s != nil {\n", indent, expr)
+		elemType := fieldType
+		elemType.pointer = false
+		if err := w.writeFieldDefaults(iw, elemType, expr, indent+"\t", depth); err != nil {
+			return err
+		}
+		fmt.Fprintf(iw, "%s}\n", indent)
+	case fieldType.named:
+		fmt.Fprintf(iw, "%s%s.ApplyDefaults()\n", indent, expr)
+	default:
+		return w.writeElementDefaults(iw, fieldType.element, fieldType.path, expr, indent, depth)
+	}
+	return nil
+}
+
+// literal returns a Go expression for attrDefault as a value of kind kind and
+// type typeName.
+func (w *attrDefaultsWriter) literal(kind valueKind, typeName, attrDefault string) (string, error) {
+	switch kind {
+	case boolValueKind:
+		b, err := strconv.ParseBool(attrDefault)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatBool(b), nil
+	case intValueKind:
+		number := w.normalizeNumber(attrDefault)
+		i, err := strconv.ParseInt(number, 10, 64)
+		if err != nil {
+			return "", err
+		}
+		if typeName == "int" {
+			return strconv.FormatInt(i, 10), nil
+		}
+		return typeName + "(" + strconv.FormatInt(i, 10) + ")", nil
+	case float64ValueKind:
+		number := w.normalizeNumber(attrDefault)
+		f, err := strconv.ParseFloat(number, 64)
+		switch {
+		case err != nil:
+			return "", err
+		case math.IsInf(f, 0) || math.IsNaN(f):
+			return "", fmt.Errorf("%q: unsupported default", attrDefault)
+		}
+		return typeName + "(" + strconv.FormatFloat(f, 'g', -1, 64) + ")", nil
+	case timeValueKind:
+		t, err := time.Parse(w.options.timeLayout, attrDefault)
+		if err != nil {
+			return "", err
+		}
+		location := "time.UTC"
+		switch name, offset := t.Zone(); {
+		case t.Location() != time.UTC:
+			location = fmt.Sprintf("time.FixedZone(%q, %d)", name, offset)
+		case typeName != "time.Time" && w.options.timeZonePolicy == TimeZonePolicyLocal:
+			location = "time.Local"
+		}
+		date := fmt.Sprintf("time.Date(%d, %d, %d, %d, %d, %d, %d, %s)", t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), location)
+		switch {
+		case typeName == "time.Time":
+			return date, nil
+		case w.options.timeZonePolicy == TimeZonePolicyRaw:
+			return fmt.Sprintf("%s{Time: %s, Raw: %s}", typeName, date, strconv.Quote(attrDefault)), nil
+		default:
+			return fmt.Sprintf("%s{Time: %s}", typeName, date), nil
+		}
+	case emptyValueKind:
+		if w.options.emptyElements {
+			return "", fmt.Errorf("%q: unsupported default", attrDefault)
+		}
+		return strconv.Quote(attrDefault), nil
+	default:
+		return strconv.Quote(attrDefault), nil
+	}
+}
+
+// normalizeNumber returns s normalized for parsing by strconv if s is a
+// number in w's number format, otherwise it returns s unchanged so that errors
+// refer to s.
+func (w *attrDefaultsWriter) normalizeNumber(s string) string {
+	if number, ok := w.options.numberFormat.normalize(s); ok {
+		return number
+	}
+	return s
+}
@@ -0,0 +1,81 @@
+package xmlstruct_test
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestApplyDefaultsTimeZonePolicies(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name           string
+		timeLayout     string
+		timeZonePolicy xmlstruct.TimeZonePolicy
+		expectedLine   string
+	}{
+		{
+			name:           "none",
+			timeLayout:     "2006-01-02T15:04:05Z07:00",
+			timeZonePolicy: xmlstruct.TimeZonePolicyNone,
+			expectedLine:   "v := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)",
+		},
+		{
+			name:           "utc",
+			timeLayout:     "2006-01-02T15:04:05",
+			timeZonePolicy: xmlstruct.TimeZonePolicyUTC,
+			expectedLine:   "v := UTCTime{Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}",
+		},
+		{
+			name:           "local",
+			timeLayout:     "2006-01-02T15:04:05",
+			timeZonePolicy: xmlstruct.TimeZonePolicyLocal,
+			expectedLine:   "v := LocalTime{Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)}",
+		},
+		{
+			name:           "raw",
+			timeLayout:     "2006-01-02T15:04:05",
+			timeZonePolicy: xmlstruct.TimeZonePolicyRaw,
+			expectedLine:   `v := RawTime{Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Raw: "2024-01-02T03:04:05"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			defaultTime := "2024-01-02T03:04:05"
+			if tc.timeZonePolicy == xmlstruct.TimeZonePolicyNone {
+				defaultTime += "Z"
+			}
+			generator := xmlstruct.NewGenerator(
+				xmlstruct.WithAttrDefaults(map[string]string{
+					"b/@t": defaultTime,
+				}),
+				xmlstruct.WithTimeLayout(tc.timeLayout),
+				xmlstruct.WithTimeZonePolicy(tc.timeZonePolicy),
+			)
+			assert.NoError(t, generator.ObserveReader(strings.NewReader(`<a><b t="`+defaultTime+`"/></a>`)))
+			assert.NoError(t, generator.ObserveReader(strings.NewReader(`<a><b/></a>`)))
+			source, err := generator.Generate()
+			assert.NoError(t, err)
+			assert.Contains(t, string(source), tc.expectedLine)
+
+			fileSet := token.NewFileSet()
+			file, err := parser.ParseFile(fileSet, "a.gen.go", source, 0)
+			assert.NoError(t, err)
+			config := &types.Config{
+				Importer: importer.Default(),
+			}
+			_, err = config.Check("main", fileSet, []*ast.File{file}, nil)
+			assert.NoError(t, err)
+		})
+	}
+}
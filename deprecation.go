@@ -0,0 +1,101 @@
+package xmlstruct
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A deprecation marks the fields matched by matcher as deprecated.
+type deprecation struct {
+	matcher FieldMatcher
+	message string
+}
+
+// WithDeprecated marks the attribute and child element fields matched by
+// matcher as deprecated, for example because the feed is phasing them out.
+// Their generated fields have a // Deprecated: comment with message, or with a
+// generic message if message is empty, so that tools warn about their use.
+func WithDeprecated(matcher FieldMatcher, message string) GeneratorOption {
+	return func(g *Generator) {
+		g.deprecations = append(g.deprecations, &deprecation{
+			matcher: matcher,
+			message: message,
+		})
+	}
+}
+
+// WithDeprecatedBuildTag excludes deprecated fields from the generated Go
+// source unless it is built with buildTag. Generate and GenerateTypeFiles
+// generate Go source without deprecated fields, constrained by !buildTag.
+// GenerateWithDeprecatedFields generates Go source with them, constrained by
+// buildTag, to be written next to it. GenerateModule writes both.
+func WithDeprecatedBuildTag(buildTag string) GeneratorOption {
+	return func(g *Generator) {
+		g.deprecatedBuildTag = buildTag
+	}
+}
+
+// GenerateWithDeprecatedFields returns the generated Go source for all the XML
+// documents observed so far, including deprecated fields. If a build tag is set
+// with WithDeprecatedBuildTag then the Go source is constrained by it, otherwise
+// it is the same as the Go source returned by Generate.
+func (g *Generator) GenerateWithDeprecatedFields() ([]byte, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	options := g.generateOptions()
+	options.deprecatedFields = true
+	return g.generate(options)
+}
+
+// deprecation returns the deprecation message of the field with path path, and
+// whether it is deprecated.
+func (options *generateOptions) deprecation(path string) (string, bool) {
+	for _, deprecation := range options.deprecations {
+		if deprecation.matcher(path) {
+			return deprecation.message, true
+		}
+	}
+	return "", false
+}
+
+// excludeField returns whether the field with path path is excluded because it
+// is deprecated and deprecated fields are not generated.
+func (options *generateOptions) excludeField(path string) bool {
+	if options.deprecatedFields {
+		return false
+	}
+	_, deprecated := options.deprecation(path)
+	return deprecated
+}
+
+// deprecatedBuildTags returns the build tags that select the Go source
+// generated with options, with or without deprecated fields.
+func (options *generateOptions) deprecatedBuildTags() []string {
+	switch {
+	case options.deprecatedBuildTag == "":
+		return nil
+	case options.deprecatedFields:
+		return []string{options.deprecatedBuildTag}
+	default:
+		return []string{"!" + options.deprecatedBuildTag}
+	}
+}
+
+// writeDeprecatedComment writes the // Deprecated: comment of f, if it is
+// deprecated, to w.
+func (f *field) writeDeprecatedComment(w io.Writer, indentPrefix string) {
+	if !f.deprecated {
+		return
+	}
+	deprecation := f.deprecation
+	if deprecation == "" {
+		deprecation = "this field is no longer used."
+	}
+	for i, line := range strings.Split(deprecation, "\n") {
+		if i == 0 {
+			line = "Deprecated: " + line
+		}
+		fmt.Fprintf(w, "%s\t// %s\n", indentPrefix, line)
+	}
+}
@@ -0,0 +1,79 @@
+package xmlstruct_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestDeprecated(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithDeprecated(xmlstruct.MatchPath("a/@old"), "use new instead."),
+		xmlstruct.WithDeprecated(xmlstruct.MatchPath("a/legacy"), ""),
+		xmlstruct.WithTopLevelAttributes(true),
+	)
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(`<a old="1" new="2"><legacy>3</legacy><b>4</b></a>`)))
+
+	actual, err := generator.Generate()
+	assert.NoError(t, err)
+	assert.Equal(t, joinLines(
+		xmlstruct.DefaultHeader,
+		"",
+		"package main",
+		"",
+		"type A struct {",
+		"\tNew int `xml:\"new,attr\"`",
+		"\t// Deprecated: use new instead.",
+		"\tOld int `xml:\"old,attr\"`",
+		"\tB   int `xml:\"b\"`",
+		"\t// Deprecated: this field is no longer used.",
+		"\tLegacy int `xml:\"legacy\"`",
+		"}",
+	), string(actual))
+}
+
+func TestDeprecatedBuildTag(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithDeprecated(xmlstruct.MatchPath("a/@old"), "use new instead."),
+		xmlstruct.WithTopLevelAttributes(true),
+		xmlstruct.WithDeprecatedBuildTag("legacy"),
+	)
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(`<a old="1" new="2"/>`)))
+
+	actual, err := generator.Generate()
+	assert.NoError(t, err)
+	assert.Equal(t, joinLines(
+		xmlstruct.DefaultHeader,
+		"",
+		"//go:build !legacy",
+		"",
+		"package main",
+		"",
+		"type A struct {",
+		"\tNew int `xml:\"new,attr\"`",
+		"}",
+	), string(actual))
+
+	actualWithDeprecatedFields, err := generator.GenerateWithDeprecatedFields()
+	assert.NoError(t, err)
+	assert.Equal(t, joinLines(
+		xmlstruct.DefaultHeader,
+		"",
+		"//go:build legacy",
+		"",
+		"package main",
+		"",
+		"type A struct {",
+		"\tNew int `xml:\"new,attr\"`",
+		"\t// Deprecated: use new instead.",
+		"\tOld int `xml:\"old,attr\"`",
+		"}",
+	), string(actualWithDeprecatedFields))
+}
@@ -9,37 +9,93 @@ import (
 	"strings"
 )
 
+// A fieldKind is the kind of a field of a generated struct.
+type fieldKind int
+
+const (
+	attrFieldKind fieldKind = iota
+	attrMapFieldKind
+	charDataFieldKind
+	elemFieldKind
+)
+
+// A field describes a field of the struct generated for an element.
+type field struct {
+	kind        fieldKind
+	name        string
+	path        string
+	value       *value
+	element     *element
+	optional    bool
+	repeated    bool
+	deprecated  bool
+	deprecation string
+}
+
+// A goFieldType describes the Go type of a field.
+type goFieldType struct {
+	slice   bool
+	pointer bool
+	name    string   // The Go type, or empty for anonymous structs.
+	named   bool     // Whether name is a generated named struct type.
+	mapType bool     // Whether name is a generated map type.
+	element *element // The element, for anonymous structs.
+	path    string   // The path of the element, for anonymous structs.
+
+	wrappedName       string // The Go type wrapped by an optional scalar wrapper, if any.
+	wrappedValueField string // The field of the optional scalar wrapper that contains the value.
+}
+
+// prefix returns the slice and pointer prefix of t.
+func (t goFieldType) prefix() string {
+	prefix := ""
+	if t.slice {
+		prefix += "[]"
+	}
+	if t.pointer {
+		prefix += "*"
+	}
+	return prefix
+}
+
 // An element describes an observed XML element, its attributes, chardata, and
 // children.
 type element struct {
-	attrValues       map[xml.Name]*value
-	charDataValue    value
-	childElements    map[xml.Name]*element
-	nestedCount      int
-	childOrder       map[xml.Name]int
-	name             xml.Name
-	optionalChildren map[xml.Name]struct{}
-	repeatedChildren map[xml.Name]struct{}
-	root             bool
+	attrCoOccurrences map[xml.Name]map[xml.Name]struct{}
+	attrValues        map[xml.Name]*value
+	charDataAbsent    bool // Whether some occurrences had no chardata.
+	charDataValue     value
+	childElements     map[xml.Name]*element
+	documentCount     int // The number of documents in which e occurred.
+	lastDocument      int // The document in which e last occurred.
+	nestedCount       int
+	childOrder        map[xml.Name]int
+	name              xml.Name
+	optionalChildren  map[xml.Name]struct{}
+	repeatedChildren  map[xml.Name]struct{}
+	root              bool
+	sample            []byte // The largest observed occurrence of e, if recorded.
 }
 
 // newElement returns a new element.
 func newElement(name xml.Name) *element {
 	return &element{
-		name:             name,
-		attrValues:       make(map[xml.Name]*value),
-		childElements:    make(map[xml.Name]*element),
-		childOrder:       make(map[xml.Name]int),
-		optionalChildren: make(map[xml.Name]struct{}),
-		repeatedChildren: make(map[xml.Name]struct{}),
+		name:              name,
+		attrCoOccurrences: make(map[xml.Name]map[xml.Name]struct{}),
+		attrValues:        make(map[xml.Name]*value),
+		childElements:     make(map[xml.Name]*element),
+		childOrder:        make(map[xml.Name]int),
+		optionalChildren:  make(map[xml.Name]struct{}),
+		repeatedChildren:  make(map[xml.Name]struct{}),
 	}
 }
 
-// observeAttrs updates e's observed attributes with attrs.
-func (e *element) observeAttrs(attrs []xml.Attr, options *observeOptions) {
+// observeAttrs updates e's observed attributes with attrs. path is the names
+// of e and its ancestors as they appear in the document.
+func (e *element) observeAttrs(attrs []xml.Attr, path []xml.Name, options *observeOptions) {
 	attrCounts := make(map[xml.Name]int)
 	for _, attr := range attrs {
-		attrName := options.nameFunc(attr.Name)
+		attrName := options.pathNameFunc(path, attr.Name)
 		if attrName == (xml.Name{}) {
 			continue
 		}
@@ -51,13 +107,16 @@ func (e *element) observeAttrs(attrs []xml.Attr, options *observeOptions) {
 			}
 			e.attrValues[attrName] = attrValue
 		}
-		attrValue.observe(attr.Value, options)
+		attrValueStr := options.sanitizeValue(path, attr.Name, attr.Value)
+		attrValue.observe(attrValueStr, options)
+		attrValue.observeIdentityValue(attrValueStr, options)
 	}
 	for attrName, count := range attrCounts {
 		if count > 1 {
 			e.attrValues[attrName].repeated = true
 		}
 	}
+	e.observeAttrCoOccurrences(attrCounts)
 	for attrName, attrValue := range e.attrValues {
 		if attrCounts[attrName] == 0 {
 			attrValue.optional = true
@@ -65,13 +124,71 @@ func (e *element) observeAttrs(attrs []xml.Attr, options *observeOptions) {
 	}
 }
 
+// observeAttrCoOccurrences updates, for each attribute in attrCounts, the set
+// of attributes that have been present every time that it was present.
+func (e *element) observeAttrCoOccurrences(attrCounts map[xml.Name]int) {
+	for attrName := range attrCounts {
+		coOccurrences, ok := e.attrCoOccurrences[attrName]
+		if !ok {
+			coOccurrences = make(map[xml.Name]struct{})
+			for otherAttrName := range attrCounts {
+				if otherAttrName != attrName {
+					coOccurrences[otherAttrName] = struct{}{}
+				}
+			}
+			e.attrCoOccurrences[attrName] = coOccurrences
+			continue
+		}
+		for otherAttrName := range coOccurrences {
+			if _, ok := attrCounts[otherAttrName]; !ok {
+				delete(coOccurrences, otherAttrName)
+			}
+		}
+	}
+}
+
+// childElement returns e's child element with name childName, creating it if
+// needed.
+func (e *element) childElement(childName xml.Name, options *observeOptions) *element {
+	childElement, ok := e.childElements[childName]
+	if !ok {
+		if options.topLevelElements != nil {
+			if topLevelElement, ok := options.topLevelElements[childName]; ok {
+				childElement = topLevelElement
+			} else {
+				topLevelElement = newElement(childName)
+				options.topLevelElements[childName] = topLevelElement
+				childElement = topLevelElement
+			}
+			if _, ok := options.typeOrder[childName]; !ok {
+				options.typeOrder[childName] = options.getOrder()
+			}
+		} else {
+			childElement = newElement(childName)
+		}
+		e.childElements[childName] = childElement
+	}
+	if _, ok := e.childOrder[childName]; !ok {
+		e.childOrder[childName] = options.getOrder()
+	}
+	return childElement
+}
+
 // observeChildElement updates e's observed chardata and child elements with
-// tokens read from decoder.
-func (e *element) observeChildElement(decoder *xml.Decoder, startElement xml.StartElement, depth int, options *observeOptions) error {
-	if options.topLevelAttributes || depth != 0 {
-		e.observeAttrs(startElement.Attr, options)
+// tokens read from decoder. path is the names of e and its ancestors as they
+// appear in the document.
+func (e *element) observeChildElement(decoder *xml.Decoder, startElement xml.StartElement, path []xml.Name, options *observeOptions) error {
+	path = slices.Clip(path)
+	if options.document != 0 && e.lastDocument != options.document {
+		e.lastDocument = options.document
+		e.documentCount++
+	}
+	if options.topLevelAttributes || len(path) > 1 {
+		e.observeAttrs(startElement.Attr, path, options)
 	}
 	childCounts := make(map[xml.Name]int)
+	charData := false
+	var whitespaceCharData []byte
 FOR:
 	for {
 		var token xml.Token
@@ -86,46 +203,43 @@ FOR:
 		}
 		switch token := token.(type) {
 		case xml.StartElement:
-			childName := options.nameFunc(token.Name)
+			if !options.observeElement(path, token) {
+				options.logSkippedElement(path, token.Name)
+				if err := options.skipElement(decoder); err != nil {
+					return err
+				}
+				break
+			}
+			childName := options.pathNameFunc(path, token.Name)
 			if childName == (xml.Name{}) {
 				break
 			}
 			childCounts[childName]++
-			childElement, ok := e.childElements[childName]
-			if !ok {
-				if options.topLevelElements != nil {
-					if topLevelElement, ok := options.topLevelElements[childName]; ok {
-						childElement = topLevelElement
-					} else {
-						topLevelElement = newElement(childName)
-						options.topLevelElements[childName] = topLevelElement
-						childElement = topLevelElement
-					}
-					if _, ok := options.typeOrder[childName]; !ok {
-						options.typeOrder[childName] = options.getOrder()
-					}
-				} else {
-					childElement = newElement(childName)
-				}
-				e.childElements[childName] = childElement
-			}
+			childElement := e.childElement(childName, options)
 			if childElement == e {
 				e.nestedCount++
 			}
-			if _, ok := e.childOrder[childName]; !ok {
-				e.childOrder[childName] = options.getOrder()
-			}
-			if err := childElement.observeChildElement(decoder, token, depth+1, options); err != nil {
+			if err := childElement.observeChildElement(decoder, token, append(path, token.Name), options); err != nil {
 				return err
 			}
 		case xml.EndElement:
 			break FOR
 		case xml.CharData:
 			if trimmedToken := bytes.TrimSpace(token); len(trimmedToken) > 0 {
-				e.charDataValue.observe(string(token), options)
+				e.charDataValue.observe(options.sanitizeValue(path, xml.Name{}, string(token)), options)
+				charData = true
+			} else if options.preserveWhitespace {
+				whitespaceCharData = append(whitespaceCharData, token...)
 			}
 		}
 	}
+	if !charData && len(childCounts) == 0 && len(whitespaceCharData) > 0 {
+		e.charDataValue.observe(options.sanitizeValue(path, xml.Name{}, string(whitespaceCharData)), options)
+		charData = true
+	}
+	if !charData {
+		e.charDataAbsent = true
+	}
 	for childName, count := range childCounts {
 		if count > 1 {
 			e.repeatedChildren[childName] = struct{}{}
@@ -139,106 +253,304 @@ FOR:
 	return nil
 }
 
-// writeGoType writes e's Go type to w.
-func (e *element) writeGoType(w io.Writer, options *generateOptions, indentPrefix string) error {
-	if options.compactTypes && e.isContainer() {
-		for _, v := range e.childElements {
-			if v == e {
-				fmt.Fprintf(w, "%s", e.charDataValue.goType(options))
-				return nil
+// writeGoType writes e's Go type to w. path is the path of e.
+func (e *element) writeGoType(w io.Writer, path string, options *generateOptions, indentPrefix string) error {
+	if !e.isStruct(options) {
+		fmt.Fprintf(w, "%s%s", e.charDataValue.goType(path, options), e.charDataValue.examplesComment(options))
+		return nil
+	}
+
+	fmt.Fprintf(w, "struct {\n")
+
+	fields, err := e.fields(path, options)
+	if err != nil {
+		return err
+	}
+
+	if e.root && options.namedRoot {
+		fmt.Fprintf(w, "%s\tXMLName xml.Name `xml:\"%s\"`\n", indentPrefix, e.name.Local)
+	}
+	embedsCommonAttrs := e.embedsCommonAttrs(options)
+	if embedsCommonAttrs {
+		fmt.Fprintf(w, "%s\t%s\n", indentPrefix, options.commonAttrs.typeName)
+	}
+	for _, field := range fields {
+		switch field.kind {
+		case attrFieldKind:
+			if embedsCommonAttrs {
+				if _, ok := options.commonAttrs.attrNames[field.value.name]; ok {
+					continue
+				}
 			}
+			field.writeDeprecatedComment(w, indentPrefix)
+			fmt.Fprintf(w, "%s\t%s %s `xml:\"%s,attr\"%s`%s\n", indentPrefix, field.name, field.value.goType(field.path, options), field.value.name.Local, field.value.exampleTag(field.path, options), field.value.examplesComment(options))
+		case attrMapFieldKind:
+			fmt.Fprintf(w, "%s\t%s %s `xml:\",any,attr\"`\n", indentPrefix, field.name, field.goFieldType(options).name)
+		case charDataFieldKind:
+			fmt.Fprintf(w, "%s\t%s %s `xml:\",chardata\"%s`%s\n", indentPrefix, field.name, field.goFieldType(options).name, field.value.exampleTag(field.path, options), field.value.examplesComment(options))
+		case elemFieldKind:
+			field.writeDeprecatedComment(w, indentPrefix)
+			fieldType := field.goFieldType(options)
+			fmt.Fprintf(w, "%s\t%s %s", indentPrefix, field.name, fieldType.prefix())
+			exampleTag, examplesComment := "", ""
+			if fieldType.element != nil {
+				if err := fieldType.element.writeGoType(w, fieldType.path, options, indentPrefix+"\t"); err != nil {
+					return err
+				}
+			} else {
+				fmt.Fprintf(w, "%s", fieldType.name)
+				if !fieldType.named {
+					charDataValue := &field.fieldElement(options).charDataValue
+					exampleTag = charDataValue.exampleTag(field.path, options)
+					examplesComment = charDataValue.examplesComment(options)
+				}
+			}
+			fmt.Fprintf(w, " `xml:\"%s\"%s`%s\n", attrName(field.element, options.compactTypes), exampleTag, examplesComment)
 		}
 	}
-
-	if len(e.attrValues) == 0 && len(e.childElements) == 0 && (!e.root || !options.namedRoot) {
-		fmt.Fprintf(w, "%s", e.charDataValue.goType(options))
-		return nil
+	if err := e.writeAnyFields(w, path, fields, options, indentPrefix); err != nil {
+		return err
 	}
 
-	fmt.Fprintf(w, "struct {\n")
+	fmt.Fprintf(w, "%s}", indentPrefix)
+	return nil
+}
 
+// fields returns the fields of the struct generated for e, in order: first
+// attributes, then chardata, then child elements. path is the path of e.
+func (e *element) fields(path string, options *generateOptions) ([]*field, error) {
 	fieldNames := make(map[string]struct{})
 
-	attrValuesByExportedName := make(map[string]*value, len(e.attrValues))
-	for attrName, attrValue := range e.attrValues {
-		exportedAttrName := options.exportNameFunc(attrName) + options.attrNameSuffix
+	attrFields := make([]*field, 0, len(e.attrValues))
+	if e.hasAttrMap(options) {
+		fieldNames[attrMapFieldName] = struct{}{}
+		attrFields = append(attrFields, &field{
+			kind: attrMapFieldKind,
+			name: attrMapFieldName,
+			path: path,
+		})
+	}
+	for _, attrValue := range e.attrValues {
+		if e.hasAttrMap(options) {
+			break
+		}
+		attrPath := path + "/@" + attrValue.name.Local
+		if options.excludeField(attrPath) {
+			options.logDecision("excluded deprecated field", attrPath)
+			continue
+		}
+		exportedAttrName := options.exportNameFunc(attrValue.name) + options.attrNameSuffix
 		if _, ok := fieldNames[exportedAttrName]; ok {
-			return fmt.Errorf("%s: duplicate field name", exportedAttrName)
+			return nil, fmt.Errorf("%s: duplicate field name", exportedAttrName)
 		}
 		fieldNames[exportedAttrName] = struct{}{}
-		attrValuesByExportedName[exportedAttrName] = attrValue
-	}
-	if e.root && options.namedRoot {
-		fmt.Fprintf(w, "%s\tXMLName xml.Name `xml:\"%s\"`\n", indentPrefix, e.name.Local)
-	}
-	for _, exportedAttrName := range sortedKeys(attrValuesByExportedName) {
-		attrValue := attrValuesByExportedName[exportedAttrName]
-		fmt.Fprintf(w, "%s\t%s %s `xml:\"%s,attr\"`\n", indentPrefix, exportedAttrName, attrValue.goType(options), attrValue.name.Local)
+		optional, _ := options.cardinality(attrPath, attrValue.optional, attrValue.repeated)
+		if _, ok := options.attrDefault(e, attrPath); ok {
+			// Attributes with defaults are optional so that ApplyDefaults can
+			// distinguish unset attributes from attributes set to zero values.
+			optional = true
+		}
+		if optional != attrValue.optional {
+			declaredAttrValue := *attrValue
+			declaredAttrValue.optional = optional
+			attrValue = &declaredAttrValue
+		}
+		deprecation, deprecated := options.deprecation(attrPath)
+		attrFields = append(attrFields, &field{
+			kind:        attrFieldKind,
+			name:        exportedAttrName,
+			path:        attrPath,
+			value:       attrValue,
+			optional:    attrValue.optional,
+			repeated:    attrValue.repeated,
+			deprecated:  deprecated,
+			deprecation: deprecation,
+		})
 	}
+	slices.SortFunc(attrFields, func(a, b *field) int {
+		return strings.Compare(a.name, b.name)
+	})
+	fields := attrFields
 
 	if e.charDataValue.observations > 0 {
 		fieldName := options.charDataFieldName
+		if options.charDataElementName {
+			fieldName = options.exportNameFunc(e.name)
+		}
 		if _, ok := fieldNames[fieldName]; ok {
-			return fmt.Errorf("%s: duplicate field name", fieldName)
+			return nil, fmt.Errorf("%s: duplicate field name", fieldName)
 		}
 		fieldNames[fieldName] = struct{}{}
-		fmt.Fprintf(w, "%s\t%s string `xml:\",chardata\"`\n", indentPrefix, fieldName)
+		fields = append(fields, &field{
+			kind:    charDataFieldKind,
+			name:    fieldName,
+			path:    path,
+			value:   &e.charDataValue,
+			element: e,
+		})
+	}
+
+	if options.logger != nil {
+		childNames := mapKeys(e.childElements)
+		slices.SortFunc(childNames, compareNames)
+		for _, childName := range childNames {
+			if childElement := e.childElements[childName]; childElement.rare(options) {
+				options.logDecision("excluded rare element", path+"/"+childName.Local, "documents", childElement.documentCount)
+			}
+		}
+	}
+	for _, childElement := range e.sortedChildElements(options) {
+		childPath := path + "/" + childElement.name.Local
+		if options.excludeField(childPath) {
+			options.logDecision("excluded deprecated field", childPath)
+			continue
+		}
+		exportedChildName := exportedName(childElement, options)
+		fieldNames[exportedChildName] = struct{}{}
+		if keyAttrName, ok := options.mapElementKeyAttrName(childElement); ok {
+			if err := checkMapElement(childElement, keyAttrName); err != nil {
+				return nil, err
+			}
+		}
+		_, optional := e.optionalChildren[childElement.name]
+		_, repeated := e.repeatedChildren[childElement.name]
+		optional, repeated = options.cardinality(childPath, optional, repeated)
+		deprecation, deprecated := options.deprecation(childPath)
+		fields = append(fields, &field{
+			kind:        elemFieldKind,
+			name:        exportedChildName,
+			path:        childPath,
+			element:     childElement,
+			optional:    optional,
+			repeated:    repeated,
+			deprecated:  deprecated,
+			deprecation: deprecation,
+		})
+	}
+
+	return fields, nil
+}
+
+// goFieldType returns the Go type of f.
+func (f *field) goFieldType(options *generateOptions) goFieldType {
+	switch f.kind {
+	case attrFieldKind:
+		return f.value.goFieldType(f.path, options)
+	case attrMapFieldKind:
+		return options.attrMapGoFieldType()
+	case charDataFieldKind:
+		if fieldType, ok := options.mappedGoFieldType(f.path); ok {
+			return fieldType
+		}
+		// Mixed content is decoded as a single string, so its type cannot be
+		// inferred from its separate chardata.
+		if len(f.element.childElements) != 0 {
+			return goFieldType{
+				name: "string",
+			}
+		}
+		if f.element.charDataAbsent && !decodesEmptyCharData(f.value, options) {
+			return goFieldType{
+				name: "string",
+			}
+		}
+		return f.value.goFieldType(f.path, options)
 	}
 
-	childElements := mapValues(e.childElements)
+	if keyAttrName, ok := options.mapElementKeyAttrName(f.element); ok {
+		return options.mapGoFieldType(f.element, keyAttrName)
+	}
+
+	var fieldType goFieldType
+	currentChild := f.fieldElement(options)
+	if topLevelElement, ok := options.namedTypes[currentChild.name]; ok {
+		fieldType.name = options.exportTypeNameFunc(topLevelElement.name)
+		fieldType.named = true
+	} else if _, ok := options.simpleTypes[currentChild.name]; ok {
+		fieldType.name = currentChild.charDataValue.goType(f.path, options)
+	} else if currentChild.isStruct(options) {
+		fieldType.element = currentChild
+		fieldType.path = f.path
+	} else {
+		fieldType.name = currentChild.charDataValue.goType(f.path, options)
+	}
+
+	isStruct := fieldType.named || fieldType.element != nil
+	switch {
+	case f.repeated:
+		fieldType.slice = true
+		fieldType.pointer = options.pointerPolicy.RepeatedElements
+	case f.optional && isStruct:
+		fieldType.pointer = options.pointerPolicy.OptionalStructs
+	case f.optional:
+		fieldType = options.optionalScalarGoFieldType(fieldType)
+	case isStruct:
+		fieldType.pointer = options.pointerPolicy.RequiredStructs
+	}
+	return fieldType
+}
+
+// fieldElement returns the element whose type is used for the child element
+// field f, skipping container elements if options.compactTypes is set.
+func (f *field) fieldElement(options *generateOptions) *element {
+	if options.compactTypes {
+		return firstNotContainerElement(f.element)
+	}
+	return f.element
+}
+
+// sortedChildElements returns e's child elements in the order in which they
+// should be generated, excluding rare child elements.
+func (e *element) sortedChildElements(options *generateOptions) []*element {
+	childElements := slices.DeleteFunc(mapValues(e.childElements), func(childElement *element) bool {
+		return childElement.rare(options)
+	})
 	if options.preserveOrder {
 		slices.SortFunc(childElements, func(a, b *element) int {
 			return e.childOrder[a.name] - e.childOrder[b.name]
 		})
 	} else {
 		slices.SortFunc(childElements, func(a, b *element) int {
-			aExportedName := exportedNameWithoutSuffix(a, options)
-			bExportedName := exportedNameWithoutSuffix(b, options)
-			switch {
-			case aExportedName < bExportedName:
-				return -1
-			case aExportedName == bExportedName:
-				return 0
-			default:
-				return 1
-			}
+			return strings.Compare(exportedNameWithoutSuffix(a, options), exportedNameWithoutSuffix(b, options))
 		})
 	}
+	return childElements
+}
 
-	for _, childElement := range childElements {
-		exportedChildName := exportedName(childElement, options)
-		if _, ok := fieldNames[exportedChildName]; ok {
-			fieldNames[exportedChildName] = struct{}{}
-		}
-		fieldNames[exportedChildName] = struct{}{}
+// rare returns whether e occurred in fewer than the minimum fraction of
+// documents set with WithMinOccurrenceRate. Elements that were not observed in
+// documents, for example those observed in Go source, are never rare.
+func (e *element) rare(options *generateOptions) bool {
+	return options.minOccurrenceRate > 0 && e.documentCount > 0 &&
+		float64(e.documentCount) < options.minOccurrenceRate*float64(options.documents)
+}
 
-		fmt.Fprintf(w, "%s\t%s ", indentPrefix, exportedChildName)
-		if _, repeated := e.repeatedChildren[childElement.name]; repeated {
-			fmt.Fprintf(w, "[]")
-		} else if options.usePointersForOptionalFields {
-			if _, optional := e.optionalChildren[childElement.name]; optional {
-				fmt.Fprintf(w, "*")
-			}
+// hasRareChildElements returns whether any of e's child elements are rare.
+func (e *element) hasRareChildElements(options *generateOptions) bool {
+	for _, childElement := range e.childElements {
+		if childElement.rare(options) {
+			return true
 		}
+	}
+	return false
+}
 
-		currentChild := childElement
-		if options.compactTypes {
-			currentChild = firstNotContainerElement(childElement)
-		}
-		if topLevelElement, ok := options.namedTypes[currentChild.name]; ok {
-			fmt.Fprintf(w, "%s", options.exportTypeNameFunc(topLevelElement.name))
-		} else if _, ok := options.simpleTypes[currentChild.name]; ok {
-			fmt.Fprintf(w, "%s", currentChild.charDataValue.goType(options))
-		} else {
-			if err := currentChild.writeGoType(w, options, indentPrefix+"\t"); err != nil {
-				return err
+// isStruct returns whether e's Go type is a struct.
+func (e *element) isStruct(options *generateOptions) bool {
+	if options.compactTypes && e.isContainer() {
+		for _, v := range e.childElements {
+			if v == e {
+				return false
 			}
 		}
-		fmt.Fprintf(w, " `xml:\"%s\"`\n", attrName(childElement, options.compactTypes))
 	}
+	return !e.isSimple() || e.root && options.namedRoot
+}
 
-	fmt.Fprintf(w, "%s}", indentPrefix)
-	return nil
+// isSimple returns whether e has only chardata, and so is represented by a
+// simple Go type rather than a struct.
+func (e *element) isSimple() bool {
+	return len(e.attrValues) == 0 && len(e.childElements) == 0
 }
 
 func (e *element) isContainer() bool {
@@ -286,3 +598,20 @@ func attrName(el *element, compactTypes bool) string {
 	}
 	return el.name.Local
 }
+
+// decodesEmptyCharData returns whether encoding/xml unmarshals empty chardata
+// into the Go type inferred for v, as its zero value. Types like time.Time and
+// the localized number types reject empty text.
+func decodesEmptyCharData(v *value, options *generateOptions) bool {
+	kind, tolerant := v.resolvedKind(options)
+	switch {
+	case tolerant:
+		return true
+	case kind == intValueKind || kind == float64ValueKind:
+		return !options.numberFormat.localized()
+	case kind == timeValueKind:
+		return false
+	default:
+		return true
+	}
+}
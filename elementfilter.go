@@ -0,0 +1,82 @@
+package xmlstruct
+
+import (
+	"encoding/xml"
+)
+
+// An ElementFilter returns whether an element is observed, given the names of
+// its ancestors, from the top level element to its parent, as they appear in
+// the document, and its start element, including its attributes. Elements that
+// are not observed are skipped with all of their descendants, as if they were
+// absent from the document. ancestors must not be modified or retained.
+type ElementFilter func(ancestors []xml.Name, startElement xml.StartElement) bool
+
+// SkipElementsWithAttr returns an ElementFilter that skips elements with local
+// name localName that have an attribute with local name attrLocalName and value
+// attrValue. For example, SkipElementsWithAttr("field", "deprecated", "true")
+// skips <ext:field deprecated="true"> elements.
+func SkipElementsWithAttr(localName, attrLocalName, attrValue string) ElementFilter {
+	return func(_ []xml.Name, startElement xml.StartElement) bool {
+		return startElement.Name.Local != localName || !hasAttr(startElement, attrLocalName, attrValue)
+	}
+}
+
+// OnlyElementsWithAttr returns an ElementFilter that skips elements with local
+// name localName unless they have an attribute with local name attrLocalName
+// and value attrValue. For example, OnlyElementsWithAttr("record", "type",
+// "order") observes only <record type="order"> elements of all record
+// elements.
+func OnlyElementsWithAttr(localName, attrLocalName, attrValue string) ElementFilter {
+	return func(_ []xml.Name, startElement xml.StartElement) bool {
+		return startElement.Name.Local != localName || hasAttr(startElement, attrLocalName, attrValue)
+	}
+}
+
+// hasAttr returns whether startElement has an attribute with local name
+// attrLocalName and value attrValue.
+func hasAttr(startElement xml.StartElement, attrLocalName, attrValue string) bool {
+	for _, attr := range startElement.Attr {
+		if attr.Name.Local == attrLocalName && attr.Value == attrValue {
+			return true
+		}
+	}
+	return false
+}
+
+// observeElement returns whether the element startElement with ancestors is
+// observed according to options' element filters.
+func (options *observeOptions) observeElement(ancestors []xml.Name, startElement xml.StartElement) bool {
+	for _, elementFilter := range options.elementFilters {
+		if !elementFilter(ancestors, startElement) {
+			return false
+		}
+	}
+	return true
+}
+
+// skipElement reads and discards tokens from decoder until the end of the
+// current element.
+func (options *observeOptions) skipElement(decoder *xml.Decoder) error {
+	depth := 0
+	for {
+		var token xml.Token
+		var err error
+		if options.useRawToken {
+			token, err = decoder.RawToken()
+		} else {
+			token, err = decoder.Token()
+		}
+		if err != nil {
+			return err
+		}
+		switch token.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			if depth == 0 {
+				return nil
+			}
+			depth--
+		}
+	}
+}
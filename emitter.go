@@ -0,0 +1,302 @@
+package xmlstruct
+
+import (
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// DefaultEmitter is the name of the emitter that generates Go source.
+const DefaultEmitter = "go"
+
+// An Emitter generates output files, for example source code in another
+// language or documentation, from the model inferred from observed XML
+// documents. Emitters are registered with RegisterEmitter and run with
+// Generator.Emit.
+type Emitter interface {
+	Emit(model *Model) ([]EmittedFile, error)
+}
+
+// An EmitterFunc is a function that implements Emitter.
+type EmitterFunc func(model *Model) ([]EmittedFile, error)
+
+// Emit implements Emitter.Emit.
+func (f EmitterFunc) Emit(model *Model) ([]EmittedFile, error) {
+	return f(model)
+}
+
+// An EmittedFile is a file generated by an Emitter. Name is relative to the
+// output directory.
+type EmittedFile struct {
+	Name     string
+	Contents []byte
+}
+
+// A Model is the model inferred from observed XML documents, as seen by
+// emitters. Types are named as in the generated Go source, and nested elements
+// that would be anonymous structs in the generated Go source are given their
+// own types.
+//
+// Generator is a snapshot of the Generator that inferred the model, which is
+// not changed by later observations. Emitters that build on the other outputs
+// of the Generator, like the built-in emitters, call its methods.
+type Model struct {
+	Header              string
+	PackageName         string
+	GeneratedFileSuffix string
+	Types               []ModelType
+	Generator           *Generator
+}
+
+// A ModelType is a type in a Model. Types of elements that have only chardata
+// are scalar, and have no fields.
+type ModelType struct {
+	Name    string
+	XMLName xml.Name
+	Path    string
+	Root    bool
+	Scalar  ModelScalar // The type of the element's chardata, if it is scalar.
+	Fields  []ModelField
+}
+
+// A ModelField is a field of a ModelType.
+type ModelField struct {
+	Kind     ModelFieldKind
+	Name     string   // The name of the field in the generated Go source.
+	XMLName  xml.Name // The name of the attribute or element, if any.
+	Path     string
+	TypeName string      // The name of the field's type, for elements that are not scalar.
+	Scalar   ModelScalar // The type of the field's values, otherwise.
+	Optional bool
+	Repeated bool
+
+	Deprecated  bool
+	Deprecation string
+}
+
+// A ModelFieldKind is the kind of a ModelField.
+type ModelFieldKind int
+
+const (
+	ModelFieldAttr     ModelFieldKind = iota // An attribute.
+	ModelFieldAttrMap                        // A map of all attributes.
+	ModelFieldCharData                       // The element's chardata.
+	ModelFieldElement                        // A child element.
+)
+
+// A ModelScalar is the type of scalar values in a Model.
+type ModelScalar string
+
+const (
+	ModelScalarBool   ModelScalar = "bool"
+	ModelScalarInt    ModelScalar = "int"
+	ModelScalarFloat  ModelScalar = "float"
+	ModelScalarTime   ModelScalar = "time"
+	ModelScalarString ModelScalar = "string"
+)
+
+var (
+	emittersMutex sync.RWMutex
+	emitters      = map[string]Emitter{
+		DefaultEmitter: EmitterFunc(emitGo),
+		"graphql":      EmitterFunc(emitGraphQL),
+		"openapi":      EmitterFunc(emitOpenAPI),
+		"schematron":   EmitterFunc(emitSchematron),
+	}
+)
+
+// RegisterEmitter makes emitter available with name. It panics if an emitter
+// with name is already registered. It is intended to be called from the init
+// functions of packages that implement emitters.
+func RegisterEmitter(name string, emitter Emitter) {
+	emittersMutex.Lock()
+	defer emittersMutex.Unlock()
+	if emitter == nil {
+		panic("xmlstruct: RegisterEmitter emitter is nil")
+	}
+	if _, ok := emitters[name]; ok {
+		panic("xmlstruct: RegisterEmitter called twice for emitter " + name)
+	}
+	emitters[name] = emitter
+}
+
+// Emitters returns the sorted names of the registered emitters.
+func Emitters() []string {
+	emittersMutex.RLock()
+	defer emittersMutex.RUnlock()
+	names := mapKeys(emitters)
+	slices.Sort(names)
+	return names
+}
+
+// Emit runs the emitter registered with name on the model inferred from all
+// the XML documents observed so far, and returns its files. Each call takes a
+// snapshot of g, which copies the whole model, so that the Model and its
+// Generator see the same observations even if g continues to observe documents
+// while the emitter runs.
+func (g *Generator) Emit(name string) ([]EmittedFile, error) {
+	emittersMutex.RLock()
+	emitter, ok := emitters[name]
+	emittersMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%s: unknown emitter", name)
+	}
+	model, err := g.Snapshot().model()
+	if err != nil {
+		return nil, err
+	}
+	return emitter.Emit(model)
+}
+
+// model returns g's Model.
+func (g *Generator) model() (*Model, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	options := g.generateOptions()
+
+	schemaTypes, typeNamesByElement, err := g.schemaTypes(options)
+	if err != nil {
+		return nil, err
+	}
+
+	model := &Model{
+		Header:              g.header,
+		PackageName:         g.packageName,
+		GeneratedFileSuffix: g.generatedFileSuffix,
+		Types:               make([]ModelType, 0, len(schemaTypes)),
+		Generator:           g,
+	}
+	for _, schemaType := range schemaTypes {
+		modelType := ModelType{
+			Name:    schemaType.name,
+			XMLName: schemaType.element.name,
+			Path:    schemaType.path,
+			Root:    schemaType.element.root,
+		}
+		if schemaType.scalar {
+			modelType.Scalar = modelScalar(&schemaType.element.charDataValue, options)
+			model.Types = append(model.Types, modelType)
+			continue
+		}
+		fields, err := schemaType.element.fields(schemaType.path, options)
+		if err != nil {
+			return nil, err
+		}
+		for _, field := range fields {
+			modelField := ModelField{
+				Name:        field.name,
+				Path:        field.path,
+				Optional:    field.optional,
+				Repeated:    field.repeated,
+				Deprecated:  field.deprecated,
+				Deprecation: field.deprecation,
+			}
+			switch field.kind {
+			case attrFieldKind:
+				modelField.Kind = ModelFieldAttr
+				modelField.XMLName = field.value.name
+				modelField.Scalar = modelScalar(field.value, options)
+			case attrMapFieldKind:
+				modelField.Kind = ModelFieldAttrMap
+				modelField.Scalar = ModelScalarString
+			case charDataFieldKind:
+				modelField.Kind = ModelFieldCharData
+				modelField.Scalar = modelScalar(field.value, options)
+				if len(field.element.childElements) != 0 {
+					modelField.Scalar = ModelScalarString
+				}
+			case elemFieldKind:
+				modelField.Kind = ModelFieldElement
+				modelField.XMLName = field.element.name
+				if typeName, ok := typeNamesByElement[field.element]; ok {
+					modelField.TypeName = typeName
+				} else {
+					modelField.Scalar = modelScalar(&field.element.charDataValue, options)
+				}
+			}
+			modelType.Fields = append(modelType.Fields, modelField)
+		}
+		model.Types = append(model.Types, modelType)
+	}
+	return model, nil
+}
+
+// modelScalar returns the ModelScalar of v.
+func modelScalar(v *value, options *generateOptions) ModelScalar {
+	kind, tolerant := v.resolvedKind(options)
+	if tolerant {
+		return ModelScalarString
+	}
+	switch kind {
+	case boolValueKind:
+		return ModelScalarBool
+	case intValueKind:
+		return ModelScalarInt
+	case float64ValueKind:
+		return ModelScalarFloat
+	case timeValueKind:
+		return ModelScalarTime
+	default:
+		return ModelScalarString
+	}
+}
+
+// emitGo is the Emitter that generates Go source, as Generate does.
+func emitGo(model *Model) ([]EmittedFile, error) {
+	source, err := model.Generator.Generate()
+	if err != nil {
+		return nil, err
+	}
+	return []EmittedFile{
+		{
+			Name:     "types" + model.GeneratedFileSuffix,
+			Contents: source,
+		},
+	}, nil
+}
+
+// emitGraphQL is the Emitter that generates a GraphQL schema, as
+// GenerateGraphQLSchema does.
+func emitGraphQL(model *Model) ([]EmittedFile, error) {
+	schema, err := model.Generator.GenerateGraphQLSchema()
+	if err != nil {
+		return nil, err
+	}
+	return []EmittedFile{
+		{
+			Name:     "schema.graphql",
+			Contents: schema,
+		},
+	}, nil
+}
+
+// emitOpenAPI is the Emitter that generates OpenAPI components, as
+// GenerateOpenAPIComponents does.
+func emitOpenAPI(model *Model) ([]EmittedFile, error) {
+	components, err := model.Generator.GenerateOpenAPIComponents()
+	if err != nil {
+		return nil, err
+	}
+	return []EmittedFile{
+		{
+			Name:     "openapi.json",
+			Contents: components,
+		},
+	}, nil
+}
+
+// emitSchematron is the Emitter that generates a Schematron schema, as
+// GenerateSchematron does.
+func emitSchematron(model *Model) ([]EmittedFile, error) {
+	schema, err := model.Generator.GenerateSchematron()
+	if err != nil {
+		return nil, err
+	}
+	return []EmittedFile{
+		{
+			Name:     "schema.sch",
+			Contents: schema,
+		},
+	}, nil
+}
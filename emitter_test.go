@@ -0,0 +1,90 @@
+package xmlstruct_test
+
+import (
+	"encoding/xml"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestEmit(t *testing.T) {
+	t.Parallel()
+
+	var actualModel *xmlstruct.Model
+	xmlstruct.RegisterEmitter("test", xmlstruct.EmitterFunc(func(model *xmlstruct.Model) ([]xmlstruct.EmittedFile, error) {
+		actualModel = model
+		return []xmlstruct.EmittedFile{
+			{
+				Name:     "model.txt",
+				Contents: []byte(model.Types[0].Name),
+			},
+		}, nil
+	}))
+	assert.True(t, slices.Contains(xmlstruct.Emitters(), "test"))
+
+	generator := xmlstruct.NewGenerator()
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(`<a x="1"><b><c>true</c></b><b/></a>`)))
+
+	files, err := generator.Emit("test")
+	assert.NoError(t, err)
+	assert.Equal(t, []xmlstruct.EmittedFile{
+		{
+			Name:     "model.txt",
+			Contents: []byte("A"),
+		},
+	}, files)
+	assert.Equal(t, "main", actualModel.PackageName)
+	assert.Equal(t, xmlstruct.DefaultGeneratedFileSuffix, actualModel.GeneratedFileSuffix)
+	assert.Equal(t, generator.Fingerprint(), actualModel.Generator.Fingerprint())
+	assert.Equal(t, []xmlstruct.ModelType{
+		{
+			Name:    "A",
+			XMLName: xml.Name{Local: "a"},
+			Path:    "a",
+			Root:    true,
+			Fields: []xmlstruct.ModelField{
+				{
+					Kind:     xmlstruct.ModelFieldElement,
+					Name:     "B",
+					XMLName:  xml.Name{Local: "b"},
+					Path:     "a/b",
+					TypeName: "B",
+					Repeated: true,
+				},
+			},
+		},
+		{
+			Name:    "B",
+			XMLName: xml.Name{Local: "b"},
+			Path:    "a/b",
+			Fields: []xmlstruct.ModelField{
+				{
+					Kind:     xmlstruct.ModelFieldElement,
+					Name:     "C",
+					XMLName:  xml.Name{Local: "c"},
+					Path:     "a/b/c",
+					Scalar:   xmlstruct.ModelScalarBool,
+					Optional: true,
+				},
+			},
+		},
+	}, actualModel.Types)
+
+	goFiles, err := generator.Emit(xmlstruct.DefaultEmitter)
+	assert.NoError(t, err)
+	source, err := generator.Generate()
+	assert.NoError(t, err)
+	assert.Equal(t, []xmlstruct.EmittedFile{
+		{
+			Name:     "types" + xmlstruct.DefaultGeneratedFileSuffix,
+			Contents: source,
+		},
+	}, goFiles)
+
+	_, err = generator.Emit("unknown")
+	assert.EqualError(t, err, "unknown: unknown emitter")
+}
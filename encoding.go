@@ -0,0 +1,178 @@
+package xmlstruct
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
+)
+
+// sniffLen is the number of bytes at the start of a document that are read to
+// determine its charset.
+const sniffLen = 1024
+
+// A CharsetPrecedence determines which of a byte order mark and an XML
+// declaration determines the charset of a document that has both.
+type CharsetPrecedence int
+
+const (
+	// CharsetPrecedenceDeclaration uses the encoding of the XML declaration.
+	CharsetPrecedenceDeclaration CharsetPrecedence = iota
+	// CharsetPrecedenceBOM uses the byte order mark, for example for legacy
+	// documents that were converted to UTF-8 without updating their XML
+	// declaration.
+	CharsetPrecedenceBOM
+)
+
+var (
+	utf8BOM    = []byte{0xef, 0xbb, 0xbf}
+	utf16BEBOM = []byte{0xfe, 0xff}
+	utf16LEBOM = []byte{0xff, 0xfe}
+
+	xmlDeclEncodingRx = regexp.MustCompile(`\A<\?xml[^>]*?\sencoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+)
+
+// A DecodeError is an error decoding an observed XML document.
+type DecodeError struct {
+	// Name is the name of the file that contains the document, if known.
+	Name string
+	// Offset is the offset in the document, after conversion to UTF-8, at
+	// which the error occurred.
+	Offset int64
+	// Charset is the name of the charset of the document, or empty if it was
+	// not determined.
+	Charset string
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	var sb strings.Builder
+	if e.Name != "" {
+		fmt.Fprintf(&sb, "%s: ", e.Name)
+	}
+	fmt.Fprintf(&sb, "offset %d: ", e.Offset)
+	if e.Charset != "" && e.Charset != "utf-8" {
+		fmt.Fprintf(&sb, "charset %s: ", e.Charset)
+	}
+	sb.WriteString(e.Err.Error())
+	return sb.String()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// sniffCharset returns the charset of the document at the start of prefix, the
+// length of its byte order mark, and whether the charset was determined by the
+// byte order mark.
+func sniffCharset(prefix []byte, charsetPrecedence CharsetPrecedence) (label string, bomLen int, fromBOM bool) {
+	var bomLabel string
+	switch {
+	case bytes.HasPrefix(prefix, utf8BOM):
+		bomLabel, bomLen = "utf-8", len(utf8BOM)
+	case bytes.HasPrefix(prefix, utf16BEBOM):
+		bomLabel, bomLen = "utf-16be", len(utf16BEBOM)
+	case bytes.HasPrefix(prefix, utf16LEBOM):
+		bomLabel, bomLen = "utf-16le", len(utf16LEBOM)
+	}
+	var declLabel string
+	if m := xmlDeclEncodingRx.FindSubmatch(prefix[bomLen:]); m != nil {
+		declLabel = string(m[1])
+	}
+	switch {
+	case bomLabel != "" && (declLabel == "" || charsetPrecedence == CharsetPrecedenceBOM || bomLabel != "utf-8"):
+		// A UTF-16 byte order mark always wins, as the XML declaration of a
+		// UTF-16 document cannot be read without it.
+		return bomLabel, bomLen, true
+	case declLabel != "":
+		return declLabel, bomLen, false
+	default:
+		return "utf-8", bomLen, false
+	}
+}
+
+// charsetReader returns a reader that converts the document read from r to
+// UTF-8, according to g's encoding options, and the name of its charset.
+func (g *Generator) charsetReader(r io.Reader) (io.Reader, string, error) {
+	bufferedReader := bufio.NewReaderSize(r, sniffLen)
+	prefix, err := bufferedReader.Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, "", err
+	}
+	label, bomLen, fromBOM := sniffCharset(prefix, g.charsetPrecedence)
+	if g.stripBOM || fromBOM && label != "utf-8" {
+		if _, err := bufferedReader.Discard(bomLen); err != nil {
+			return nil, "", err
+		}
+	}
+	enc, name := charset.Lookup(label)
+	if enc == nil {
+		return nil, label, fmt.Errorf("%s: unsupported charset", label)
+	}
+	if name != "utf-8" {
+		return transform.NewReader(bufferedReader, enc.NewDecoder()), name, nil
+	}
+	if len(g.fallbackCharsets) == 0 {
+		return bufferedReader, name, nil
+	}
+	fallbackEncodings := make([]encoding.Encoding, 0, len(g.fallbackCharsets))
+	for _, fallbackCharset := range g.fallbackCharsets {
+		fallbackEncoding, _ := charset.Lookup(fallbackCharset)
+		if fallbackEncoding == nil {
+			return nil, name, fmt.Errorf("%s: unsupported charset", fallbackCharset)
+		}
+		fallbackEncodings = append(fallbackEncodings, fallbackEncoding)
+	}
+	return transform.NewReader(bufferedReader, &utf8FallbackTransformer{
+		fallbackEncodings: fallbackEncodings,
+	}), name, nil
+}
+
+// A utf8FallbackTransformer is a transform.Transformer that passes through
+// valid UTF-8 and decodes each byte that is not valid UTF-8 with the first of
+// its fallback encodings that decodes it. Bytes that no fallback encoding
+// decodes are passed through, so that the XML decoder reports them.
+type utf8FallbackTransformer struct {
+	transform.NopResetter
+	fallbackEncodings []encoding.Encoding
+}
+
+// Transform implements transform.Transformer.Transform.
+func (t *utf8FallbackTransformer) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for nSrc < len(src) {
+		if !atEOF && !utf8.FullRune(src[nSrc:]) {
+			return nDst, nSrc, transform.ErrShortSrc
+		}
+		r, size := utf8.DecodeRune(src[nSrc:])
+		decoded := src[nSrc : nSrc+size]
+		if r == utf8.RuneError && size == 1 {
+			decoded = t.decodeByte(decoded)
+		}
+		if nDst+len(decoded) > len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		nDst += copy(dst[nDst:], decoded)
+		nSrc += size
+	}
+	return nDst, nSrc, nil
+}
+
+// decodeByte returns the UTF-8 encoding of b, which is not valid UTF-8, in the
+// first of t's fallback encodings that decodes it.
+func (t *utf8FallbackTransformer) decodeByte(b []byte) []byte {
+	for _, fallbackEncoding := range t.fallbackEncodings {
+		decoded, err := fallbackEncoding.NewDecoder().Bytes(b)
+		if err == nil && len(decoded) > 0 && !bytes.Equal(decoded, []byte(string(utf8.RuneError))) {
+			return decoded
+		}
+	}
+	return b
+}
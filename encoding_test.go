@@ -0,0 +1,106 @@
+package xmlstruct_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestEncoding(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name        string
+		options     []xmlstruct.GeneratorOption
+		xmlStr      string
+		expectedErr string
+	}{
+		{
+			name:   "utf8_bom",
+			xmlStr: "\xef\xbb\xbf<a>é</a>",
+		},
+		{
+			name: "utf16le_bom",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithStripBOM(true),
+			},
+			xmlStr: "\xff\xfe<\x00a\x00>\x00\xe9\x00<\x00/\x00a\x00>\x00",
+		},
+		{
+			name: "bom_precedence",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithCharsetPrecedence(xmlstruct.CharsetPrecedenceBOM),
+			},
+			xmlStr: "\xef\xbb\xbf<?xml version=\"1.0\" encoding=\"windows-1252\"?><a>é</a>",
+		},
+		{
+			name: "declaration_precedence",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithStripBOM(true),
+			},
+			xmlStr: "\xef\xbb\xbf<?xml version=\"1.0\" encoding=\"windows-1252\"?><a>\xe9</a>",
+		},
+		{
+			name: "fallback_charsets",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithFallbackCharsets("windows-1252"),
+			},
+			xmlStr: "<?xml version=\"1.0\" encoding=\"utf-8\"?><a>\xe9</a>",
+		},
+		{
+			name:        "invalid_utf8",
+			xmlStr:      "<?xml version=\"1.0\" encoding=\"utf-8\"?><a>\xe9</a>",
+			expectedErr: "offset 42: XML syntax error on line 1: invalid UTF-8",
+		},
+		{
+			name: "unsupported_fallback_charset",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithFallbackCharsets("x-unknown"),
+			},
+			xmlStr:      "<a>é</a>",
+			expectedErr: "offset 0: x-unknown: unsupported charset",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			generator := xmlstruct.NewGenerator(append(tc.options,
+				xmlstruct.WithValueExamples(1),
+			)...)
+			err := generator.ObserveReader(strings.NewReader(tc.xmlStr))
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			actual, err := generator.Generate()
+			assert.NoError(t, err)
+			assert.Equal(t, joinLines(
+				xmlstruct.DefaultHeader,
+				"",
+				"package main",
+				"",
+				"type A string // Examples: \"é\"",
+			), string(actual))
+		})
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "a.xml")
+	assert.NoError(t, os.WriteFile(name, []byte("<a><b>ok</b><b>\xe9</b></a>"), 0o666))
+
+	err := xmlstruct.NewGenerator().ObserveFile(name)
+	var decodeError *xmlstruct.DecodeError
+	assert.True(t, errors.As(err, &decodeError))
+	assert.Equal(t, name, decodeError.Name)
+	assert.Equal(t, int64(16), decodeError.Offset)
+	assert.EqualError(t, err, name+": offset 16: XML syntax error on line 1: invalid UTF-8")
+}
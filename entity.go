@@ -0,0 +1,92 @@
+package xmlstruct
+
+import (
+	"encoding/xml"
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+)
+
+// An EntityResolver returns the replacement text of the external general
+// entity name declared with the public identifier publicID, which may be
+// empty, and the system identifier systemID, typically a URL or file name.
+type EntityResolver func(name, publicID, systemID string) (string, error)
+
+// entityDeclRx matches general entity declarations in a DOCTYPE directive.
+// Its submatches are the entity's name, its system identifier if it is
+// declared with SYSTEM, its public and system identifiers if it is declared
+// with PUBLIC, its value if it is internal, and any NDATA annotation.
+var entityDeclRx = regexp.MustCompile(`<!ENTITY\s+([^\s%>]+)\s+` +
+	`(?:SYSTEM\s+("[^"]*"|'[^']*')|PUBLIC\s+("[^"]*"|'[^']*')\s+("[^"]*"|'[^']*')|("[^"]*"|'[^']*'))` +
+	`(\s+NDATA\s+[^\s>]+)?\s*>`)
+
+// WithEntities sets the replacement text of entities that are not declared in
+// the observed documents, for example xml.HTMLEntity for documents that use
+// HTML entities like &nbsp;. entities maps entity names, without the leading
+// & and trailing ;, to their replacement text.
+func WithEntities(entities map[string]string) GeneratorOption {
+	return func(g *Generator) {
+		g.entities = entities
+	}
+}
+
+// WithEntityResolver sets the function used to resolve the external general
+// entities declared in the DOCTYPE of observed documents. Internal general
+// entities declared in the DOCTYPE are also defined, and take precedence over
+// those set with WithEntities. Unparsed entities and parameter entities are
+// ignored. By default, declared entities are not defined, and references to
+// them are errors.
+func WithEntityResolver(entityResolver EntityResolver) GeneratorOption {
+	return func(g *Generator) {
+		g.entityResolver = entityResolver
+	}
+}
+
+// decoderEntities returns the entities of a new decoder.
+func (g *Generator) decoderEntities() map[string]string {
+	if g.entityResolver == nil {
+		return g.entities
+	}
+	entities := maps.Clone(g.entities)
+	if entities == nil {
+		entities = make(map[string]string)
+	}
+	return entities
+}
+
+// observeDirective defines the entities declared in directive in entities, if
+// g has an entity resolver.
+func (g *Generator) observeDirective(directive xml.Directive, entities map[string]string) error {
+	if g.entityResolver == nil || !strings.HasPrefix(string(directive), "DOCTYPE") {
+		return nil
+	}
+	for _, m := range entityDeclRx.FindAllStringSubmatch(string(directive), -1) {
+		name, systemID, publicIDInPublic, systemIDInPublic, value, nData := m[1], m[2], m[3], m[4], m[5], m[6]
+		switch {
+		case nData != "":
+			continue
+		case value != "":
+			entities[name] = unquoteLiteral(value)
+		default:
+			var publicID string
+			if systemID == "" {
+				publicID, systemID = publicIDInPublic, systemIDInPublic
+			}
+			replacementText, err := g.entityResolver(name, unquoteLiteral(publicID), unquoteLiteral(systemID))
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			entities[name] = replacementText
+		}
+	}
+	return nil
+}
+
+// unquoteLiteral returns the literal s without its quotes.
+func unquoteLiteral(s string) string {
+	if len(s) < 2 {
+		return s
+	}
+	return s[1 : len(s)-1]
+}
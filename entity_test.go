@@ -0,0 +1,89 @@
+package xmlstruct_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestEntities(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithEntities(xml.HTMLEntity),
+		xmlstruct.WithValueExamples(1),
+	)
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(`<a><b>caf&eacute;&nbsp;1</b></a>`)))
+
+	actual, err := generator.Generate()
+	assert.NoError(t, err)
+	assert.Equal(t, joinLines(
+		xmlstruct.DefaultHeader,
+		"",
+		"package main",
+		"",
+		"type A struct {",
+		"\tB string `xml:\"b\"` // Examples: \"café\\u00a01\"",
+		"}",
+	), string(actual))
+}
+
+func TestEntitiesUndefined(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator()
+	assert.Error(t, generator.ObserveReader(strings.NewReader(`<a>&nbsp;</a>`)))
+}
+
+func TestEntityResolver(t *testing.T) {
+	t.Parallel()
+
+	var systemIDs []string
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithEntityResolver(func(name, publicID, systemID string) (string, error) {
+			systemIDs = append(systemIDs, publicID+" "+systemID)
+			switch name {
+			case "chapter":
+				return "42", nil
+			default:
+				return "", errors.New("not found")
+			}
+		}),
+	)
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(joinLines(
+		`<!DOCTYPE book [`,
+		`  <!ENTITY title "Go">`,
+		`  <!ENTITY chapter SYSTEM "chapter.xml">`,
+		`  <!ENTITY logo SYSTEM "logo.gif" NDATA gif>`,
+		`  <!ENTITY % param "ignored">`,
+		`]>`,
+		`<book><title>&title;</title><chapter>&chapter;</chapter></book>`,
+	))))
+	assert.Equal(t, []string{" chapter.xml"}, systemIDs)
+
+	actual, err := generator.Generate()
+	assert.NoError(t, err)
+	assert.Equal(t, joinLines(
+		xmlstruct.DefaultHeader,
+		"",
+		"package main",
+		"",
+		"type Book struct {",
+		"\tChapter int    `xml:\"chapter\"`",
+		"\tTitle   string `xml:\"title\"`",
+		"}",
+	), string(actual))
+
+	err = generator.ObserveReader(strings.NewReader(joinLines(
+		`<!DOCTYPE book PUBLIC "-//Example//Book" "book.dtd" [`,
+		`  <!ENTITY appendix PUBLIC "-//Example//Appendix" "appendix.xml">`,
+		`]>`,
+		`<book/>`,
+	)))
+	assert.EqualError(t, err, "offset 122: appendix: not found")
+}
@@ -0,0 +1,156 @@
+package xmlstruct
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A ValueCollation controls how example values are compared when they are
+// deduplicated and sorted. Collation does not depend on the locale, so
+// generated source is the same everywhere.
+type ValueCollation int
+
+const (
+	// ValueCollationCaseInsensitive compares values ignoring case, so values
+	// that differ only in case are considered duplicates.
+	ValueCollationCaseInsensitive ValueCollation = 1 << iota
+	// ValueCollationNumeric compares runs of digits by their numeric value,
+	// so item2 sorts before item10.
+	ValueCollationNumeric
+)
+
+// key returns the key of s under c. Values with equal keys are duplicates.
+func (c ValueCollation) key(s string) string {
+	if c&ValueCollationCaseInsensitive != 0 {
+		return strings.ToLower(s)
+	}
+	return s
+}
+
+// compare compares the keys a and b under c.
+func (c ValueCollation) compare(a, b string) int {
+	if c&ValueCollationNumeric == 0 {
+		return strings.Compare(a, b)
+	}
+	for a != "" && b != "" {
+		aRune, _ := utf8.DecodeRuneInString(a)
+		bRune, _ := utf8.DecodeRuneInString(b)
+		if unicode.IsDigit(aRune) && unicode.IsDigit(bRune) {
+			aDigits, aRest := cutDigits(a)
+			bDigits, bRest := cutDigits(b)
+			aNumber := strings.TrimLeft(aDigits, "0")
+			bNumber := strings.TrimLeft(bDigits, "0")
+			if result := cmp.Or(
+				cmp.Compare(len(aNumber), len(bNumber)),
+				strings.Compare(aNumber, bNumber),
+				cmp.Compare(len(aDigits), len(bDigits)),
+			); result != 0 {
+				return result
+			}
+			a, b = aRest, bRest
+			continue
+		}
+		if result := cmp.Compare(aRune, bRune); result != 0 {
+			return result
+		}
+		a, b = a[utf8.RuneLen(aRune):], b[utf8.RuneLen(bRune):]
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// cutDigits returns the leading digits of s and the rest of s.
+func cutDigits(s string) (string, string) {
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return !unicode.IsDigit(r)
+	})
+	if i == -1 {
+		return s, ""
+	}
+	return s[:i], s[i:]
+}
+
+// observeExample records s as an example value of v. v keeps the
+// options.valueExamples smallest distinct values under options.valueCollation,
+// so the examples do not depend on the order in which values are observed. If
+// the examples are not exactly the set of observed values then
+// v.examplesIncomplete is set. If options.exampleTags is set then v also keeps
+// the smallest value for its example tag.
+func (v *value) observeExample(s string, options *observeOptions) {
+	if options.valueExamples <= 0 && !options.exampleTags {
+		return
+	}
+	s = strings.TrimSpace(s)
+	if options.exampleTags && s != "" {
+		if v.example == "" || cmp.Or(
+			options.valueCollation.compare(options.valueCollation.key(s), options.valueCollation.key(v.example)),
+			strings.Compare(s, v.example),
+		) < 0 {
+			v.example = s
+		}
+	}
+	if options.valueExamples <= 0 {
+		return
+	}
+	if s == "" {
+		v.examplesIncomplete = true
+		return
+	}
+	if v.examples == nil {
+		v.examples = make(map[string]string)
+	}
+	key := options.valueCollation.key(s)
+	if example, ok := v.examples[key]; ok {
+		if example != s {
+			v.examplesIncomplete = true
+		}
+		v.examples[key] = min(example, s)
+		return
+	}
+	if len(v.examples) >= options.valueExamples {
+		v.examplesIncomplete = true
+		maxKey := slices.MaxFunc(mapKeys(v.examples), options.valueCollation.compare)
+		if options.valueCollation.compare(key, maxKey) >= 0 {
+			return
+		}
+		delete(v.examples, maxKey)
+	}
+	v.examples[key] = s
+}
+
+// examplesComment returns a trailing comment listing v's example values, or
+// an empty string if there are none.
+func (v *value) examplesComment(options *generateOptions) string {
+	if v == nil || len(v.examples) == 0 {
+		return ""
+	}
+	keys := mapKeys(v.examples)
+	slices.SortFunc(keys, func(a, b string) int {
+		return cmp.Or(options.valueCollation.compare(a, b), strings.Compare(a, b))
+	})
+	examples := make([]string, 0, len(keys))
+	for _, key := range keys {
+		examples = append(examples, strconv.Quote(v.examples[key]))
+	}
+	return " // Examples: " + strings.Join(examples, ", ")
+}
+
+// exampleTag returns an example struct tag, including a leading space, with
+// v's example value, or an empty string if there is none. path is the path of
+// v.
+func (v *value) exampleTag(path string, options *generateOptions) string {
+	if !options.exampleTags || v == nil || v.example == "" {
+		return ""
+	}
+	example := v.example
+	if options.exampleRedactFunc != nil {
+		example = options.exampleRedactFunc(path, example)
+	}
+	if example == "" || strings.ContainsRune(example, '`') {
+		return ""
+	}
+	return " example:" + strconv.Quote(example)
+}
@@ -0,0 +1,89 @@
+package xmlstruct
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A FieldMatcher returns whether the field with the given path should be
+// matched.
+//
+// Paths are the local names of the elements from the top level element to the
+// field, separated by slashes. Attributes are prefixed with @. For example, the
+// path of the id attribute of the item element in the document
+// <order><item id="1"/></order> is order/item/@id. The path of a chardata field
+// is the path of its element. When generating named types, paths start at the
+// element of the named type.
+type FieldMatcher func(path string) bool
+
+// MatchPath returns a FieldMatcher that matches paths that end with pattern.
+// Each slash-separated component of pattern is matched against the
+// corresponding component of the path with path.Match, so * matches any single
+// element or attribute. If pattern starts with a slash then it must match the
+// whole path.
+func MatchPath(pattern string) FieldMatcher {
+	anchored := strings.HasPrefix(pattern, "/")
+	patternComponents := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+	return func(fieldPath string) bool {
+		pathComponents := strings.Split(fieldPath, "/")
+		if len(pathComponents) < len(patternComponents) || anchored && len(pathComponents) != len(patternComponents) {
+			return false
+		}
+		pathComponents = pathComponents[len(pathComponents)-len(patternComponents):]
+		for i, patternComponent := range patternComponents {
+			if matched, err := path.Match(patternComponent, pathComponents[i]); err != nil || !matched {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// MatchName returns a FieldMatcher that matches paths whose final component,
+// the local name of the element or attribute, matches rx. Attribute names are
+// matched without their @ prefix.
+func MatchName(rx *regexp.Regexp) FieldMatcher {
+	return func(fieldPath string) bool {
+		name := fieldPath[strings.LastIndexByte(fieldPath, '/')+1:]
+		return rx.MatchString(strings.TrimPrefix(name, "@"))
+	}
+}
+
+// An importMapping maps fields to a Go type imported from another package.
+type importMapping struct {
+	importPath string
+	goType     string
+	matcher    FieldMatcher
+}
+
+// mappedGoFieldType returns the Go type of the field with path fieldPath from
+// the first matching import mapping, and whether there was a match. It adds
+// the import to options.
+func (options *generateOptions) mappedGoFieldType(fieldPath string) (goFieldType, bool) {
+	for _, importMapping := range options.importMappings {
+		if !importMapping.matcher(fieldPath) {
+			continue
+		}
+		if importMapping.importPath != "" {
+			importAlias := ""
+			if qualifier, _, ok := strings.Cut(importMapping.goType, "."); ok && qualifier != path.Base(importMapping.importPath) {
+				importAlias = qualifier
+			}
+			options.importPackageNames[importMapping.importPath] = importAlias
+		}
+		return goFieldType{
+			name: importMapping.goType,
+		}, true
+	}
+	return goFieldType{}, false
+}
+
+// importSpec returns the Go import spec for importPath with importAlias.
+func importSpec(importPath, importAlias string) string {
+	if importAlias == "" {
+		return strconv.Quote(importPath)
+	}
+	return importAlias + " " + strconv.Quote(importPath)
+}
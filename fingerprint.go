@@ -0,0 +1,84 @@
+package xmlstruct
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"hash"
+	"slices"
+)
+
+// Fingerprint returns a stable hash of the model inferred from all the XML
+// documents observed so far. Two generators that have inferred the same model
+// have the same fingerprint, independent of the order in which documents and
+// values were observed and of how the generated source would be formatted.
+// Observation order is only included if g preserves order.
+//
+// The fingerprint does not include g's generation options, so callers that
+// cache generated source should include the options in their cache key.
+func (g *Generator) Fingerprint() string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.fingerprint()
+}
+
+// fingerprint returns the fingerprint of g's model.
+func (g *Generator) fingerprint() string {
+	h := sha256.New()
+	visited := make(map[*element]bool)
+	typeElements := mapValues(g.typeElements)
+	slices.SortFunc(typeElements, func(a, b *element) int {
+		return compareNames(a.name, b.name)
+	})
+	for _, typeElement := range typeElements {
+		if g.preserveOrder {
+			fmt.Fprintf(h, "order %d\n", g.typeOrder[typeElement.name])
+		}
+		g.writeElementFingerprint(h, typeElement, visited)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeElementFingerprint writes a canonical description of e to h. Elements
+// that have already been visited are only written by name.
+func (g *Generator) writeElementFingerprint(h hash.Hash, e *element, visited map[*element]bool) {
+	if visited[e] {
+		fmt.Fprintf(h, "ref %q %q\n", e.name.Space, e.name.Local)
+		return
+	}
+	visited[e] = true
+
+	fmt.Fprintf(h, "element %q %q root=%t nested=%t\n", e.name.Space, e.name.Local, e.root, e.nestedCount > 0)
+
+	attrNames := mapKeys(e.attrValues)
+	slices.SortFunc(attrNames, compareNames)
+	for _, attrName := range attrNames {
+		attrValue := e.attrValues[attrName]
+		fmt.Fprintf(h, "attr %q %q kind=%d optional=%t repeated=%t\n", attrName.Space, attrName.Local, attrValue.kind(), attrValue.optional, attrValue.repeated)
+	}
+
+	if e.charDataValue.observations > 0 {
+		fmt.Fprintf(h, "chardata kind=%d\n", e.charDataValue.kind())
+	}
+
+	childNames := mapKeys(e.childElements)
+	slices.SortFunc(childNames, compareNames)
+	for _, childName := range childNames {
+		_, optional := e.optionalChildren[childName]
+		_, repeated := e.repeatedChildren[childName]
+		fmt.Fprintf(h, "child optional=%t repeated=%t", optional, repeated)
+		if g.preserveOrder {
+			fmt.Fprintf(h, " order=%d", e.childOrder[childName])
+		}
+		fmt.Fprintf(h, "\n")
+		g.writeElementFingerprint(h, e.childElements[childName], visited)
+	}
+	fmt.Fprintf(h, "end\n")
+}
+
+// compareNames compares a and b by namespace and then by local name.
+func compareNames(a, b xml.Name) int {
+	return cmp.Or(cmp.Compare(a.Space, b.Space), cmp.Compare(a.Local, b.Local))
+}
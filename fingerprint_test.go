@@ -0,0 +1,34 @@
+package xmlstruct_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestFingerprint(t *testing.T) {
+	t.Parallel()
+
+	fingerprint := func(xmlStrs ...string) string {
+		t.Helper()
+		generator := xmlstruct.NewGenerator(
+			xmlstruct.WithTopLevelAttributes(true),
+		)
+		for _, xmlStr := range xmlStrs {
+			assert.NoError(t, generator.ObserveReader(strings.NewReader(xmlStr)))
+		}
+		return generator.Fingerprint()
+	}
+
+	expected := fingerprint(`<a b="1"><c>x</c><d/></a>`)
+	assert.Equal(t, 64, len(expected))
+	assert.Equal(t, expected, fingerprint(`<a b="1"><c>x</c><d/></a>`))
+	assert.Equal(t, expected, fingerprint("<a b='2'>\n  <d></d>\n  <c>y</c>\n</a>"))
+	assert.NotEqual(t, expected, fingerprint(`<a b="x"><c>x</c><d/></a>`))
+	assert.NotEqual(t, expected, fingerprint(`<a b="1"><c>x</c><c>y</c><d/></a>`))
+	assert.NotEqual(t, expected, fingerprint(`<a b="1"><c>x</c><d/></a>`, `<a b="1"><c>x</c></a>`))
+	assert.NotEqual(t, fingerprint(`<a/>`), fingerprint(`<b/>`))
+}
@@ -1,16 +1,26 @@
 package xmlstruct
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"go/ast"
+	"go/build/constraint"
 	"go/format"
+	"go/parser"
+	"go/token"
 	"io"
 	"io/fs"
+	"log/slog"
 	"os"
 	"slices"
-	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/net/html/charset"
 )
@@ -21,43 +31,182 @@ var (
 	SkipFile = errors.New("skip file") //nolint:errname,revive
 )
 
+// An ASTHookFunc makes arbitrary changes to the generated Go source, parsed
+// into an *ast.File, before it is formatted.
+type ASTHookFunc func(*ast.File) error
+
 // A ModifyDecoderFunc makes arbitrary changes to an encoding/xml.Decoder before
 // it is used.
 type ModifyDecoderFunc func(*xml.Decoder)
 
 // A Generator observes XML documents and generates Go structs into which the
 // XML documents can be unmarshalled.
+//
+// A Generator is safe for concurrent use by multiple goroutines. Observations
+// are serialized and block generation until they are complete. To generate
+// from a consistent model while observation continues in other goroutines, use
+// Snapshot.
 type Generator struct {
+	mu sync.RWMutex
+
+	anonymizationKey             []byte
+	anonymizeEnumThreshold       int
+	anyAttrs                     []FieldMatcher
+	anyElements                  []FieldMatcher
+	astHooks                     []ASTHookFunc
+	attrDefaults                 map[string]string
+	attrMapThreshold             int
 	attrNameSuffix               string
+	benchmarks                   bool
+	buildTags                    []string
+	charsetPrecedence            CharsetPrecedence
+	checkpointInterval           int
+	checkpointStore              CheckpointStore
+	cardinalities                []*cardinalityDeclaration
+	cardinalityPrecedence        CardinalityPrecedence
+	charDataElementName          bool
 	charDataFieldName            string
+	commonFieldExtraction        int
+	deepCopyGenMarkers           bool
+	deepCopyMethods              bool
+	deprecatedBuildTag           string
+	deprecations                 []*deprecation
+	documents                    int
 	elemNameSuffix               string
+	elementFilters               []ElementFilter
+	entities                     map[string]string
+	entityResolver               EntityResolver
+	exampleRedactFunc            ExampleRedactFunc
+	exampleTags                  bool
 	exportNameFunc               ExportNameFunc
 	exportTypeNameFunc           ExportNameFunc
 	exportRenames                map[string]string
+	exportTypeRenames            map[string]string
+	fallbackCharsets             []string
 	formatSource                 bool
+	generatedFileSuffix          string
 	header                       string
+	identityConstraints          bool
+	importMappings               []*importMapping
 	imports                      bool
+	indexFuncs                   bool
+	inputs                       []MetadataInput
 	intType                      string
+	keyUniqueness                float64
+	logger                       *slog.Logger
+	mapElements                  map[string]string
+	minOccurrenceRate            float64
 	modifyDecoderFunc            ModifyDecoderFunc
 	nameFunc                     NameFunc
+	pathNameFunc                 PathNameFunc
 	namedRoot                    bool
 	namedTypes                   bool
+	numberFormat                 NumberFormat
 	compactTypes                 bool
 	order                        int
 	packageName                  string
+	preserveLeadingZeros         bool
 	preserveOrder                bool
+	preserveWhitespace           bool
+	rootRegistry                 bool
+	sanitizeIdentifierFunc       SanitizeIdentifierFunc
+	stripBOM                     bool
 	timeLayout                   string
+	timeZonePolicy               TimeZonePolicy
+	tokenFilters                 []TokenFilter
 	topLevelAttributes           bool
+	typeConfidence               float64
+	typeConflictPolicy           TypeConflictPolicy
 	typeOrder                    map[xml.Name]int
+	pointerPolicy                *PointerPolicy
 	usePointersForOptionalFields bool
 	useRawToken                  bool
+	valueCollation               ValueCollation
+	valueExamples                int
+	valueSanitizer               ValueSanitizerFunc
 	typeElements                 map[xml.Name]*element
 	emptyElements                bool
+	unsavedObservations          int
 }
 
 // A GeneratorOption sets an option on a Generator.
 type GeneratorOption func(*Generator)
 
+// WithAnyAttrs adds a catch-all AnyAttrs []xml.Attr field to the structs
+// generated for elements matched by matcher, for example elements whose schema
+// has xs:anyAttribute. Attributes that were not observed are decoded into it.
+// Elements are matched by their path, as for chardata fields. If the struct
+// already has an AnyAttrs field then underscores are appended to the name of
+// the catch-all field until it is unique.
+func WithAnyAttrs(matcher FieldMatcher) GeneratorOption {
+	return func(g *Generator) {
+		g.anyAttrs = append(g.anyAttrs, matcher)
+	}
+}
+
+// WithAnyElements adds a catch-all Any []AnyElement field to the structs
+// generated for elements matched by matcher, for example elements whose schema
+// has xs:any. Child elements that were not observed are decoded into it, with
+// their attributes and undecoded contents. Elements are matched by their path,
+// as for chardata fields. If the struct already has an Any field, for example
+// for an any child element in an XML schema, then underscores are appended to
+// the name of the catch-all field until it is unique.
+func WithAnyElements(matcher FieldMatcher) GeneratorOption {
+	return func(g *Generator) {
+		g.anyElements = append(g.anyElements, matcher)
+	}
+}
+
+// WithASTHook adds astHook to the hooks called with the generated Go source,
+// parsed into an *ast.File with comments, before it is formatted. Hooks can
+// add, remove, or modify declarations and comments, for example to add methods
+// or rename types. Hooks are called in the order in which they are added, and
+// the first error returned by a hook is returned by Generate.
+//
+// If any hooks are added then the generated Go source is always formatted, as
+// it is printed from the modified *ast.File.
+func WithASTHook(astHook ASTHookFunc) GeneratorOption {
+	return func(g *Generator) {
+		g.astHooks = append(g.astHooks, astHook)
+	}
+}
+
+// WithAttrDefaults sets the default values of attributes. attrDefaults maps
+// attribute path patterns, matched as with MatchPath, to default values, for
+// example b/@c or a/b/@c. The element of the attribute may be qualified with
+// its namespace in the form {namespace}b/@c, which only matches if the name
+// func keeps namespaces. If several patterns match an attribute then the one
+// with the most components is used, preferring qualified elements.
+//
+// Fields for attributes with default values are optional, even if the
+// attributes are always present, so that unset attributes can be distinguished
+// from attributes that are explicitly set to zero values. For every generated
+// type with such a field that is a pointer or optional wrapper, or with a
+// field whose type has such a field, an ApplyDefaults method is generated which
+// sets all unset attributes to their default values. Call ApplyDefaults after
+// unmarshalling to apply the defaults. If the pointer policy makes such a field
+// neither a pointer nor an optional wrapper then the type has an UnmarshalXML
+// method that sets the field to its default value before decoding. It is an
+// error if such a field is in a repeated or optional anonymous struct.
+func WithAttrDefaults(attrDefaults map[string]string) GeneratorOption {
+	return func(g *Generator) {
+		g.attrDefaults = attrDefaults
+	}
+}
+
+// WithAttrMapThreshold sets the maximum number of distinct attributes of an
+// element that are represented by a field for each attribute. The attributes
+// of elements with more distinct attributes are represented by a single Attrs
+// field of type AttrMap, a map[string]string keyed by local name. Named types
+// with an Attrs field have a MarshalXML method that marshals its attributes,
+// but the attributes of anonymous structs are only unmarshaled. Zero, the
+// default, means no maximum.
+func WithAttrMapThreshold(attrMapThreshold int) GeneratorOption {
+	return func(g *Generator) {
+		g.attrMapThreshold = attrMapThreshold
+	}
+}
+
 // WithAttrNameSuffix sets the attribute suffix.
 func WithAttrNameSuffix(attrSuffix string) GeneratorOption {
 	return func(g *Generator) {
@@ -65,6 +214,50 @@ func WithAttrNameSuffix(attrSuffix string) GeneratorOption {
 	}
 }
 
+// WithBuildTags sets the build constraints of the generated Go source. Each
+// build tag is a build constraint expression, for example linux or
+// (linux || darwin) && !purego, and the generated Go source is only built if
+// all of them are satisfied.
+func WithBuildTags(buildTags ...string) GeneratorOption {
+	return func(g *Generator) {
+		g.buildTags = buildTags
+	}
+}
+
+// WithCardinality declares the cardinality of all fields matched by matcher,
+// for example from an XML Schema or DTD, as their minimum and maximum number of
+// occurrences. maxOccurs can be Unbounded. Fields with a minOccurs of zero are
+// optional and fields with a maxOccurs greater than one are repeated. Only
+// minOccurs is used for attributes. If the declared cardinality disagrees with
+// the observed cardinality then the cardinality is determined by the
+// precedence set with WithCardinalityPrecedence. WithCardinality can be used
+// multiple times, in which case the first matching declaration is used.
+func WithCardinality(minOccurs, maxOccurs int, matcher FieldMatcher) GeneratorOption {
+	return func(g *Generator) {
+		g.cardinalities = append(g.cardinalities, &cardinalityDeclaration{
+			minOccurs: minOccurs,
+			maxOccurs: maxOccurs,
+			matcher:   matcher,
+		})
+	}
+}
+
+// WithCardinalityPrecedence sets the precedence of declared and observed
+// cardinalities.
+func WithCardinalityPrecedence(cardinalityPrecedence CardinalityPrecedence) GeneratorOption {
+	return func(g *Generator) {
+		g.cardinalityPrecedence = cardinalityPrecedence
+	}
+}
+
+// WithCharsetPrecedence sets which of a byte order mark and an XML declaration
+// determines the charset of an observed document that has both.
+func WithCharsetPrecedence(charsetPrecedence CharsetPrecedence) GeneratorOption {
+	return func(g *Generator) {
+		g.charsetPrecedence = charsetPrecedence
+	}
+}
+
 // WithCharDataFieldName sets the char data field name.
 func WithCharDataFieldName(charDataFieldName string) GeneratorOption {
 	return func(g *Generator) {
@@ -72,6 +265,33 @@ func WithCharDataFieldName(charDataFieldName string) GeneratorOption {
 	}
 }
 
+// WithCharDataFieldNameFromElement sets whether char data fields are named
+// after their element, like Price in a Price struct with a Currency attribute,
+// instead of with the char data field name.
+func WithCharDataFieldNameFromElement(charDataFieldNameFromElement bool) GeneratorOption {
+	return func(g *Generator) {
+		g.charDataElementName = charDataFieldNameFromElement
+	}
+}
+
+// WithDeepCopyGenMarkers sets whether to precede each generated type with a
+// +k8s:deepcopy-gen=true marker comment, so that Kubernetes' deepcopy-gen will
+// generate deep copy methods for it.
+func WithDeepCopyGenMarkers(deepCopyGenMarkers bool) GeneratorOption {
+	return func(g *Generator) {
+		g.deepCopyGenMarkers = deepCopyGenMarkers
+	}
+}
+
+// WithDeepCopyMethods sets whether to generate DeepCopyInto and DeepCopy
+// methods, compatible with those generated by Kubernetes' deepcopy-gen, for
+// each generated type.
+func WithDeepCopyMethods(deepCopyMethods bool) GeneratorOption {
+	return func(g *Generator) {
+		g.deepCopyMethods = deepCopyMethods
+	}
+}
+
 // WithElemNameSuffix sets the attribute suffix.
 func WithElemNameSuffix(elemSuffix string) GeneratorOption {
 	return func(g *Generator) {
@@ -87,6 +307,33 @@ func WithEmptyElements(emptyElements bool) GeneratorOption {
 	}
 }
 
+// WithElementFilter adds an element filter. An element is observed only if all
+// element filters return true.
+func WithElementFilter(elementFilter ElementFilter) GeneratorOption {
+	return func(g *Generator) {
+		g.elementFilters = append(g.elementFilters, elementFilter)
+	}
+}
+
+// WithExampleRedactFunc sets a function that redacts the values of example
+// tags, for example to hide personal data observed in the XML documents.
+func WithExampleRedactFunc(exampleRedactFunc ExampleRedactFunc) GeneratorOption {
+	return func(g *Generator) {
+		g.exampleRedactFunc = exampleRedactFunc
+	}
+}
+
+// WithExampleTags sets whether to add example tags with an observed value to
+// attribute and chardata fields, for documentation generators that read them.
+// The example is the smallest observed value according to the value
+// collation, so that the generated source is stable. It must be set before any
+// XML documents are observed.
+func WithExampleTags(exampleTags bool) GeneratorOption {
+	return func(g *Generator) {
+		g.exampleTags = exampleTags
+	}
+}
+
 // WithExportNameFunc sets the export name function for the generated Go source.
 // It overrides WithExportRenames.
 func WithExportNameFunc(exportNameFunc ExportNameFunc) GeneratorOption {
@@ -111,6 +358,17 @@ func WithExportRenames(exportRenames map[string]string) GeneratorOption {
 	}
 }
 
+// WithFallbackCharsets sets the charsets, in order, with which bytes that are
+// not valid UTF-8 in observed UTF-8 documents are decoded, instead of failing,
+// for example windows-1252 for legacy documents that mix encodings. Each such
+// byte is decoded individually, so fallback charsets should be single-byte
+// charsets.
+func WithFallbackCharsets(fallbackCharsets ...string) GeneratorOption {
+	return func(g *Generator) {
+		g.fallbackCharsets = fallbackCharsets
+	}
+}
+
 // WithFormatSource sets whether to format the generated Go source.
 func WithFormatSource(formatSource bool) GeneratorOption {
 	return func(g *Generator) {
@@ -118,13 +376,56 @@ func WithFormatSource(formatSource bool) GeneratorOption {
 	}
 }
 
-// WithHeader sets the header of the generated Go source.
+// WithGeneratedFileSuffix sets the suffix of the names of generated Go source
+// files written by GenerateModule, for example _gen.go. It must end in .go.
+func WithGeneratedFileSuffix(generatedFileSuffix string) GeneratorOption {
+	return func(g *Generator) {
+		g.generatedFileSuffix = generatedFileSuffix
+	}
+}
+
+// WithHeader sets the header of the generated Go source. To be recognized as
+// generated by Go tools, the header should include a line matching
+//
+//	^// Code generated .* DO NOT EDIT\.$
+//
+// like DefaultHeader.
 func WithHeader(header string) GeneratorOption {
 	return func(g *Generator) {
 		g.header = header
 	}
 }
 
+// WithIdentityConstraints sets whether to record the distinct values of every
+// attribute, so that candidate keys and references can be reported with
+// IdentityConstraints and indexed with WithIndexFuncs. Recording uses memory
+// proportional to the number of distinct attribute values. It must be set
+// before any XML documents are observed.
+func WithIdentityConstraints(identityConstraints bool) GeneratorOption {
+	return func(g *Generator) {
+		g.identityConstraints = identityConstraints
+	}
+}
+
+// WithImportMapping sets the type of all fields matched by matcher that would
+// otherwise have a simple type like string or int to goType, which is imported
+// from importPath. goType should be qualified by the package name, for example
+// decimal.Decimal. If importPath is empty then no import is added, which is
+// useful for types declared in the same package. WithImportMapping can be
+// used multiple times, in which case the first matching mapping is used.
+//
+// Only fields with simple types can be mapped: attributes, chardata, and child
+// elements with only chardata.
+func WithImportMapping(importPath, goType string, matcher FieldMatcher) GeneratorOption {
+	return func(g *Generator) {
+		g.importMappings = append(g.importMappings, &importMapping{
+			importPath: importPath,
+			goType:     goType,
+			matcher:    matcher,
+		})
+	}
+}
+
 // WithImports sets whether to include an import statement in the generated code.
 func WithImports(withImports bool) GeneratorOption {
 	return func(g *Generator) {
@@ -132,6 +433,17 @@ func WithImports(withImports bool) GeneratorOption {
 	}
 }
 
+// WithIndexFuncs sets whether to generate, for each candidate key attribute of
+// a named type, a function that returns a map of pointers to the elements of a
+// slice of that type by the attribute's value, for example IndexItemByID for
+// an Item type with a unique id attribute. Candidate keys are as reported by
+// IdentityConstraints, so WithIdentityConstraints must also be set.
+func WithIndexFuncs(indexFuncs bool) GeneratorOption {
+	return func(g *Generator) {
+		g.indexFuncs = indexFuncs
+	}
+}
+
 // WithIntType sets the int type in the generated Go source.
 func WithIntType(intType string) GeneratorOption {
 	return func(g *Generator) {
@@ -139,6 +451,43 @@ func WithIntType(intType string) GeneratorOption {
 	}
 }
 
+// WithKeyUniquenessThreshold sets the minimum fraction of distinct values of
+// an attribute for it to be a candidate key. The default of 1 requires all
+// values to be distinct. Lower values also find keys that have some
+// duplicates, for example because of errors in the documents.
+func WithKeyUniquenessThreshold(keyUniquenessThreshold float64) GeneratorOption {
+	return func(g *Generator) {
+		g.keyUniqueness = keyUniquenessThreshold
+	}
+}
+
+// WithMapElements sets elements that are generated as maps from the value of
+// a key attribute to the element's chardata, instead of as slices of structs.
+// mapElements maps element local names to the local name of their key
+// attribute. For example, map[string]string{"entry": "key"} generates a
+// map[string]string for <entry key="foo">bar</entry> elements. The generated
+// map types have UnmarshalXML and MarshalXML methods. It is an error if a map
+// element has child elements, other attributes, or an optional key attribute.
+func WithMapElements(mapElements map[string]string) GeneratorOption {
+	return func(g *Generator) {
+		g.mapElements = mapElements
+	}
+}
+
+// WithMinOccurrenceRate sets the minimum fraction of observed documents in
+// which an element must occur for it to be generated as a field of its parent,
+// keeping the generated types focused on the stable core of a noisy feed. Rarer
+// elements are excluded and their parents get a catch-all Any field, as with
+// WithAnyElements, into which they are decoded. Every root element, or every
+// fragment observed with ObserveFragments, is a document. Elements that were
+// not observed in documents, for example those observed in Go source, are
+// always generated. The default of 0 generates all elements.
+func WithMinOccurrenceRate(minOccurrenceRate float64) GeneratorOption {
+	return func(g *Generator) {
+		g.minOccurrenceRate = minOccurrenceRate
+	}
+}
+
 // WithModifyDecoderFunc sets the function that will modify the
 // encoding/xml.Decoder used.
 func WithModifyDecoderFunc(modifyDecoderFunc ModifyDecoderFunc) GeneratorOption {
@@ -154,6 +503,16 @@ func WithNameFunc(nameFunc NameFunc) GeneratorOption {
 	}
 }
 
+// WithPathNameFunc sets the name function that is given the names of the
+// ancestors of each element and attribute, for naming decisions that depend on
+// context. If it is set then it is used instead of the name function set with
+// WithNameFunc.
+func WithPathNameFunc(pathNameFunc PathNameFunc) GeneratorOption {
+	return func(g *Generator) {
+		g.pathNameFunc = pathNameFunc
+	}
+}
+
 // WithNamedRoot sets whether to generate an XMLName field for the root element.
 func WithNamedRoot(namedRoot bool) GeneratorOption {
 	return func(o *Generator) {
@@ -168,6 +527,17 @@ func WithNamedTypes(namedTypes bool) GeneratorOption {
 	}
 }
 
+// WithCommonFieldExtraction sets the minimum number of struct types that must
+// share a group of attributes for the group to be extracted into a CommonAttrs
+// struct type that is embedded in each of them, instead of being repeated in
+// each of them. Attributes are shared if they have the same name and Go type.
+// Values less than two disable extraction.
+func WithCommonFieldExtraction(commonFieldExtraction int) GeneratorOption {
+	return func(g *Generator) {
+		g.commonFieldExtraction = commonFieldExtraction
+	}
+}
+
 // WithCompactTypes sets whether to generate compact types.
 func WithCompactTypes(compactTypes bool) GeneratorOption {
 	return func(o *Generator) {
@@ -175,6 +545,18 @@ func WithCompactTypes(compactTypes bool) GeneratorOption {
 	}
 }
 
+// WithNumberFormat sets the format of numbers in the observed XML documents.
+// If the format cannot be parsed by Go's strconv package, for example because
+// it uses a comma as the decimal separator, then numbers are generated as
+// LocalizedInt and LocalizedFloat64 types that implement
+// encoding.TextUnmarshaler and encoding.TextMarshaler. It must be set before
+// any XML documents are observed.
+func WithNumberFormat(numberFormat NumberFormat) GeneratorOption {
+	return func(g *Generator) {
+		g.numberFormat = numberFormat
+	}
+}
+
 // WithPackageName sets the package name of the generated Go source.
 func WithPackageName(packageName string) GeneratorOption {
 	return func(g *Generator) {
@@ -182,6 +564,26 @@ func WithPackageName(packageName string) GeneratorOption {
 	}
 }
 
+// WithPointerPolicy sets which fields use pointers in the generated Go source.
+// It takes precedence over WithUsePointersForOptionalFields. With a pointer
+// policy, repeated attributes use slices of pointers only if RepeatedElements
+// is set, like repeated elements.
+func WithPointerPolicy(pointerPolicy PointerPolicy) GeneratorOption {
+	return func(g *Generator) {
+		g.pointerPolicy = &pointerPolicy
+	}
+}
+
+// WithPreserveLeadingZeros sets whether numbers padded with leading zeros,
+// like postal codes or account numbers such as 007, are treated as strings so
+// that the padding is not lost. It must be set before any XML documents are
+// observed.
+func WithPreserveLeadingZeros(preserveLeadingZeros bool) GeneratorOption {
+	return func(g *Generator) {
+		g.preserveLeadingZeros = preserveLeadingZeros
+	}
+}
+
 // WithPreserveOrder sets whether to preserve the order of types and fields.
 func WithPreserveOrder(preserveOrder bool) GeneratorOption {
 	return func(g *Generator) {
@@ -189,6 +591,38 @@ func WithPreserveOrder(preserveOrder bool) GeneratorOption {
 	}
 }
 
+// WithPreserveWhitespaceCharData sets whether chardata that is only whitespace
+// is observed in elements without child elements, for example the separator in
+// <sep> </sep>. Whitespace between child elements, such as the indentation of
+// pretty-printed documents, is never observed, so it does not cause CharData
+// fields on container elements. By default, chardata that is only whitespace is
+// treated as no chardata at all.
+func WithPreserveWhitespaceCharData(preserveWhitespaceCharData bool) GeneratorOption {
+	return func(g *Generator) {
+		g.preserveWhitespace = preserveWhitespaceCharData
+	}
+}
+
+// WithSanitizeIdentifierFunc sets the function used to make the identifiers
+// returned by the export name functions valid Go identifiers. Identifiers
+// returned by export name functions that unexport names, like
+// DefaultUnexportNameFunc, have the first rune of their sanitized form
+// lowercased. A nil sanitizeIdentifierFunc disables sanitization.
+func WithSanitizeIdentifierFunc(sanitizeIdentifierFunc SanitizeIdentifierFunc) GeneratorOption {
+	return func(g *Generator) {
+		g.sanitizeIdentifierFunc = sanitizeIdentifierFunc
+	}
+}
+
+// WithStripBOM sets whether to remove the byte order mark at the start of
+// observed documents. UTF-16 documents with a byte order mark are converted to
+// UTF-8 whether or not it is set.
+func WithStripBOM(stripBOM bool) GeneratorOption {
+	return func(g *Generator) {
+		g.stripBOM = stripBOM
+	}
+}
+
 // WithTimeLayout sets the time layout used to identify times in the observed
 // XML documents. Use an empty string to disable identifying times.
 func WithTimeLayout(timeLayout string) GeneratorOption {
@@ -197,6 +631,24 @@ func WithTimeLayout(timeLayout string) GeneratorOption {
 	}
 }
 
+// WithTimeZonePolicy sets the type of times whose layout does not include a
+// time zone offset. time.Time, the default, cannot decode them unless they are
+// in RFC 3339 format, and a decoded time.Time does not record that its time
+// zone was assumed.
+func WithTimeZonePolicy(timeZonePolicy TimeZonePolicy) GeneratorOption {
+	return func(g *Generator) {
+		g.timeZonePolicy = timeZonePolicy
+	}
+}
+
+// WithTokenFilters adds token filters, which transform the tokens read from
+// observed XML documents, in order, before elements are filtered and observed.
+func WithTokenFilters(tokenFilters ...TokenFilter) GeneratorOption {
+	return func(g *Generator) {
+		g.tokenFilters = append(g.tokenFilters, tokenFilters...)
+	}
+}
+
 // WithTopLevelAttributes sets whether to include top level attributes.
 func WithTopLevelAttributes(topLevelAttributes bool) GeneratorOption {
 	return func(g *Generator) {
@@ -204,8 +656,29 @@ func WithTopLevelAttributes(topLevelAttributes bool) GeneratorOption {
 	}
 }
 
+// WithTypeConfidenceThreshold sets the minimum fraction of the observed values
+// of an attribute or chardata that must be of a type more specific than string
+// for the type conflict policy to apply.
+func WithTypeConfidenceThreshold(typeConfidenceThreshold float64) GeneratorOption {
+	return func(g *Generator) {
+		g.typeConfidence = typeConfidenceThreshold
+	}
+}
+
+// WithTypeConflictPolicy sets the policy for attributes and chardata whose
+// observed values are not all of the same type. Use TypeConflicts to find
+// them.
+func WithTypeConflictPolicy(typeConflictPolicy TypeConflictPolicy) GeneratorOption {
+	return func(g *Generator) {
+		g.typeConflictPolicy = typeConflictPolicy
+	}
+}
+
 // WithUsePointersForOptionFields sets whether to use pointers for optional
-// fields in the generated Go source.
+// fields in the generated Go source. It is equivalent to a PointerPolicy with
+// both OptionalScalars and OptionalStructs set, except that repeated
+// attributes that are optional use slices of pointers. It is ignored if
+// WithPointerPolicy is used.
 func WithUsePointersForOptionalFields(usePointersForOptionalFields bool) GeneratorOption {
 	return func(g *Generator) {
 		g.usePointersForOptionalFields = usePointersForOptionalFields
@@ -220,79 +693,508 @@ func WithUseRawToken(useRawToken bool) GeneratorOption {
 	}
 }
 
+// WithValueCollation sets how example values are deduplicated and sorted. It
+// must be set before any XML documents are observed.
+func WithValueCollation(valueCollation ValueCollation) GeneratorOption {
+	return func(g *Generator) {
+		g.valueCollation = valueCollation
+	}
+}
+
+// WithValueExamples sets the maximum number of distinct example values that
+// are recorded for each attribute and chardata and emitted as comments in the
+// generated source. The smallest values, according to the value collation,
+// are kept so that the generated source is stable. It must be set before any
+// XML documents are observed.
+func WithValueExamples(valueExamples int) GeneratorOption {
+	return func(g *Generator) {
+		g.valueExamples = valueExamples
+	}
+}
+
+// WithValueSanitizer sets a function that is applied to every attribute value
+// and chardata before it is observed, for example to remove personal data.
+// Only the returned values are used to infer types and are recorded, so the
+// original values do not appear in the Generator's memory, saved state,
+// comments, or tags. It must be set before any XML documents are observed.
+func WithValueSanitizer(valueSanitizer ValueSanitizerFunc) GeneratorOption {
+	return func(g *Generator) {
+		g.valueSanitizer = valueSanitizer
+	}
+}
+
 // NewGenerator returns a new Generator with the given options.
 func NewGenerator(options ...GeneratorOption) *Generator {
 	g := &Generator{
+		attrMapThreshold:             DefaultAttrMapThreshold,
+		anonymizeEnumThreshold:       DefaultAnonymizationEnumThreshold,
 		attrNameSuffix:               DefaultAttrNameSuffix,
+		benchmarks:                   DefaultBenchmarks,
+		cardinalityPrecedence:        DefaultCardinalityPrecedence,
+		charDataElementName:          DefaultCharDataFieldNameFromElement,
+		charsetPrecedence:            DefaultCharsetPrecedence,
+		checkpointInterval:           DefaultCheckpointInterval,
 		charDataFieldName:            DefaultCharDataFieldName,
+		commonFieldExtraction:        DefaultCommonFieldExtraction,
+		deepCopyGenMarkers:           DefaultDeepCopyGenMarkers,
+		deepCopyMethods:              DefaultDeepCopyMethods,
 		elemNameSuffix:               DefaultElemNameSuffix,
+		exampleTags:                  DefaultExampleTags,
 		formatSource:                 DefaultFormatSource,
+		generatedFileSuffix:          DefaultGeneratedFileSuffix,
 		header:                       DefaultHeader,
+		identityConstraints:          DefaultIdentityConstraints,
 		imports:                      DefaultImports,
+		indexFuncs:                   DefaultIndexFuncs,
 		intType:                      DefaultIntType,
+		keyUniqueness:                DefaultKeyUniquenessThreshold,
+		minOccurrenceRate:            DefaultMinOccurrenceRate,
 		nameFunc:                     DefaultNameFunc,
 		namedRoot:                    DefaultNamedRoot,
 		namedTypes:                   DefaultNamedTypes,
 		compactTypes:                 DefaultCompactTypes,
 		packageName:                  DefaultPackageName,
+		preserveLeadingZeros:         DefaultPreserveLeadingZeros,
 		preserveOrder:                DefaultPreserveOrder,
+		preserveWhitespace:           DefaultPreserveWhitespaceCharData,
+		rootRegistry:                 DefaultRootRegistry,
+		sanitizeIdentifierFunc:       DefaultSanitizeIdentifierFunc,
+		stripBOM:                     DefaultStripBOM,
 		timeLayout:                   DefaultTimeLayout,
+		timeZonePolicy:               DefaultTimeZonePolicy,
 		topLevelAttributes:           DefaultTopLevelAttributes,
+		typeConfidence:               DefaultTypeConfidenceThreshold,
+		typeConflictPolicy:           DefaultTypeConflictPolicy,
 		typeOrder:                    make(map[xml.Name]int),
 		usePointersForOptionalFields: DefaultUsePointersForOptionalFields,
 		useRawToken:                  DefaultUseRawToken,
+		valueCollation:               DefaultValueCollation,
+		valueExamples:                DefaultValueExamples,
 		typeElements:                 make(map[xml.Name]*element),
 		emptyElements:                DefaultEmptyElements,
 	}
-	g.exportNameFunc = func(name xml.Name) string {
-		if exportRename, ok := g.exportRenames[name.Local]; ok {
-			return exportRename
-		}
-		return DefaultExportNameFunc(name)
-	}
 	for _, option := range options {
 		option(g)
 	}
-	if g.exportTypeNameFunc == nil {
-		g.exportTypeNameFunc = g.exportNameFunc
-	}
 	return g
 }
 
 // Generate returns the generated Go source for all the XML documents observed
 // so far.
 func (g *Generator) Generate() ([]byte, error) {
-	options := generateOptions{
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.generate(g.generateOptions())
+}
+
+// GenerateTo writes the generated Go source for all the XML documents observed
+// so far to w. With the default options, the whole source is assembled in
+// memory before anything is written to w, as formatting and AST hooks need the
+// complete source and imports depend on the generated types, so GenerateTo
+// uses as much memory as Generate. If the source is not formatted, there are
+// no AST hooks, imports are disabled with WithImports, and a package name is
+// set, then type declarations are instead written to w in small chunks as they
+// are generated, so w may receive partial source if an error occurs.
+func (g *Generator) GenerateTo(w io.Writer) error {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.generateTo(w, g.generateOptions())
+}
+
+// WriteTo implements io.WriterTo. It writes the generated Go source, like
+// GenerateTo, and returns the number of bytes written.
+func (g *Generator) WriteTo(w io.Writer) (int64, error) {
+	countingWriter := &countingWriter{w: w}
+	err := g.GenerateTo(countingWriter)
+	return countingWriter.n, err
+}
+
+// A countingWriter is an io.Writer that counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// generate returns the generated Go source using options.
+func (g *Generator) generate(options *generateOptions) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	if err := g.generateTo(buffer, options); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// generateTo writes the generated Go source using options to w. If the source
+// is not formatted, rewritten, or preceded by imports then it is written
+// directly to w, without first being assembled in memory.
+func (g *Generator) generateTo(w io.Writer, options *generateOptions) error {
+	if options.namedRoot {
+		options.importPackageNames["encoding/xml"] = ""
+	}
+
+	buildConstraint, err := g.buildConstraint(options.deprecatedBuildTags()...)
+	if err != nil {
+		return err
+	}
+
+	typeElements := g.sortedTypeElements(options)
+
+	if options.commonFieldExtraction > 1 {
+		options.commonAttrs, err = findCommonAttrs(typeElements, options)
+		if err != nil {
+			return err
+		}
+	}
+
+	packageName := options.packageName
+	if packageName == "" {
+		packageName = "main"
+	}
+	packageDeclaration := "package " + packageName + "\n"
+
+	// Imports are only known once all types have been written, so types can
+	// only be streamed if imports are not written before them.
+	var typesWriter io.Writer
+	var typesBuilder *strings.Builder
+	var streamWriter *bufio.Writer
+	streaming := len(g.astHooks) == 0 && !g.formatSource && options.packageName != "" && !g.imports
+	if streaming {
+		streamWriter = bufio.NewWriter(w)
+		writeSourceHeader(streamWriter, options.header, buildConstraint)
+		_, _ = streamWriter.WriteString(packageDeclaration)
+		typesWriter = streamWriter
+	} else {
+		typesBuilder = &strings.Builder{}
+		typesWriter = typesBuilder
+	}
+
+	typeNames := make(map[string]struct{})
+	for _, typeElement := range typeElements {
+		typeName := options.exportTypeNameFunc(typeElement.name)
+		if _, ok := typeNames[typeName]; ok {
+			return fmt.Errorf("%s: duplicate type name", typeName)
+		}
+		typeNames[typeName] = struct{}{}
+		_, _ = io.WriteString(typesWriter, "\n")
+		if g.deepCopyGenMarkers {
+			fmt.Fprintf(typesWriter, "%s\n", deepCopyGenMarker)
+		}
+		fmt.Fprintf(typesWriter, "type %s ", typeName)
+		if err := typeElement.writeGoType(typesWriter, typeElement.name.Local, options, ""); err != nil {
+			return err
+		}
+		_, _ = io.WriteString(typesWriter, "\n")
+	}
+
+	for _, typeName := range sortedKeys(options.declarations) {
+		if _, ok := typeNames[typeName]; ok {
+			return fmt.Errorf("%s: duplicate type name", typeName)
+		}
+		fmt.Fprintf(typesWriter, "\n%s", options.declarations[typeName])
+	}
+
+	for _, typeElement := range typeElements {
+		if typeElement.isStruct(options) && typeElement.hasAttrMap(options) {
+			writeAttrMapMarshalXMLMethod(typesWriter, options.exportTypeNameFunc(typeElement.name))
+		}
+	}
+
+	if len(g.attrDefaults) != 0 {
+		attrDefaultsWriter, err := newAttrDefaultsWriter(typeElements, options)
+		if err != nil {
+			return err
+		}
+		if err := attrDefaultsWriter.writeDefaultsMethods(typesWriter, typeElements); err != nil {
+			return err
+		}
+	}
+
+	if g.deepCopyMethods {
+		for _, typeElement := range typeElements {
+			typeName := options.exportTypeNameFunc(typeElement.name)
+			if err := writeDeepCopyMethods(typesWriter, typeName, typeElement, options); err != nil {
+				return err
+			}
+		}
+	}
+
+	if g.indexFuncs {
+		for _, typeElement := range typeElements {
+			typeName := options.exportTypeNameFunc(typeElement.name)
+			if err := writeIndexFuncs(typesWriter, typeName, typeElement, g.keyUniqueness, options); err != nil {
+				return err
+			}
+		}
+	}
+
+	if g.rootRegistry {
+		if err := writeRootRegistry(typesWriter, typeElements, typeNames, options); err != nil {
+			return err
+		}
+	}
+
+	if streaming {
+		return streamWriter.Flush()
+	}
+
+	if len(g.astHooks) == 0 && !g.formatSource && options.packageName != "" {
+		headerBuilder := &strings.Builder{}
+		writeSourceHeader(headerBuilder, options.header, buildConstraint)
+		headerBuilder.WriteString(packageDeclaration)
+		if g.imports {
+			writeImports(headerBuilder, options.importPackageNames)
+		}
+		if _, err := io.WriteString(w, headerBuilder.String()); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, typesBuilder.String())
+		return err
+	}
+
+	sourceBuilder := &strings.Builder{}
+	writeSourceHeader(sourceBuilder, options.header, buildConstraint)
+	sourceBuilder.WriteString(packageDeclaration)
+	if g.imports {
+		writeImports(sourceBuilder, options.importPackageNames)
+	}
+	sourceBuilder.WriteString(typesBuilder.String())
+
+	source := []byte(sourceBuilder.String())
+	if len(g.astHooks) != 0 {
+		source, err = g.applyASTHooks(source)
+		if err != nil {
+			return err
+		}
+	} else if g.formatSource {
+		if formattedSource, err := format.Source(source); err == nil {
+			source = formattedSource
+		}
+	}
+	if options.packageName == "" {
+		indexOfPackageDeclaration := bytes.Index(source, []byte(packageDeclaration))
+		if indexOfPackageDeclaration != -1 {
+			sourceWithoutPackageDeclaration := make([]byte, 0, len(source))
+			sourceWithoutPackageDeclaration = append(sourceWithoutPackageDeclaration, source[:indexOfPackageDeclaration]...)
+			indexOfTypeDecleration := indexOfPackageDeclaration + len(packageDeclaration)
+			// remove \n prefix
+			if len(source) > indexOfTypeDecleration {
+				indexOfTypeDecleration++
+			}
+			sourceWithoutPackageDeclaration = append(sourceWithoutPackageDeclaration, source[indexOfTypeDecleration:]...)
+			source = sourceWithoutPackageDeclaration
+		}
+	}
+
+	_, err = w.Write(source)
+	return err
+}
+
+// buildConstraint returns the //go:build line for g's build tags and
+// extraBuildTags, or an empty string if there are none.
+func (g *Generator) buildConstraint(extraBuildTags ...string) (string, error) {
+	var expr constraint.Expr
+	for _, buildTag := range slices.Concat(g.buildTags, extraBuildTags) {
+		buildTagExpr, err := constraint.Parse("//go:build " + buildTag)
+		if err != nil {
+			return "", fmt.Errorf("%s: invalid build tag: %w", buildTag, err)
+		}
+		if expr == nil {
+			expr = buildTagExpr
+		} else {
+			expr = &constraint.AndExpr{X: expr, Y: buildTagExpr}
+		}
+	}
+	if expr == nil {
+		return "", nil
+	}
+	return "//go:build " + expr.String(), nil
+}
+
+// writeSourceHeader writes header and buildConstraint, if they are not empty,
+// to w, each followed by a blank line.
+func writeSourceHeader(w io.Writer, header, buildConstraint string) {
+	if header != "" {
+		fmt.Fprintf(w, "%s\n\n", header)
+	}
+	if buildConstraint != "" {
+		fmt.Fprintf(w, "%s\n\n", buildConstraint)
+	}
+}
+
+// writeImports writes the import declaration for importPackageNames, a map
+// of import paths to import aliases, to w.
+func writeImports(w io.Writer, importPackageNames map[string]string) {
+	switch len(importPackageNames) {
+	case 0:
+		// Do nothing.
+	case 1:
+		for importPackageName, importAlias := range importPackageNames {
+			fmt.Fprintf(w, "import %s\n", importSpec(importPackageName, importAlias))
+		}
+	default:
+		fmt.Fprintf(w, "import (\n")
+		for _, importPackageName := range sortedKeys(importPackageNames) {
+			fmt.Fprintf(w, "\t%s\n", importSpec(importPackageName, importPackageNames[importPackageName]))
+		}
+		fmt.Fprintf(w, ")\n")
+	}
+}
+
+// applyASTHooks returns source after it has been parsed, modified by g's AST
+// hooks, and formatted.
+func (g *Generator) applyASTHooks(source []byte) ([]byte, error) {
+	fileSet := token.NewFileSet()
+	file, err := parser.ParseFile(fileSet, "", source, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	for _, astHook := range g.astHooks {
+		if err := astHook(file); err != nil {
+			return nil, err
+		}
+	}
+	formattedSource := &bytes.Buffer{}
+	if err := format.Node(formattedSource, fileSet, file); err != nil {
+		return nil, err
+	}
+	return formattedSource.Bytes(), nil
+}
+
+// generateOptions returns the options for generating Go source.
+func (g *Generator) generateOptions() *generateOptions {
+	return &generateOptions{
+		anyAttrs:                     g.anyAttrs,
+		anyElements:                  g.anyElements,
+		attrDefaults:                 newAttrDefaults(g.attrDefaults),
+		attrMapThreshold:             g.attrMapThreshold,
 		attrNameSuffix:               g.attrNameSuffix,
+		cardinalities:                g.cardinalities,
+		cardinalityPrecedence:        g.cardinalityPrecedence,
+		charDataElementName:          g.charDataElementName,
 		charDataFieldName:            g.charDataFieldName,
+		commonFieldExtraction:        g.commonFieldExtraction,
+		declarations:                 make(map[string]string),
+		deprecatedBuildTag:           g.deprecatedBuildTag,
+		deprecatedFields:             g.deprecatedBuildTag == "",
+		deprecations:                 g.deprecations,
+		documents:                    g.documents,
 		elemNameSuffix:               g.elemNameSuffix,
-		exportNameFunc:               g.exportNameFunc,
-		exportTypeNameFunc:           g.exportTypeNameFunc,
+		exampleRedactFunc:            g.exampleRedactFunc,
+		exampleTags:                  g.exampleTags,
+		exportNameFunc:               g.sanitizeExportNameFunc(g.resolvedExportNameFunc()),
+		exportTypeNameFunc:           g.sanitizeExportNameFunc(g.resolvedExportTypeNameFunc()),
 		header:                       g.header,
-		importPackageNames:           make(map[string]struct{}),
+		importMappings:               g.importMappings,
+		importPackageNames:           make(map[string]string),
 		intType:                      g.intType,
+		loggedDecisions:              make(map[string]struct{}),
+		logger:                       g.logger,
+		mapElements:                  g.mapElements,
+		minOccurrenceRate:            g.minOccurrenceRate,
 		namedRoot:                    g.namedRoot,
+		numberFormat:                 g.numberFormat,
+		packageName:                  g.packageName,
 		compactTypes:                 g.compactTypes,
 		preserveOrder:                g.preserveOrder,
-		usePointersForOptionalFields: g.usePointersForOptionalFields,
+		timeLayout:                   g.timeLayout,
+		timeZonePolicy:               g.timeZonePolicy,
+		typeConfidence:               g.typeConfidence,
+		typeConflictPolicy:           g.typeConflictPolicy,
+		pointerPolicy:                g.generatePointerPolicy(),
+		usePointersForOptionalFields: g.pointerPolicy == nil && g.usePointersForOptionalFields,
+		valueCollation:               g.valueCollation,
 		emptyElements:                g.emptyElements,
 	}
+}
 
-	if options.namedRoot {
-		options.importPackageNames["encoding/xml"] = struct{}{}
+// resolvedExportNameFunc returns g's export name function. Unless it is set
+// with WithExportNameFunc, it applies g's export renames.
+func (g *Generator) resolvedExportNameFunc() ExportNameFunc {
+	if g.exportNameFunc != nil {
+		return g.exportNameFunc
 	}
+	exportRenames := g.exportRenames
+	return func(name xml.Name) string {
+		if exportRename, ok := exportRenames[name.Local]; ok {
+			return exportRename
+		}
+		return DefaultExportNameFunc(name)
+	}
+}
 
+// resolvedExportTypeNameFunc returns g's export type name function. Unless it
+// is set with WithExportTypeNameFunc, it applies g's export type renames and
+// otherwise uses g's export name function.
+func (g *Generator) resolvedExportTypeNameFunc() ExportNameFunc {
+	if g.exportTypeNameFunc != nil {
+		return g.exportTypeNameFunc
+	}
+	exportNameFunc := g.resolvedExportNameFunc()
+	exportTypeRenames := g.exportTypeRenames
+	return func(name xml.Name) string {
+		if exportTypeRename, ok := exportTypeRenames[name.Local]; ok {
+			return exportTypeRename
+		}
+		return exportNameFunc(name)
+	}
+}
+
+// generatePointerPolicy returns the pointer policy set with WithPointerPolicy
+// or, if there is none, the policy equivalent to
+// WithUsePointersForOptionalFields.
+func (g *Generator) generatePointerPolicy() PointerPolicy {
+	if g.pointerPolicy != nil {
+		return *g.pointerPolicy
+	}
+	return PointerPolicy{
+		OptionalScalars: g.usePointersForOptionalFields,
+		OptionalStructs: g.usePointersForOptionalFields,
+	}
+}
+
+// sanitizeExportNameFunc returns exportNameFunc with its results sanitized by
+// g's identifier sanitizer. If exportNameFunc returns unexported names, like
+// DefaultUnexportNameFunc, then sanitized names are kept unexported, so 1st
+// becomes x1st rather than X1st.
+func (g *Generator) sanitizeExportNameFunc(exportNameFunc ExportNameFunc) ExportNameFunc {
+	sanitizeIdentifierFunc := g.sanitizeIdentifierFunc
+	if sanitizeIdentifierFunc == nil {
+		return exportNameFunc
+	}
+	if token.IsExported(exportNameFunc(xml.Name{Local: "a"})) {
+		return func(name xml.Name) string {
+			return sanitizeIdentifierFunc(exportNameFunc(name))
+		}
+	}
+	return func(name xml.Name) string {
+		return lowerFirstRune(sanitizeIdentifierFunc(exportNameFunc(name)))
+	}
+}
+
+// sortedTypeElements returns the elements for which named types are
+// generated, in order. If g generates named types, then it also initializes
+// options.namedTypes and options.simpleTypes.
+func (g *Generator) sortedTypeElements(options *generateOptions) []*element {
 	var typeElements []*element
 	if g.namedTypes {
 		options.namedTypes = make(map[xml.Name]*element)
 		for k, v := range g.typeElements {
+			if _, ok := options.mapElementKeyAttrName(v); ok && !v.root {
+				continue
+			}
 			if !options.compactTypes || !v.isContainer() || v.root {
 				options.namedTypes[k] = v
 			}
 		}
 		options.simpleTypes = make(map[xml.Name]struct{})
 		for name, element := range options.namedTypes {
-			if len(element.attrValues) != 0 || len(element.childElements) != 0 || element.root {
+			if !element.isSimple() || element.root {
 				continue
 			}
 			options.simpleTypes[name] = struct{}{}
@@ -309,87 +1211,11 @@ func (g *Generator) Generate() ([]byte, error) {
 		})
 	} else {
 		slices.SortFunc(typeElements, func(a, b *element) int {
-			aExportedName := options.exportNameFunc(a.name)
-			bExportedName := options.exportNameFunc(b.name)
-			switch {
-			case aExportedName < bExportedName:
-				return -1
-			case aExportedName == bExportedName:
-				return 0
-			default:
-				return 1
-			}
+			return strings.Compare(options.exportNameFunc(a.name), options.exportNameFunc(b.name))
 		})
 	}
 
-	typesBuilder := &strings.Builder{}
-	typeNames := make(map[string]struct{})
-	for _, typeElement := range typeElements {
-		typeName := options.exportTypeNameFunc(typeElement.name)
-		if _, ok := typeNames[typeName]; ok {
-			return nil, fmt.Errorf("%s: duplicate type name", typeName)
-		}
-		typeNames[typeName] = struct{}{}
-		fmt.Fprintf(typesBuilder, "\ntype %s ", typeName)
-		if err := typeElement.writeGoType(typesBuilder, &options, ""); err != nil {
-			return nil, err
-		}
-		typesBuilder.WriteByte('\n')
-	}
-
-	sourceBuilder := &strings.Builder{}
-	if options.header != "" {
-		fmt.Fprintf(sourceBuilder, "%s\n\n", options.header)
-	}
-	packageName := g.packageName
-	if packageName == "" {
-		packageName = "main"
-	}
-	packageDeclaration := "package " + packageName + "\n"
-	sourceBuilder.WriteString(packageDeclaration)
-	if g.imports {
-		switch len(options.importPackageNames) {
-		case 0:
-			// Do nothing.
-		case 1:
-			for importPackageName := range options.importPackageNames {
-				fmt.Fprintf(sourceBuilder, "import %q\n", importPackageName)
-			}
-		default:
-			fmt.Fprintf(sourceBuilder, "import (\n")
-			importPackageNames := mapKeys(options.importPackageNames)
-			sort.Strings(importPackageNames)
-			for _, importPackageName := range importPackageNames {
-				fmt.Fprintf(sourceBuilder, "\t%q\n", importPackageName)
-			}
-			fmt.Fprintf(sourceBuilder, ")\n")
-		}
-	}
-	sourceBuilder.WriteString(typesBuilder.String())
-
-	source := []byte(sourceBuilder.String())
-	if g.formatSource {
-		if formattedSource, err := format.Source(source); err == nil {
-			source = formattedSource
-		}
-	}
-	if g.packageName == "" {
-		indexOfPackageDeclaration := 0
-		if g.header != "" {
-			indexOfPackageDeclaration = len(g.header) + 2
-		}
-		sourceWithoutPackageDeclaration := make([]byte, 0, len(source))
-		sourceWithoutPackageDeclaration = append(sourceWithoutPackageDeclaration, source[:indexOfPackageDeclaration]...)
-		indexOfTypeDecleration := indexOfPackageDeclaration + len(packageDeclaration)
-		// remove \n prefix
-		if len(source) > indexOfTypeDecleration {
-			indexOfTypeDecleration++
-		}
-		sourceWithoutPackageDeclaration = append(sourceWithoutPackageDeclaration, source[indexOfTypeDecleration:]...)
-		source = sourceWithoutPackageDeclaration
-	}
-
-	return source, nil
+	return typeElements
 }
 
 // ObserveFS observes all XML documents in fs.
@@ -408,46 +1234,254 @@ func (g *Generator) ObserveFS(fsys fs.FS, root string, observeFunc func(string,
 				return err
 			}
 			defer file.Close()
-			return g.ObserveReader(file)
+			return g.observeInput(path, file, false)
 		}
 	})
 }
 
-// ObserveFile observes an XML document in the given file.
+// ObserveFile observes an XML document in the given file. The file's name and
+// hash are recorded in g's Metadata.
 func (g *Generator) ObserveFile(name string) error {
+	return g.observeFile(name, false)
+}
+
+// ObserveFragmentsFile observes a stream of concatenated XML fragments in the
+// given file, like ObserveFragments. The file's name and hash are recorded in
+// g's Metadata.
+func (g *Generator) ObserveFragmentsFile(name string) error {
+	return g.observeFile(name, true)
+}
+
+// observeFile observes the XML in the file name.
+func (g *Generator) observeFile(name string, fragments bool) error {
 	file, err := os.Open(name)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
-	return g.ObserveReader(file)
+	return g.observeInput(name, file, fragments)
+}
+
+// observeInput observes the XML from r, read from the file name, and records
+// its name and hash.
+func (g *Generator) observeInput(name string, r io.Reader, fragments bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	start := time.Now()
+	documents := g.documents
+	hash := sha256.New()
+	if err := g.observeReader(io.TeeReader(r, hash), fragments); err != nil {
+		var decodeError *DecodeError
+		if errors.As(err, &decodeError) {
+			decodeError.Name = name
+		}
+		return err
+	}
+	if g.logger != nil {
+		g.logger.Debug("observed file",
+			"name", name,
+			"documents", g.documents-documents,
+			"duration", time.Since(start),
+		)
+	}
+	g.inputs = append(g.inputs, MetadataInput{
+		Name:   name,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+	})
+	return g.checkpoint()
+}
+
+// ObserveElement observes a synthetic element, as if it were in an XML
+// document, without reading a document. This allows knowledge that is not
+// present in the observed documents to be added, for example elements that are
+// documented but that do not appear in any sample.
+//
+// path is the names of the elements from the top level element to the observed
+// element. charData, if it is not empty or whitespace, is observed as the
+// element's chardata. attrs are observed as the element's attributes, subject
+// to WithTopLevelAttributes for top level elements. Elements and attributes
+// that are added to previously observed elements are marked optional, as they
+// were absent from the observed documents.
+func (g *Generator) ObserveElement(path []xml.Name, charData string, attrs []xml.Attr) error {
+	if len(path) == 0 {
+		return errors.New("empty path")
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	options := g.observeOptions()
+
+	name := options.pathNameFunc(nil, path[0])
+	if name == (xml.Name{}) {
+		return fmt.Errorf("%s: ignored element", path[0].Local)
+	}
+	currentElement, ok := g.typeElements[name]
+	created := !ok
+	if created {
+		currentElement = newElement(name)
+		currentElement.root = true
+		g.typeElements[name] = currentElement
+	}
+	if _, ok := g.typeOrder[name]; !ok {
+		g.typeOrder[name] = options.getOrder()
+	}
+
+	for i, pathName := range path[1:] {
+		childName := options.pathNameFunc(path[:i+1:i+1], pathName)
+		if childName == (xml.Name{}) {
+			return fmt.Errorf("%s: ignored element", pathName.Local)
+		}
+		_, isChild := currentElement.childElements[childName]
+		_, isTopLevel := options.topLevelElements[childName]
+		childElement := currentElement.childElement(childName, &options)
+		if !isChild && !created {
+			currentElement.optionalChildren[childName] = struct{}{}
+		}
+		created = !isChild && !isTopLevel
+		currentElement = childElement
+	}
+
+	if len(path) > 1 || options.topLevelAttributes {
+		attrCounts := make(map[xml.Name]int)
+		for _, attr := range attrs {
+			attrName := options.pathNameFunc(path, attr.Name)
+			if attrName == (xml.Name{}) {
+				continue
+			}
+			attrCounts[attrName]++
+			attrValue, ok := currentElement.attrValues[attrName]
+			if !ok {
+				attrValue = &value{
+					name:     attrName,
+					optional: !created,
+				}
+				currentElement.attrValues[attrName] = attrValue
+			}
+			attrValueStr := options.sanitizeValue(path, attr.Name, attr.Value)
+			attrValue.observe(attrValueStr, &options)
+			attrValue.observeIdentityValue(attrValueStr, &options)
+		}
+		currentElement.observeAttrCoOccurrences(attrCounts)
+	}
+
+	if strings.TrimSpace(charData) != "" {
+		currentElement.charDataValue.observe(options.sanitizeValue(path, xml.Name{}, charData), &options)
+	} else {
+		currentElement.charDataAbsent = true
+	}
+
+	return g.checkpoint()
 }
 
 // ObserveReader observes an XML document from r.
 func (g *Generator) ObserveReader(r io.Reader) error {
-	options := observeOptions{
-		getOrder: func() int {
-			g.order++
-			return g.order
-		},
-		nameFunc:           g.nameFunc,
-		timeLayout:         g.timeLayout,
-		topLevelAttributes: g.topLevelAttributes,
-		typeOrder:          g.typeOrder,
-		useRawToken:        g.useRawToken,
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.observeReader(r, false); err != nil {
+		return err
 	}
-	if g.namedTypes {
-		options.topLevelElements = g.typeElements
+	return g.checkpoint()
+}
+
+// ObserveFragments observes a stream of concatenated XML fragments from r, for
+// example one document per line as is common in logs and message queue dumps.
+// Every top level element is observed as the root element of a separate
+// document and XML declarations may appear before each fragment. Errors are
+// annotated with the index of the fragment, starting from zero, in which they
+// occurred.
+func (g *Generator) ObserveFragments(r io.Reader) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.observeReader(r, true); err != nil {
+		return err
 	}
+	return g.checkpoint()
+}
 
+// CheckReader returns an error if the XML document read from r cannot be
+// observed. It decodes r as ObserveReader does, with g's charset handling,
+// entities, decoder modifications, and token filters, but does not change g's
+// model, so it can be used to reject truncated or invalid documents before
+// observing them.
+func (g *Generator) CheckReader(r io.Reader) error {
+	g.mu.RLock()
+	checker := g.cloneOptions()
+	g.mu.RUnlock()
+	checker.benchmarks = false
+	checker.logger = nil
+	return checker.observeReader(r, false)
+}
+
+// observeReader observes XML from r. If fragments is true then every top level
+// element is treated as a root element, otherwise only the first is.
+func (g *Generator) observeReader(r io.Reader, fragments bool) error {
+	options := g.observeOptions()
+
+	var charsetName string
+	charsetReader := func(label string, input io.Reader) (io.Reader, error) {
+		charsetName = label
+		return charset.NewReaderLabel(label, input)
+	}
+	if g.stripBOM || g.charsetPrecedence != DefaultCharsetPrecedence || len(g.fallbackCharsets) > 0 {
+		var err error
+		r, charsetName, err = g.charsetReader(r)
+		if err != nil {
+			return &DecodeError{
+				Charset: charsetName,
+				Err:     err,
+			}
+		}
+		// r is already converted to UTF-8, so ignore the XML declaration.
+		charsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+			return input, nil
+		}
+	}
+
+	var recorder *sampleRecorder
+	var inputDecoder *xml.Decoder
+	if g.benchmarks && g.valueSanitizer == nil {
+		recorder = &sampleRecorder{}
+		r = recorder.rawReader(r)
+		rawCharsetReader := charsetReader
+		charsetReader = func(label string, input io.Reader) (io.Reader, error) {
+			convertedReader, err := rawCharsetReader(label, input)
+			if err != nil {
+				return nil, err
+			}
+			return recorder.convertedReader(convertedReader, inputDecoder.InputOffset()), nil
+		}
+	}
+
+	entities := g.decoderEntities()
 	decoder := xml.NewDecoder(r)
-	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.CharsetReader = charsetReader
+	decoder.Entity = entities
 	if g.modifyDecoderFunc != nil {
 		g.modifyDecoderFunc(decoder)
 	}
+	inputDecoder = decoder
+	if len(g.tokenFilters) > 0 {
+		decoder = xml.NewTokenDecoder(&filteredTokenReader{
+			decoder:      decoder,
+			useRawToken:  g.useRawToken,
+			tokenFilters: g.tokenFilters,
+		})
+	}
 	var foundRootElement bool
+	fragment := 0
+	annotateError := func(err error) error {
+		if fragments {
+			err = fmt.Errorf("fragment %d: %w", fragment, err)
+		}
+		return &DecodeError{
+			Offset:  inputDecoder.InputOffset(),
+			Charset: charsetName,
+			Err:     err,
+		}
+	}
 FOR:
 	for {
+		tokenStart := inputDecoder.InputOffset()
 		var token xml.Token
 		var err error
 		if g.useRawToken {
@@ -459,15 +1493,28 @@ FOR:
 		case errors.Is(err, io.EOF):
 			return nil
 		case err != nil:
-			return err
+			return annotateError(err)
 		default:
+			if directive, ok := token.(xml.Directive); ok {
+				if err := g.observeDirective(directive, entities); err != nil {
+					return annotateError(err)
+				}
+			}
 			if startElement, ok := token.(xml.StartElement); ok {
 				var root bool
-				if !foundRootElement {
+				if !foundRootElement || fragments {
 					foundRootElement = true
 					root = true
 				}
-				name := g.nameFunc(startElement.Name)
+				if !options.observeElement(nil, startElement) {
+					options.logSkippedElement(nil, startElement.Name)
+					if err := options.skipElement(decoder); err != nil {
+						return annotateError(err)
+					}
+					fragment++
+					continue FOR
+				}
+				name := options.pathNameFunc(nil, startElement.Name)
 				if name == (xml.Name{}) {
 					continue FOR
 				}
@@ -480,10 +1527,50 @@ FOR:
 				if _, ok := g.typeOrder[name]; !ok {
 					g.typeOrder[name] = options.getOrder()
 				}
-				if err := typeElement.observeChildElement(decoder, startElement, 0, &options); err != nil {
-					return err
+				g.documents++
+				options.document = g.documents
+				if err := typeElement.observeChildElement(decoder, startElement, []xml.Name{startElement.Name}, &options); err != nil {
+					return annotateError(err)
+				}
+				if recorder != nil && root {
+					typeElement.observeSample(recorder.sample(tokenStart, inputDecoder.InputOffset()))
 				}
+				fragment++
 			}
 		}
 	}
 }
+
+// observeOptions returns the options for observing XML documents.
+func (g *Generator) observeOptions() observeOptions {
+	options := observeOptions{
+		elementFilters: g.elementFilters,
+		exampleTags:    g.exampleTags,
+		getOrder: func() int {
+			g.order++
+			return g.order
+		},
+		identityConstraints:  g.identityConstraints,
+		logger:               g.logger,
+		numberFormat:         g.numberFormat,
+		pathNameFunc:         g.pathNameFunc,
+		preserveLeadingZeros: g.preserveLeadingZeros,
+		preserveWhitespace:   g.preserveWhitespace,
+		timeLayout:           g.timeLayout,
+		topLevelAttributes:   g.topLevelAttributes,
+		typeOrder:            g.typeOrder,
+		useRawToken:          g.useRawToken,
+		valueCollation:       g.valueCollation,
+		valueExamples:        g.valueExamples,
+		valueSanitizer:       g.valueSanitizer,
+	}
+	if options.pathNameFunc == nil {
+		options.pathNameFunc = func(_ []xml.Name, name xml.Name) xml.Name {
+			return g.nameFunc(name)
+		}
+	}
+	if g.namedTypes {
+		options.topLevelElements = g.typeElements
+	}
+	return options
+}
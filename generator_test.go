@@ -1,6 +1,13 @@
 package xmlstruct_test
 
 import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"regexp"
+	"slices"
 	"strings"
 	"testing"
 
@@ -232,6 +239,95 @@ func TestGenerator(t *testing.T) {
 				`}`,
 			),
 		},
+		{
+			name: "path_name_func",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithPathNameFunc(func(ancestors []xml.Name, name xml.Name) xml.Name {
+					if len(ancestors) == 0 {
+						return name
+					}
+					parent := ancestors[len(ancestors)-1]
+					switch {
+					case parent.Local == "d" && name.Local == "c":
+						return xml.Name{}
+					case parent.Local == "b" && name.Local == "id":
+						return xml.Name{}
+					default:
+						return name
+					}
+				}),
+			},
+			xmlStr: joinLines(
+				`<a>`,
+				`  <b id="1"><c id="2"/></b>`,
+				`  <d><c id="3"/></d>`,
+				`</a>`,
+			),
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`type A struct {`,
+				"\tB struct {",
+				"\t\tC struct {",
+				"\t\t\tID int `xml:\"id,attr\"`",
+				"\t\t} `xml:\"c\"`",
+				"\t} `xml:\"b\"`",
+				"\tD struct{} `xml:\"d\"`",
+				`}`,
+			),
+		},
+		{
+			name: "skip_elements_with_attr",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithElementFilter(xmlstruct.SkipElementsWithAttr("field", "deprecated", "true")),
+				xmlstruct.WithUseRawToken(true),
+			},
+			xmlStr: joinLines(
+				`<a xmlns:ext="urn:ext">`,
+				`  <ext:field name="x">1</ext:field>`,
+				`  <ext:field name="y" deprecated="true"><old>z</old></ext:field>`,
+				`</a>`,
+			),
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`type A struct {`,
+				"\tField struct {",
+				"\t\tName     string `xml:\"name,attr\"`",
+				"\t\tCharData int    `xml:\",chardata\"`",
+				"\t} `xml:\"field\"`",
+				`}`,
+			),
+		},
+		{
+			name: "only_elements_with_attr",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithElementFilter(xmlstruct.OnlyElementsWithAttr("record", "type", "order")),
+			},
+			xmlStr: joinLines(
+				`<export>`,
+				`  <record type="order"><total>1</total></record>`,
+				`  <record type="customer"><name>x</name></record>`,
+				`  <record type="order"><total>2</total></record>`,
+				`</export>`,
+			),
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`type Export struct {`,
+				"\tRecord []struct {",
+				"\t\tType  string `xml:\"type,attr\"`",
+				"\t\tTotal int    `xml:\"total\"`",
+				"\t} `xml:\"record\"`",
+				`}`,
+			),
+		},
 		{
 			name: "unexported_named_types",
 			options: []xmlstruct.GeneratorOption{
@@ -429,6 +525,48 @@ func TestGenerator(t *testing.T) {
 				`type A string`,
 			),
 		},
+		{
+			name: "cardinality_union",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithCardinality(0, xmlstruct.Unbounded, xmlstruct.MatchPath("a/b")),
+				xmlstruct.WithCardinality(0, 1, xmlstruct.MatchPath("b/@id")),
+				xmlstruct.WithCardinality(1, 1, xmlstruct.MatchPath("a/c")),
+			},
+			xmlStr: `<a><b id="1"/><c/><c/></a>`,
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`type A struct {`,
+				`	B []struct {`,
+				"\t\tID *int `xml:\"id,attr\"`",
+				"\t} `xml:\"b\"`",
+				"\tC []struct{} `xml:\"c\"`",
+				`}`,
+			),
+		},
+		{
+			name: "cardinality_declared",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithCardinality(0, xmlstruct.Unbounded, xmlstruct.MatchPath("a/b")),
+				xmlstruct.WithCardinality(1, 1, xmlstruct.MatchPath("a/c")),
+				xmlstruct.WithCardinalityPrecedence(xmlstruct.CardinalityPrecedenceDeclared),
+			},
+			xmlStr: `<a><b id="1"/><c/><c/></a>`,
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`type A struct {`,
+				`	B []struct {`,
+				"\t\tID int `xml:\"id,attr\"`",
+				"\t} `xml:\"b\"`",
+				"\tC struct{} `xml:\"c\"`",
+				`}`,
+			),
+		},
 		{
 			name: "char_data_field_name",
 			options: []xmlstruct.GeneratorOption{
@@ -452,6 +590,47 @@ func TestGenerator(t *testing.T) {
 				`}`,
 			),
 		},
+		{
+			name: "char_data_with_attrs",
+			xmlStrs: []string{
+				`<catalog><price currency="EUR">9.99</price><date zone="UTC">2024-01-02T03:04:05Z</date></catalog>`,
+				`<catalog><price currency="USD">10</price><date zone="UTC"/></catalog>`,
+			},
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`type Catalog struct {`,
+				"\tDate struct {",
+				"\t\tZone     string `xml:\"zone,attr\"`",
+				"\t\tCharData string `xml:\",chardata\"`",
+				"\t} `xml:\"date\"`",
+				"\tPrice struct {",
+				"\t\tCurrency string  `xml:\"currency,attr\"`",
+				"\t\tCharData float64 `xml:\",chardata\"`",
+				"\t} `xml:\"price\"`",
+				`}`,
+			),
+		},
+		{
+			name: "char_data_field_name_from_element",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithCharDataFieldNameFromElement(true),
+				xmlstruct.WithTopLevelAttributes(true),
+			},
+			xmlStr: `<price currency="EUR">9.99</price>`,
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`type Price struct {`,
+				"\tCurrency string  `xml:\"currency,attr\"`",
+				"\tPrice    float64 `xml:\",chardata\"`",
+				`}`,
+			),
+		},
 		{
 			name:    "test_int_parse",
 			options: []xmlstruct.GeneratorOption{},
@@ -614,6 +793,58 @@ func TestGenerator(t *testing.T) {
 				`}`,
 			),
 		},
+		{
+			name: "common_field_extraction",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithCommonFieldExtraction(2),
+				xmlstruct.WithTopLevelAttributes(true),
+			},
+			xmlStr: `<a id="1" kind="x"><b id="2" kind="y" n="1"/><c id="3"/></a>`,
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`type A struct {`,
+				`	CommonAttrs`,
+				`	B struct {`,
+				`		CommonAttrs`,
+				"\t\tN int `xml:\"n,attr\"`",
+				"\t} `xml:\"b\"`",
+				`	C struct {`,
+				"\t\tID int `xml:\"id,attr\"`",
+				"\t} `xml:\"c\"`",
+				`}`,
+				``,
+				`// CommonAttrs contains the attributes that are common to many elements.`,
+				`type CommonAttrs struct {`,
+				"\tID   int    `xml:\"id,attr\"`",
+				"\tKind string `xml:\"kind,attr\"`",
+				`}`,
+			),
+		},
+		{
+			name: "common_field_extraction_below_threshold",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithCommonFieldExtraction(3),
+				xmlstruct.WithTopLevelAttributes(true),
+			},
+			xmlStr: `<a id="1" kind="x"><b id="2" kind="y"/></a>`,
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`type A struct {`,
+				"\tID   int    `xml:\"id,attr\"`",
+				"\tKind string `xml:\"kind,attr\"`",
+				`	B    struct {`,
+				"\t\tID   int    `xml:\"id,attr\"`",
+				"\t\tKind string `xml:\"kind,attr\"`",
+				"\t} `xml:\"b\"`",
+				`}`,
+			),
+		},
 		{
 			name: "compact_types",
 			options: []xmlstruct.GeneratorOption{
@@ -786,29 +1017,1946 @@ func TestGenerator(t *testing.T) {
 				"}",
 			),
 		},
-	} {
-		tc := tc
-		t.Run(tc.name, func(t *testing.T) {
-			t.Parallel()
-
-			generator := xmlstruct.NewGenerator(tc.options...)
-			if tc.xmlStr != "" {
-				assert.NoError(t, generator.ObserveReader(strings.NewReader(tc.xmlStr)))
-			}
-			for _, xmlStr := range tc.xmlStrs {
-				assert.NoError(t, generator.ObserveReader(strings.NewReader(xmlStr)))
-			}
-			actual, err := generator.Generate()
-			if tc.expectedErr != "" {
-				assert.EqualError(t, err, tc.expectedErr)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tc.expectedStr, string(actual))
-			}
-		})
-	}
-}
-
-func joinLines(lines ...string) string {
-	return strings.Join(lines, "\n") + "\n"
+		{
+			name: "deep_copy",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithDeepCopyGenMarkers(true),
+				xmlstruct.WithDeepCopyMethods(true),
+				xmlstruct.WithNamedTypes(true),
+			},
+			xmlStrs: []string{
+				`<a><b c="1"/><b/><d/></a>`,
+				`<a/>`,
+			},
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`import "slices"`,
+				``,
+				`// +k8s:deepcopy-gen=true`,
+				`type A struct {`,
+				"\tB []B       `xml:\"b\"`",
+				"\tD *struct{} `xml:\"d\"`",
+				`}`,
+				``,
+				`// +k8s:deepcopy-gen=true`,
+				`type B struct {`,
+				"\tC *int `xml:\"c,attr\"`",
+				`}`,
+				``,
+				`// DeepCopyInto copies the receiver into out. in must be non-nil.`,
+				`func (in *A) DeepCopyInto(out *A) {`,
+				`	*out = *in`,
+				`	if in.B != nil {`,
+				`		out.B = slices.Clone(in.B)`,
+				`		for i := range in.B {`,
+				`			in.B[i].DeepCopyInto(&out.B[i])`,
+				`		}`,
+				`	}`,
+				`	if in.D != nil {`,
+				`		x := *in.D`,
+				`		out.D = &x`,
+				`	}`,
+				`}`,
+				``,
+				`// DeepCopy returns a deep copy of the receiver.`,
+				`func (in *A) DeepCopy() *A {`,
+				`	if in == nil {`,
+				`		return nil`,
+				`	}`,
+				`	out := new(A)`,
+				`	in.DeepCopyInto(out)`,
+				`	return out`,
+				`}`,
+				``,
+				`// DeepCopyInto copies the receiver into out. in must be non-nil.`,
+				`func (in *B) DeepCopyInto(out *B) {`,
+				`	*out = *in`,
+				`	if in.C != nil {`,
+				`		x := *in.C`,
+				`		out.C = &x`,
+				`	}`,
+				`}`,
+				``,
+				`// DeepCopy returns a deep copy of the receiver.`,
+				`func (in *B) DeepCopy() *B {`,
+				`	if in == nil {`,
+				`		return nil`,
+				`	}`,
+				`	out := new(B)`,
+				`	in.DeepCopyInto(out)`,
+				`	return out`,
+				`}`,
+			),
+		},
+		{
+			name: "attr_defaults",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithAttrDefaults(map[string]string{
+					"b/@c": "EUR",
+					"b/@d": "1",
+				}),
+				xmlstruct.WithNamedTypes(true),
+			},
+			xmlStrs: []string{
+				`<a><b c="USD" d="2"/><b/></a>`,
+			},
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`type A struct {`,
+				"\tB []B `xml:\"b\"`",
+				`}`,
+				``,
+				`type B struct {`,
+				"\tC *string `xml:\"c,attr\"`",
+				"\tD *int    `xml:\"d,attr\"`",
+				`}`,
+				``,
+				`// ApplyDefaults sets all unset attributes that have default values to their`,
+				`// default values.`,
+				`func (x *A) ApplyDefaults() {`,
+				`	for i := range x.B {`,
+				`		x.B[i].ApplyDefaults()`,
+				`	}`,
+				`}`,
+				``,
+				`// ApplyDefaults sets all unset attributes that have default values to their`,
+				`// default values.`,
+				`func (x *B) ApplyDefaults() {`,
+				`	if x.C == nil {`,
+				`		v := "EUR"`,
+				`		x.C = &v`,
+				`	}`,
+				`	if x.D == nil {`,
+				`		v := 1`,
+				`		x.D = &v`,
+				`	}`,
+				`}`,
+			),
+		},
+		{
+			name: "attr_defaults_explicit_zero",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithAttrDefaults(map[string]string{
+					"b/@c": "5",
+					"b/@d": "true",
+				}),
+				xmlstruct.WithNamedTypes(true),
+			},
+			xmlStrs: []string{
+				`<a><b c="0" d="false"/><b c="1" d="true"/></a>`,
+			},
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`type A struct {`,
+				"\tB []B `xml:\"b\"`",
+				`}`,
+				``,
+				`type B struct {`,
+				"\tC *int  `xml:\"c,attr\"`",
+				"\tD *bool `xml:\"d,attr\"`",
+				`}`,
+				``,
+				`// ApplyDefaults sets all unset attributes that have default values to their`,
+				`// default values.`,
+				`func (x *A) ApplyDefaults() {`,
+				`	for i := range x.B {`,
+				`		x.B[i].ApplyDefaults()`,
+				`	}`,
+				`}`,
+				``,
+				`// ApplyDefaults sets all unset attributes that have default values to their`,
+				`// default values.`,
+				`func (x *B) ApplyDefaults() {`,
+				`	if x.C == nil {`,
+				`		v := 5`,
+				`		x.C = &v`,
+				`	}`,
+				`	if x.D == nil {`,
+				`		v := true`,
+				`		x.D = &v`,
+				`	}`,
+				`}`,
+			),
+		},
+		{
+			name: "attr_defaults_not_optional",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithAttrDefaults(map[string]string{
+					"b/@c": "5",
+				}),
+				xmlstruct.WithNamedTypes(true),
+				xmlstruct.WithUsePointersForOptionalFields(false),
+			},
+			xmlStrs: []string{
+				`<a><b c="0"/><b/></a>`,
+			},
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`import "encoding/xml"`,
+				``,
+				`type A struct {`,
+				"\tB []B `xml:\"b\"`",
+				`}`,
+				``,
+				`type B struct {`,
+				"\tC int `xml:\"c,attr\"`",
+				`}`,
+				``,
+				`// UnmarshalXML implements encoding/xml.Unmarshaler. It sets attributes that`,
+				`// have default values to their default values before decoding.`,
+				`func (x *B) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {`,
+				`	type plain B`,
+				`	x.C = 5`,
+				`	return d.DecodeElement((*plain)(x), &start)`,
+				`}`,
+			),
+		},
+		{
+			name: "attr_defaults_not_optional_anonymous",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithAttrDefaults(map[string]string{
+					"b/@c": "5",
+				}),
+				xmlstruct.WithPointerPolicy(xmlstruct.PointerPolicy{}),
+			},
+			xmlStrs: []string{
+				`<a><b c="0"/></a>`,
+			},
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`import "encoding/xml"`,
+				``,
+				`type A struct {`,
+				`	B struct {`,
+				"\t\tC int `xml:\"c,attr\"`",
+				"\t} `xml:\"b\"`",
+				`}`,
+				``,
+				`// UnmarshalXML implements encoding/xml.Unmarshaler. It sets attributes that`,
+				`// have default values to their default values before decoding.`,
+				`func (x *A) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {`,
+				`	type plain A`,
+				`	x.B.C = 5`,
+				`	return d.DecodeElement((*plain)(x), &start)`,
+				`}`,
+			),
+		},
+		{
+			name: "attr_defaults_not_optional_repeated_anonymous",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithAttrDefaults(map[string]string{
+					"b/@c": "5",
+				}),
+				xmlstruct.WithUsePointersForOptionalFields(false),
+			},
+			xmlStrs: []string{
+				`<a><b c="0"/><b/></a>`,
+			},
+			expectedErr: "a/b: defaults in repeated or optional anonymous structs require pointers or named types",
+		},
+		{
+			name: "attr_defaults_paths_and_namespaces",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithAttrDefaults(map[string]string{
+					"b/@c":         "8",
+					"{urn:z}b/@c":  "7",
+					"/a/x/b/@d":    "6",
+					"/a/y/b/@d":    "5",
+					"/a/y/b/@none": "4",
+				}),
+				xmlstruct.WithNameFunc(func(name xml.Name) xml.Name {
+					return name
+				}),
+				xmlstruct.WithUsePointersForOptionalFields(false),
+			},
+			xmlStrs: []string{
+				`<a xmlns:z="urn:z"><x><z:b c="1" d="1"/></x><y><b c="2" d="2"/></y></a>`,
+			},
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`import "encoding/xml"`,
+				``,
+				`type A struct {`,
+				`	X struct {`,
+				`		B struct {`,
+				"\t\t\tC int `xml:\"c,attr\"`",
+				"\t\t\tD int `xml:\"d,attr\"`",
+				"\t\t} `xml:\"b\"`",
+				"\t} `xml:\"x\"`",
+				`	Y struct {`,
+				`		B struct {`,
+				"\t\t\tC int `xml:\"c,attr\"`",
+				"\t\t\tD int `xml:\"d,attr\"`",
+				"\t\t} `xml:\"b\"`",
+				"\t} `xml:\"y\"`",
+				`}`,
+				``,
+				`// UnmarshalXML implements encoding/xml.Unmarshaler. It sets attributes that`,
+				`// have default values to their default values before decoding.`,
+				`func (x *A) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {`,
+				`	type plain A`,
+				`	x.X.B.C = 7`,
+				`	x.X.B.D = 6`,
+				`	x.Y.B.C = 8`,
+				`	x.Y.B.D = 5`,
+				`	return d.DecodeElement((*plain)(x), &start)`,
+				`}`,
+			),
+		},
+		{
+			name: "attr_defaults_invalid",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithAttrDefaults(map[string]string{
+					"b/@c": "x",
+				}),
+			},
+			xmlStrs: []string{
+				`<a><b c="1"/><b/></a>`,
+			},
+			expectedErr: `a/b/@c: strconv.ParseInt: parsing "x": invalid syntax`,
+		},
+		{
+			name: "value_examples",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithValueExamples(3),
+			},
+			xmlStrs: []string{
+				`<a><b c="item10">z</b><d>3</d></a>`,
+				`<a><b c="item2">y</b><d>1</d></a>`,
+				`<a><b c="Item1">x<e/></b><d>2</d></a>`,
+				`<a><b c="item1">w</b><d>2</d></a>`,
+			},
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"type A struct {",
+				"\tB struct {",
+				"\t\tC        string    `xml:\"c,attr\"`    // Examples: \"Item1\", \"item1\", \"item10\"",
+				"\t\tCharData string    `xml:\",chardata\"` // Examples: \"w\", \"x\", \"y\"",
+				"\t\tE        *struct{} `xml:\"e\"`",
+				"\t} `xml:\"b\"`",
+				"\tD int `xml:\"d\"` // Examples: \"1\", \"2\", \"3\"",
+				"}",
+			),
+		},
+		{
+			name: "value_examples_collation",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithValueExamples(3),
+				xmlstruct.WithValueCollation(xmlstruct.ValueCollationCaseInsensitive | xmlstruct.ValueCollationNumeric),
+			},
+			xmlStrs: []string{
+				`<a><b c="item10"/></a>`,
+				`<a><b c="item2"/></a>`,
+				`<a><b c="item1"/></a>`,
+				`<a><b c="Item1"/></a>`,
+				`<a><b c="item3"/></a>`,
+			},
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"type A struct {",
+				"\tB struct {",
+				"\t\tC string `xml:\"c,attr\"` // Examples: \"Item1\", \"item2\", \"item3\"",
+				"\t} `xml:\"b\"`",
+				"}",
+			),
+		},
+		{
+			name: "value_sanitizer",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithValueExamples(2),
+				xmlstruct.WithValueSanitizer(func(path, value string) string {
+					switch path {
+					case "a/b/@ssn", "a/email":
+						return "redacted"
+					}
+					return value
+				}),
+			},
+			xmlStrs: []string{
+				`<a><b id="1" ssn="123-45-6789"/><email>a@example.com</email></a>`,
+				`<a><b id="2" ssn="987-65-4321"/><email>b@example.com</email></a>`,
+			},
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"type A struct {",
+				"\tB struct {",
+				"\t\tID  int    `xml:\"id,attr\"`  // Examples: \"1\", \"2\"",
+				"\t\tSsn string `xml:\"ssn,attr\"` // Examples: \"redacted\"",
+				"\t} `xml:\"b\"`",
+				"\tEmail string `xml:\"email\"` // Examples: \"redacted\"",
+				"}",
+			),
+		},
+		{
+			name: "example_tags",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithExampleTags(true),
+				xmlstruct.WithExampleRedactFunc(func(path, example string) string {
+					switch path {
+					case "a/b/@secret":
+						return ""
+					case "a/b/@token":
+						return "xxx"
+					}
+					return example
+				}),
+			},
+			xmlStrs: []string{
+				`<a><b c="item2" secret="s" token="k">y<f/></b><d>3</d><e>"quoted"</e></a>`,
+				`<a><b c="item10" secret="s" token="k">x</b><d>10</d><e>"quoted"</e></a>`,
+			},
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"type A struct {",
+				"\tB struct {",
+				"\t\tC        string    `xml:\"c,attr\" example:\"item10\"`",
+				"\t\tSecret   string    `xml:\"secret,attr\"`",
+				"\t\tToken    string    `xml:\"token,attr\" example:\"xxx\"`",
+				"\t\tCharData string    `xml:\",chardata\" example:\"x\"`",
+				"\t\tF        *struct{} `xml:\"f\"`",
+				"\t} `xml:\"b\"`",
+				"\tD int    `xml:\"d\" example:\"10\"`",
+				"\tE string `xml:\"e\" example:\"\\\"quoted\\\"\"`",
+				"}",
+			),
+		},
+		{
+			name: "unusual_characters",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithExportNameFunc(xmlstruct.TitleFirstRuneExportNameFunc),
+			},
+			xmlStr: `<a><foo.bar>1</foo.bar><x-y z-w="2"/></a>`,
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"type A struct {",
+				"\tFoo_bar int `xml:\"foo.bar\"`",
+				"\tX_y     struct {",
+				"\t\tZ_w int `xml:\"z-w,attr\"`",
+				"\t} `xml:\"x-y\"`",
+				"}",
+			),
+		},
+		{
+			name:   "reserved_identifiers",
+			xmlStr: `<a><type>1</type><func/><_1 range="x"/><x-2/></a>`,
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"type A struct {",
+				"\tFunc struct{} `xml:\"func\"`",
+				"\tType int      `xml:\"type\"`",
+				"\tX_1  struct {",
+				"\t\tRange string `xml:\"range,attr\"`",
+				"\t} `xml:\"_1\"`",
+				"\tX_2 struct{} `xml:\"x-2\"`",
+				"}",
+			),
+		},
+		{
+			name: "reserved_identifiers_unexported",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithExportTypeNameFunc(xmlstruct.DefaultUnexportNameFunc),
+				xmlstruct.WithNamedTypes(true),
+			},
+			xmlStr: `<type><string a="1"/><range/><_1 b="x"/></type>`,
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"type xString struct {",
+				"\tA int `xml:\"a,attr\"`",
+				"}",
+				"",
+				"type xType struct {",
+				"\tRange  struct{} `xml:\"range\"`",
+				"\tString xString  `xml:\"string\"`",
+				"\tX_1    x_1      `xml:\"_1\"`",
+				"}",
+				"",
+				"type x_1 struct {",
+				"\tB string `xml:\"b,attr\"`",
+				"}",
+			),
+		},
+		{
+			name: "reserved_identifiers_suffix",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithExportTypeNameFunc(xmlstruct.DefaultUnexportNameFunc),
+				xmlstruct.WithSanitizeIdentifierFunc(xmlstruct.SuffixSanitizeIdentifierFunc("_")),
+			},
+			xmlStr: `<type><a/></type>`,
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"type type_ struct {",
+				"\tA struct{} `xml:\"a\"`",
+				"}",
+			),
+		},
+		{
+			name: "map_elements",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithMapElements(map[string]string{
+					"property": "name",
+				}),
+			},
+			xmlStr: `<a><property name="x">1</property><property name="y">2</property></a>`,
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"import (",
+				"\t\"encoding/xml\"",
+				"\t\"sort\"",
+				")",
+				"",
+				"type A struct {",
+				"\tProperty PropertyMap `xml:\"property\"`",
+				"}",
+				"",
+				"// PropertyMap is a map of property elements' chardata keyed by their name attribute.",
+				"type PropertyMap map[string]string",
+				"",
+				"// UnmarshalXML implements encoding/xml.Unmarshaler.",
+				"func (m *PropertyMap) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {",
+				"\tvar entry struct {",
+				"\t\tKey   string `xml:\"name,attr\"`",
+				"\t\tValue string `xml:\",chardata\"`",
+				"\t}",
+				"\tif err := d.DecodeElement(&entry, &start); err != nil {",
+				"\t\treturn err",
+				"\t}",
+				"\tif *m == nil {",
+				"\t\t*m = make(PropertyMap)",
+				"\t}",
+				"\t(*m)[entry.Key] = entry.Value",
+				"\treturn nil",
+				"}",
+				"",
+				"// MarshalXML implements encoding/xml.Marshaler. Entries are marshaled in",
+				"// key order.",
+				"func (m PropertyMap) MarshalXML(e *xml.Encoder, start xml.StartElement) error {",
+				"\tkeys := make([]string, 0, len(m))",
+				"\tfor key := range m {",
+				"\t\tkeys = append(keys, key)",
+				"\t}",
+				"\tsort.Strings(keys)",
+				"\tfor _, key := range keys {",
+				"\t\tentry := struct {",
+				"\t\t\tKey   string `xml:\"name,attr\"`",
+				"\t\t\tValue string `xml:\",chardata\"`",
+				"\t\t}{",
+				"\t\t\tKey:   key,",
+				"\t\t\tValue: m[key],",
+				"\t\t}",
+				"\t\tif err := e.EncodeElement(entry, start); err != nil {",
+				"\t\t\treturn err",
+				"\t\t}",
+				"\t}",
+				"\treturn nil",
+				"}",
+			),
+		},
+		{
+			name: "map_elements_other_attributes",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithMapElements(map[string]string{
+					"entry": "key",
+				}),
+			},
+			xmlStr:      `<a><entry key="x" type="int">1</entry></a>`,
+			expectedErr: "entry/@type: map element has other attributes",
+		},
+		{
+			name: "preserve_leading_zeros",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithPreserveLeadingZeros(true),
+			},
+			xmlStrs: []string{
+				`<a><b>1</b><c>0.5</c><d>0</d><e>-1</e></a>`,
+				`<a><b>007</b><c>00.5</c><d>0</d><e>-01</e></a>`,
+			},
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"type A struct {",
+				"\tB string `xml:\"b\"`",
+				"\tC string `xml:\"c\"`",
+				"\tD int    `xml:\"d\"`",
+				"\tE string `xml:\"e\"`",
+				"}",
+			),
+		},
+		{
+			name: "preserve_leading_zeros_time",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithPreserveLeadingZeros(true),
+				xmlstruct.WithTimeLayout("15:04"),
+			},
+			xmlStrs: []string{
+				`<a><start>09:30</start><end>10:30</end></a>`,
+				`<a><start>08:00</start><end>07:45</end></a>`,
+			},
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				`import "time"`,
+				"",
+				"type A struct {",
+				"\tEnd   time.Time `xml:\"end\"`",
+				"\tStart time.Time `xml:\"start\"`",
+				"}",
+			),
+		},
+		{
+			name: "number_format",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithNumberFormat(xmlstruct.NumberFormat{
+					DecimalSeparator:   ',',
+					ThousandsSeparator: '.',
+					DisallowExponents:  true,
+				}),
+			},
+			xmlStrs: []string{
+				`<a><b>1.234,56</b><c>1.234</c><d>1e3</d><e>1.5</e></a>`,
+				`<a><b>7</b><c>12</c><d>1</d><e>2</e></a>`,
+			},
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"import (",
+				"\t\"strconv\"",
+				"\t\"strings\"",
+				")",
+				"",
+				"type A struct {",
+				"\tB LocalizedFloat64 `xml:\"b\"`",
+				"\tC LocalizedInt     `xml:\"c\"`",
+				"\tD string           `xml:\"d\"`",
+				"\tE string           `xml:\"e\"`",
+				"}",
+				"",
+				"// LocalizedFloat64 is a number of type float64 formatted with ',' as the decimal separator",
+				"// and '.' as the thousands separator.",
+				"type LocalizedFloat64 float64",
+				"",
+				"// UnmarshalText implements encoding.TextUnmarshaler.",
+				"func (x *LocalizedFloat64) UnmarshalText(text []byte) error {",
+				"\ts := strings.TrimSpace(string(text))",
+				"\ts = strings.ReplaceAll(s, \".\", \"\")",
+				"\ts = strings.Replace(s, \",\", \".\", 1)",
+				"\tv, err := strconv.ParseFloat(s, 64)",
+				"\tif err != nil {",
+				"\t\treturn err",
+				"\t}",
+				"\t*x = LocalizedFloat64(v)",
+				"\treturn nil",
+				"}",
+				"",
+				"// MarshalText implements encoding.TextMarshaler. Thousands are not",
+				"// separated.",
+				"func (x LocalizedFloat64) MarshalText() ([]byte, error) {",
+				"\treturn []byte(strings.Replace(strconv.FormatFloat(float64(x), 'f', -1, 64), \".\", \",\", 1)), nil",
+				"}",
+				"",
+				"// LocalizedInt is a number of type int formatted with ',' as the decimal separator",
+				"// and '.' as the thousands separator.",
+				"type LocalizedInt int",
+				"",
+				"// UnmarshalText implements encoding.TextUnmarshaler.",
+				"func (x *LocalizedInt) UnmarshalText(text []byte) error {",
+				"\ts := strings.TrimSpace(string(text))",
+				"\ts = strings.ReplaceAll(s, \".\", \"\")",
+				"\ts = strings.Replace(s, \",\", \".\", 1)",
+				"\tv, err := strconv.ParseInt(s, 10, 64)",
+				"\tif err != nil {",
+				"\t\treturn err",
+				"\t}",
+				"\t*x = LocalizedInt(v)",
+				"\treturn nil",
+				"}",
+				"",
+				"// MarshalText implements encoding.TextMarshaler. Thousands are not",
+				"// separated.",
+				"func (x LocalizedInt) MarshalText() ([]byte, error) {",
+				"\treturn []byte(strconv.FormatInt(int64(x), 10)), nil",
+				"}",
+			),
+		},
+		{
+			name: "import_mapping",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithImportMapping("github.com/shopspring/decimal", "decimal.Decimal", xmlstruct.MatchPath("item/@price")),
+				xmlstruct.WithImportMapping("github.com/google/uuid", "uuid.UUID", xmlstruct.MatchName(regexp.MustCompile(`^id$`))),
+				xmlstruct.WithImportMapping("example.com/money/v2", "money.Amount", xmlstruct.MatchPath("/order/total")),
+			},
+			xmlStr: `<order><id>1</id><item id="2" price="1.50"/><total>1.50</total></order>`,
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"import (",
+				"\tmoney \"example.com/money/v2\"",
+				"\t\"github.com/google/uuid\"",
+				"\t\"github.com/shopspring/decimal\"",
+				")",
+				"",
+				"type Order struct {",
+				"\tID   uuid.UUID `xml:\"id\"`",
+				"\tItem struct {",
+				"\t\tID    uuid.UUID       `xml:\"id,attr\"`",
+				"\t\tPrice decimal.Decimal `xml:\"price,attr\"`",
+				"\t} `xml:\"item\"`",
+				"\tTotal money.Amount `xml:\"total\"`",
+				"}",
+			),
+		},
+		{
+			name: "pointer_policy",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithNamedTypes(true),
+				xmlstruct.WithPointerPolicy(xmlstruct.PointerPolicy{
+					OptionalStructs: true,
+					RequiredStructs: true,
+				}),
+			},
+			xmlStrs: []string{
+				`<a><b><c>1</c></b><d><e/></d><d><e/></d><f x="1"/><s>x</s></a>`,
+				`<a><d><e/></d><f x="2"/></a>`,
+			},
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"type A struct {",
+				"\tB *B     `xml:\"b\"`",
+				"\tD []D    `xml:\"d\"`",
+				"\tF *F     `xml:\"f\"`",
+				"\tS string `xml:\"s\"`",
+				"}",
+				"",
+				"type B struct {",
+				"\tC int `xml:\"c\"`",
+				"}",
+				"",
+				"type D struct {",
+				"\tE struct{} `xml:\"e\"`",
+				"}",
+				"",
+				"type F struct {",
+				"\tX int `xml:\"x,attr\"`",
+				"}",
+			),
+		},
+		{
+			name: "repeated_attrs",
+			xmlStrs: []string{
+				`<a xmlns:x="urn:x" xmlns:y="urn:y"><b x:c="1" y:c="2"/></a>`,
+				`<a><b/></a>`,
+			},
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"type A struct {",
+				"\tB struct {",
+				"\t\tC []*int `xml:\"c,attr\"`",
+				"\t} `xml:\"b\"`",
+				"}",
+			),
+		},
+		{
+			name: "pointer_policy_repeated_attrs",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithPointerPolicy(xmlstruct.PointerPolicy{
+					OptionalScalars: true,
+				}),
+			},
+			xmlStrs: []string{
+				`<a xmlns:x="urn:x" xmlns:y="urn:y"><b x:c="1" y:c="2"/></a>`,
+				`<a><b/></a>`,
+			},
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"type A struct {",
+				"\tB struct {",
+				"\t\tC []int `xml:\"c,attr\"`",
+				"\t} `xml:\"b\"`",
+				"}",
+			),
+		},
+		{
+			name: "pointer_policy_repeated_elements",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithPointerPolicy(xmlstruct.PointerPolicy{
+					RepeatedElements: true,
+				}),
+			},
+			xmlStr: `<a><b>1</b><b>2</b><c><d/></c><c><d/></c></a>`,
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"type A struct {",
+				"\tB []*int `xml:\"b\"`",
+				"\tC []*struct {",
+				"\t\tD struct{} `xml:\"d\"`",
+				"\t} `xml:\"c\"`",
+				"}",
+			),
+		},
+		{
+			name: "optional_scalar_wrapper_package",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithPointerPolicy(xmlstruct.PointerPolicy{
+					OptionalScalarWrapper: xmlstruct.OptionalScalarWrapperPackage,
+				}),
+				xmlstruct.WithAttrDefaults(map[string]string{
+					"b/@c": "2",
+				}),
+			},
+			xmlStrs: []string{
+				`<a><b c="1"/><d>x</d></a>`,
+				`<a><b/></a>`,
+			},
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"import \"github.com/twpayne/go-xmlstruct/optional\"",
+				"",
+				"type A struct {",
+				"\tB struct {",
+				"\t\tC optional.Optional[int] `xml:\"c,attr\"`",
+				"\t} `xml:\"b\"`",
+				"\tD optional.Optional[string] `xml:\"d\"`",
+				"}",
+				"",
+				"// ApplyDefaults sets all unset attributes that have default values to their",
+				"// default values.",
+				"func (x *A) ApplyDefaults() {",
+				"\tif !x.B.C.Valid {",
+				"\t\tx.B.C.Value = 2",
+				"\t\tx.B.C.Valid = true",
+				"\t}",
+				"}",
+			),
+		},
+		{
+			name: "optional_scalar_wrapper",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithPointerPolicy(xmlstruct.PointerPolicy{
+					OptionalScalarWrapper: xmlstruct.OptionalScalarWrapperSQLNull,
+				}),
+			},
+			xmlStrs: []string{
+				`<a><b c="1"/><d>x</d></a>`,
+				`<a><b/></a>`,
+			},
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"import (",
+				"\t\"bytes\"",
+				"\t\"database/sql\"",
+				"\t\"encoding/xml\"",
+				")",
+				"",
+				"type A struct {",
+				"\tB struct {",
+				"\t\tC Null[int] `xml:\"c,attr\"`",
+				"\t} `xml:\"b\"`",
+				"\tD Null[string] `xml:\"d\"`",
+				"}",
+				"",
+				"// Null is an optional XML attribute or element value of type T that can also",
+				"// be used with database/sql.",
+				"type Null[T any] struct {",
+				"\tsql.Null[T]",
+				"}",
+				"",
+				"// UnmarshalXML implements encoding/xml.Unmarshaler.",
+				"func (o *Null[T]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {",
+				"\tif err := d.DecodeElement(&o.V, &start); err != nil {",
+				"\t\treturn err",
+				"\t}",
+				"\to.Valid = true",
+				"\treturn nil",
+				"}",
+				"",
+				"// UnmarshalXMLAttr implements encoding/xml.UnmarshalerAttr. The value is",
+				"// unmarshaled by encoding/xml, as if it were not wrapped.",
+				"func (o *Null[T]) UnmarshalXMLAttr(attr xml.Attr) error {",
+				"\tbuffer := &bytes.Buffer{}",
+				"\tbuffer.WriteString(`<v v=\"`)",
+				"\tif err := xml.EscapeText(buffer, []byte(attr.Value)); err != nil {",
+				"\t\treturn err",
+				"\t}",
+				"\tbuffer.WriteString(`\"/>`)",
+				"\tvar v struct {",
+				"\t\tValue T `xml:\"v,attr\"`",
+				"\t}",
+				"\tif err := xml.Unmarshal(buffer.Bytes(), &v); err != nil {",
+				"\t\treturn err",
+				"\t}",
+				"\to.V, o.Valid = v.Value, true",
+				"\treturn nil",
+				"}",
+				"",
+				"// MarshalXML implements encoding/xml.Marshaler. Invalid values are omitted.",
+				"func (o Null[T]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {",
+				"\tif !o.Valid {",
+				"\t\treturn nil",
+				"\t}",
+				"\treturn e.EncodeElement(o.V, start)",
+				"}",
+				"",
+				"// MarshalXMLAttr implements encoding/xml.MarshalerAttr. Invalid values are",
+				"// omitted. The value is marshaled by encoding/xml, as if it were not wrapped.",
+				"func (o Null[T]) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {",
+				"\tif !o.Valid {",
+				"\t\treturn xml.Attr{}, nil",
+				"\t}",
+				"\tdata, err := xml.Marshal(struct {",
+				"\t\tXMLName xml.Name `xml:\"v\"`",
+				"\t\tValue   T        `xml:\"v,attr\"`",
+				"\t}{",
+				"\t\tValue: o.V,",
+				"\t})",
+				"\tif err != nil {",
+				"\t\treturn xml.Attr{}, err",
+				"\t}",
+				"\tvar v struct {",
+				"\t\tValue string `xml:\"v,attr\"`",
+				"\t}",
+				"\tif err := xml.Unmarshal(data, &v); err != nil {",
+				"\t\treturn xml.Attr{}, err",
+				"\t}",
+				"\treturn xml.Attr{Name: name, Value: v.Value}, nil",
+				"}",
+			),
+		},
+		{
+			name: "build_tags",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithBuildTags("linux", "a || b"),
+			},
+			xmlStr: `<a/>`,
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"//go:build linux && (a || b)",
+				"",
+				"package main",
+				"",
+				"type A struct{}",
+			),
+		},
+		{
+			name: "build_tags_without_package",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithBuildTags("linux"),
+				xmlstruct.WithPackageName(""),
+			},
+			xmlStr: `<a/>`,
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"//go:build linux",
+				"",
+				"type A struct{}",
+			),
+		},
+		{
+			name: "invalid_build_tag",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithBuildTags("linux &&"),
+			},
+			xmlStr:      `<a/>`,
+			expectedErr: "linux &&: invalid build tag: unexpected end of expression",
+		},
+		{
+			name: "any_content",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithAnyAttrs(xmlstruct.MatchPath("a/b")),
+				xmlstruct.WithAnyElements(xmlstruct.MatchPath("a/b")),
+			},
+			xmlStr: `<a><b x="1"><c/></b></a>`,
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`import "encoding/xml"`,
+				``,
+				`type A struct {`,
+				`	B struct {`,
+				"\t\tX        int          `xml:\"x,attr\"`",
+				"\t\tC        struct{}     `xml:\"c\"`",
+				"\t\tAnyAttrs []xml.Attr   `xml:\",any,attr\"`",
+				"\t\tAny      []AnyElement `xml:\",any\"`",
+				"\t} `xml:\"b\"`",
+				`}`,
+				``,
+				`// AnyElement is an element that was not observed, with its attributes and`,
+				`// undecoded contents.`,
+				`type AnyElement struct {`,
+				`	XMLName  xml.Name`,
+				"\tAttrs    []xml.Attr `xml:\",any,attr\"`",
+				"\tInnerXML string     `xml:\",innerxml\"`",
+				`}`,
+			),
+		},
+		{
+			name: "any_content_field_name_collision",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithAnyAttrs(xmlstruct.MatchPath("a/b")),
+				xmlstruct.WithAnyElements(xmlstruct.MatchPath("a/b")),
+				xmlstruct.WithDeepCopyMethods(true),
+			},
+			xmlStr: `<a><b anyAttrs="1"><any/></b></a>`,
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`import (`,
+				`	"encoding/xml"`,
+				`	"slices"`,
+				`)`,
+				``,
+				`type A struct {`,
+				`	B struct {`,
+				"\t\tAnyAttrs  int          `xml:\"anyAttrs,attr\"`",
+				"\t\tAny       struct{}     `xml:\"any\"`",
+				"\t\tAnyAttrs_ []xml.Attr   `xml:\",any,attr\"`",
+				"\t\tAny_      []AnyElement `xml:\",any\"`",
+				"\t} `xml:\"b\"`",
+				`}`,
+				``,
+				`// AnyElement is an element that was not observed, with its attributes and`,
+				`// undecoded contents.`,
+				`type AnyElement struct {`,
+				`	XMLName  xml.Name`,
+				"\tAttrs    []xml.Attr `xml:\",any,attr\"`",
+				"\tInnerXML string     `xml:\",innerxml\"`",
+				`}`,
+				``,
+				`// DeepCopyInto copies the receiver into out. in must be non-nil.`,
+				`func (in *A) DeepCopyInto(out *A) {`,
+				`	*out = *in`,
+				`	if in.B.AnyAttrs_ != nil {`,
+				`		out.B.AnyAttrs_ = slices.Clone(in.B.AnyAttrs_)`,
+				`	}`,
+				`	if in.B.Any_ != nil {`,
+				`		out.B.Any_ = slices.Clone(in.B.Any_)`,
+				`		for i := range in.B.Any_ {`,
+				`			out.B.Any_[i].Attrs = slices.Clone(in.B.Any_[i].Attrs)`,
+				`		}`,
+				`	}`,
+				`}`,
+				``,
+				`// DeepCopy returns a deep copy of the receiver.`,
+				`func (in *A) DeepCopy() *A {`,
+				`	if in == nil {`,
+				`		return nil`,
+				`	}`,
+				`	out := new(A)`,
+				`	in.DeepCopyInto(out)`,
+				`	return out`,
+				`}`,
+			),
+		},
+		{
+			name: "attr_map_threshold",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithAttrMapThreshold(2),
+				xmlstruct.WithNamedTypes(true),
+			},
+			xmlStr: `<a><b x="1" y="2" z="3"/><b w="4"/><c q="1"/></a>`,
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`import (`,
+				`	"encoding/xml"`,
+				`	"sort"`,
+				`)`,
+				``,
+				`type A struct {`,
+				"\tB []B `xml:\"b\"`",
+				"\tC C   `xml:\"c\"`",
+				`}`,
+				``,
+				`type B struct {`,
+				"\tAttrs AttrMap `xml:\",any,attr\"`",
+				`}`,
+				``,
+				`type C struct {`,
+				"\tQ int `xml:\"q,attr\"`",
+				`}`,
+				``,
+				`// AttrMap is a map of attribute values keyed by their local names.`,
+				`type AttrMap map[string]string`,
+				``,
+				`// UnmarshalXMLAttr implements encoding/xml.UnmarshalerAttr. Namespace`,
+				`// declarations are ignored.`,
+				`func (m *AttrMap) UnmarshalXMLAttr(attr xml.Attr) error {`,
+				`	if attr.Name.Space == "xmlns" || attr.Name.Space == "" && attr.Name.Local == "xmlns" {`,
+				`		return nil`,
+				`	}`,
+				`	if *m == nil {`,
+				`		*m = make(AttrMap)`,
+				`	}`,
+				`	(*m)[attr.Name.Local] = attr.Value`,
+				`	return nil`,
+				`}`,
+				``,
+				`// MarshalXMLAttr implements encoding/xml.MarshalerAttr. encoding/xml cannot`,
+				`// marshal a map as several attributes, so nothing is marshaled here. Instead,`,
+				`// named types with an AttrMap field marshal its attributes in their MarshalXML`,
+				`// method.`,
+				`func (m AttrMap) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {`,
+				`	return xml.Attr{}, nil`,
+				`}`,
+				``,
+				`// appendAttrs appends m's attributes, in key order, to attrs.`,
+				`func (m AttrMap) appendAttrs(attrs []xml.Attr) []xml.Attr {`,
+				`	keys := make([]string, 0, len(m))`,
+				`	for key := range m {`,
+				`		keys = append(keys, key)`,
+				`	}`,
+				`	sort.Strings(keys)`,
+				`	for _, key := range keys {`,
+				`		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: key}, Value: m[key]})`,
+				`	}`,
+				`	return attrs`,
+				`}`,
+				``,
+				`// MarshalXML implements encoding/xml.Marshaler. It marshals the attributes in`,
+				`// x.Attrs, which encoding/xml cannot marshal.`,
+				`func (x B) MarshalXML(e *xml.Encoder, start xml.StartElement) error {`,
+				`	type plain B`,
+				`	start.Attr = x.Attrs.appendAttrs(start.Attr)`,
+				`	return e.EncodeElement(plain(x), start)`,
+				`}`,
+			),
+		},
+		{
+			name: "type_conflict_policy_string",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithTopLevelAttributes(true),
+			},
+			xmlStrs: []string{
+				`<a x="1"/>`,
+				`<a x="2"/>`,
+				`<a x="n/a"/>`,
+			},
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`type A struct {`,
+				"\tX string `xml:\"x,attr\"`",
+				`}`,
+			),
+		},
+		{
+			name: "type_conflict_policy_keep",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithTopLevelAttributes(true),
+				xmlstruct.WithTypeConfidenceThreshold(0.5),
+				xmlstruct.WithTypeConflictPolicy(xmlstruct.TypeConflictPolicyKeep),
+			},
+			xmlStrs: []string{
+				`<a x="1"/>`,
+				`<a x="2"/>`,
+				`<a x="n/a"/>`,
+			},
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`type A struct {`,
+				"\tX int `xml:\"x,attr\"`",
+				`}`,
+			),
+		},
+		{
+			name: "type_conflict_policy_keep_below_threshold",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithTopLevelAttributes(true),
+				xmlstruct.WithTypeConflictPolicy(xmlstruct.TypeConflictPolicyKeep),
+			},
+			xmlStrs: []string{
+				`<a x="1"/>`,
+				`<a x="2"/>`,
+				`<a x="n/a"/>`,
+			},
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`type A struct {`,
+				"\tX string `xml:\"x,attr\"`",
+				`}`,
+			),
+		},
+		{
+			name: "type_conflict_policy_tolerant",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithTypeConfidenceThreshold(0.5),
+				xmlstruct.WithTypeConflictPolicy(xmlstruct.TypeConflictPolicyTolerant),
+			},
+			xmlStrs: []string{
+				`<a><b>1</b></a>`,
+				`<a><b>2</b></a>`,
+				`<a><b>n/a</b></a>`,
+			},
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`import (`,
+				`	"strconv"`,
+				`	"strings"`,
+				`)`,
+				``,
+				`type A struct {`,
+				"\tB TolerantInt `xml:\"b\"`",
+				`}`,
+				``,
+				`// TolerantInt is a value of type int that also accepts text that is not`,
+				`// a valid int, which is kept in Raw.`,
+				`type TolerantInt struct {`,
+				`	Value int`,
+				`	Raw   string`,
+				`}`,
+				``,
+				`// UnmarshalText implements encoding.TextUnmarshaler.`,
+				`func (x *TolerantInt) UnmarshalText(text []byte) error {`,
+				`	v, err := strconv.ParseInt(strings.TrimSpace(string(text)), 10, 64)`,
+				`	if err != nil {`,
+				`		*x = TolerantInt{Raw: string(text)}`,
+				`		return nil`,
+				`	}`,
+				`	*x = TolerantInt{Value: int(v)}`,
+				`	return nil`,
+				`}`,
+				``,
+				`// MarshalText implements encoding.TextMarshaler. Raw is marshaled if it is`,
+				`// set.`,
+				`func (x TolerantInt) MarshalText() ([]byte, error) {`,
+				`	if x.Raw != "" {`,
+				`		return []byte(x.Raw), nil`,
+				`	}`,
+				`	return []byte(strconv.FormatInt(int64(x.Value), 10)), nil`,
+				`}`,
+			),
+		},
+		{
+			name: "time_zone_policy_utc",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithTimeLayout("2006-01-02T15:04:05"),
+				xmlstruct.WithTimeZonePolicy(xmlstruct.TimeZonePolicyUTC),
+			},
+			xmlStr: `<a><b>2024-01-02T03:04:05</b></a>`,
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`import "time"`,
+				``,
+				`type A struct {`,
+				"\tB UTCTime `xml:\"b\"`",
+				`}`,
+				``,
+				`// UTCTime is a time formatted with the layout "2006-01-02T15:04:05", which does not`,
+				`// include a time zone offset, in UTC.`,
+				`type UTCTime struct {`,
+				`	time.Time`,
+				`}`,
+				``,
+				`// UnmarshalText implements encoding.TextUnmarshaler.`,
+				`func (x *UTCTime) UnmarshalText(text []byte) error {`,
+				`	t, err := time.ParseInLocation("2006-01-02T15:04:05", string(text), time.UTC)`,
+				`	if err != nil {`,
+				`		return err`,
+				`	}`,
+				`	x.Time = t`,
+				`	return nil`,
+				`}`,
+				``,
+				`// MarshalText implements encoding.TextMarshaler.`,
+				`func (x UTCTime) MarshalText() ([]byte, error) {`,
+				`	return []byte(x.Time.In(time.UTC).Format("2006-01-02T15:04:05")), nil`,
+				`}`,
+			),
+		},
+		{
+			name: "time_zone_policy_raw",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithTimeLayout("2006-01-02 15:04"),
+				xmlstruct.WithTimeZonePolicy(xmlstruct.TimeZonePolicyRaw),
+			},
+			xmlStr: `<a><b>2024-01-02 03:04</b></a>`,
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`import "time"`,
+				``,
+				`type A struct {`,
+				"\tB RawTime `xml:\"b\"`",
+				`}`,
+				``,
+				`// RawTime is a time formatted with the layout "2006-01-02 15:04", which does not`,
+				`// include a time zone offset, in UTC, with the original text in Raw.`,
+				`type RawTime struct {`,
+				`	Time time.Time`,
+				`	Raw  string`,
+				`}`,
+				``,
+				`// UnmarshalText implements encoding.TextUnmarshaler.`,
+				`func (x *RawTime) UnmarshalText(text []byte) error {`,
+				`	t, err := time.ParseInLocation("2006-01-02 15:04", string(text), time.UTC)`,
+				`	if err != nil {`,
+				`		return err`,
+				`	}`,
+				`	*x = RawTime{Time: t, Raw: string(text)}`,
+				`	return nil`,
+				`}`,
+				``,
+				`// MarshalText implements encoding.TextMarshaler. Raw is marshaled if it is`,
+				`// set.`,
+				`func (x RawTime) MarshalText() ([]byte, error) {`,
+				`	if x.Raw != "" {`,
+				`		return []byte(x.Raw), nil`,
+				`	}`,
+				`	return []byte(x.Time.In(time.UTC).Format("2006-01-02 15:04")), nil`,
+				`}`,
+			),
+		},
+		{
+			name: "time_zone_policy_layout_with_offset",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithTimeLayout("2006-01-02T15:04:05Z07:00"),
+				xmlstruct.WithTimeZonePolicy(xmlstruct.TimeZonePolicyLocal),
+			},
+			xmlStr: `<a><b>2024-01-02T03:04:05+01:00</b></a>`,
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`import "time"`,
+				``,
+				`type A struct {`,
+				"\tB time.Time `xml:\"b\"`",
+				`}`,
+			),
+		},
+		{
+			name: "min_occurrence_rate",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithMinOccurrenceRate(0.5),
+			},
+			xmlStrs: []string{
+				`<a><b>1</b></a>`,
+				`<a><b>2</b></a>`,
+				`<a><b>3</b><c><d/></c></a>`,
+				`<a><c/><e/></a>`,
+			},
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`import "encoding/xml"`,
+				``,
+				`type A struct {`,
+				"\tB *int `xml:\"b\"`",
+				`	C struct {`,
+				"\t\tAny []AnyElement `xml:\",any\"`",
+				"\t} `xml:\"c\"`",
+				"\tAny []AnyElement `xml:\",any\"`",
+				`}`,
+				``,
+				`// AnyElement is an element that was not observed, with its attributes and`,
+				`// undecoded contents.`,
+				`type AnyElement struct {`,
+				`	XMLName  xml.Name`,
+				"\tAttrs    []xml.Attr `xml:\",any,attr\"`",
+				"\tInnerXML string     `xml:\",innerxml\"`",
+				`}`,
+			),
+		},
+		{
+			name: "token_filters",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithTokenFilters(
+					func(token xml.Token) xml.Token {
+						switch token := token.(type) {
+						case xml.StartElement:
+							if token.Name.Local == "Envelope" {
+								return nil
+							}
+						case xml.EndElement:
+							if token.Name.Local == "Envelope" {
+								return nil
+							}
+						}
+						return token
+					},
+					xmlstruct.RenameNamespace("urn:old", "urn:new"),
+					xmlstruct.LowerCaseNames(),
+				),
+				xmlstruct.WithElementFilter(func(_ []xml.Name, startElement xml.StartElement) bool {
+					return startElement.Name.Space == "urn:new"
+				}),
+				xmlstruct.WithTopLevelAttributes(true),
+			},
+			xmlStr: `<Envelope><A xmlns="urn:old" X="1"><B>2</B><C xmlns="urn:other"/></A></Envelope>`,
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`type A struct {`,
+				"\tX     int    `xml:\"x,attr\"`",
+				"\tXmlns string `xml:\"xmlns,attr\"`",
+				"\tB     int    `xml:\"b\"`",
+				`}`,
+			),
+		},
+		{
+			name: "preserve_whitespace_char_data",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithPreserveWhitespaceCharData(true),
+			},
+			xmlStr: joinLines(
+				`<a>`,
+				`  <b>`,
+				`    <sep> </sep>`,
+				`  </b>`,
+				`</a>`,
+			),
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`type A struct {`,
+				`	B struct {`,
+				"\t\tSep string `xml:\"sep\"`",
+				"\t} `xml:\"b\"`",
+				`}`,
+			),
+		},
+		{
+			name: "root_registry",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithRootRegistry(true),
+			},
+			xmlStrs: []string{
+				`<order><id>1</id></order>`,
+				`<invoice>2</invoice>`,
+			},
+			expectedStr: joinLines(
+				xmlstruct.DefaultHeader,
+				``,
+				`package main`,
+				``,
+				`import (`,
+				`	"encoding/xml"`,
+				`	"fmt"`,
+				`	"io"`,
+				`)`,
+				``,
+				`type Invoice int`,
+				``,
+				`type Order struct {`,
+				"\tID int `xml:\"id\"`",
+				`}`,
+				``,
+				`// Roots maps the names of top level elements to functions that return`,
+				`// pointers to new values of their types.`,
+				`var Roots = map[xml.Name]func() any{`,
+				`	{Space: "", Local: "invoice"}: func() any { return new(Invoice) },`,
+				`	{Space: "", Local: "order"}:   func() any { return new(Order) },`,
+				`}`,
+				``,
+				`// DecodeAny returns a pointer to a new value of the type of the top level`,
+				`// element of the document read from r, decoded from r. Elements are looked`,
+				`// up in Roots by their name, and then by their local name only.`,
+				`func DecodeAny(r io.Reader) (any, error) {`,
+				`	decoder := xml.NewDecoder(r)`,
+				`	for {`,
+				`		token, err := decoder.Token()`,
+				`		if err != nil {`,
+				`			return nil, err`,
+				`		}`,
+				`		startElement, ok := token.(xml.StartElement)`,
+				`		if !ok {`,
+				`			continue`,
+				`		}`,
+				`		newRoot, ok := Roots[startElement.Name]`,
+				`		if !ok {`,
+				`			newRoot, ok = Roots[xml.Name{Local: startElement.Name.Local}]`,
+				`		}`,
+				`		if !ok {`,
+				`			return nil, fmt.Errorf("%s: unknown top level element", startElement.Name.Local)`,
+				`		}`,
+				`		v := newRoot()`,
+				`		if err := decoder.DecodeElement(v, &startElement); err != nil {`,
+				`			return nil, err`,
+				`		}`,
+				`		return v, nil`,
+				`	}`,
+				`}`,
+			),
+		},
+		{
+			name: "ast_hook",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithASTHook(func(file *ast.File) error {
+					for _, decl := range file.Decls {
+						genDecl, ok := decl.(*ast.GenDecl)
+						if !ok || genDecl.Tok != token.TYPE {
+							continue
+						}
+						for _, spec := range genDecl.Specs {
+							typeSpec := spec.(*ast.TypeSpec)
+							typeSpec.Name.Name = "Feed" + typeSpec.Name.Name
+						}
+					}
+					return nil
+				}),
+				xmlstruct.WithASTHook(func(file *ast.File) error {
+					file.Decls = append(file.Decls, &ast.FuncDecl{
+						Recv: &ast.FieldList{
+							List: []*ast.Field{
+								{Type: ast.NewIdent("FeedA")},
+							},
+						},
+						Name: ast.NewIdent("Valid"),
+						Type: &ast.FuncType{
+							Results: &ast.FieldList{
+								List: []*ast.Field{
+									{Type: ast.NewIdent("bool")},
+								},
+							},
+						},
+						Body: &ast.BlockStmt{
+							List: []ast.Stmt{
+								&ast.ReturnStmt{
+									Results: []ast.Expr{ast.NewIdent("true")},
+								},
+							},
+						},
+					})
+					return nil
+				}),
+			},
+			xmlStr: `<a><b>c</b></a>`,
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"type FeedA struct {",
+				"\tB string `xml:\"b\"`",
+				"}",
+				"",
+				"func (FeedA) Valid() bool {",
+				"\treturn true",
+				"}",
+			),
+		},
+		{
+			name: "ast_hook_error",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithASTHook(func(*ast.File) error {
+					return errors.New("hook failed")
+				}),
+			},
+			xmlStr:      `<a/>`,
+			expectedErr: "hook failed",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			generator := xmlstruct.NewGenerator(tc.options...)
+			if tc.xmlStr != "" {
+				assert.NoError(t, generator.ObserveReader(strings.NewReader(tc.xmlStr)))
+			}
+			for _, xmlStr := range tc.xmlStrs {
+				assert.NoError(t, generator.ObserveReader(strings.NewReader(xmlStr)))
+			}
+			actual, err := generator.Generate()
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedStr, string(actual))
+			}
+		})
+	}
+}
+
+func TestObserveElement(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name        string
+		options     []xmlstruct.GeneratorOption
+		xmlStr      string
+		path        []string
+		charData    string
+		attrs       []xml.Attr
+		expectedStr string
+		expectedErr string
+	}{
+		{
+			name:     "new_document",
+			path:     []string{"a", "b"},
+			charData: "1",
+			attrs: []xml.Attr{
+				{Name: xml.Name{Local: "c"}, Value: "true"},
+			},
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"type A struct {",
+				"\tB struct {",
+				"\t\tC        bool `xml:\"c,attr\"`",
+				"\t\tCharData int  `xml:\",chardata\"`",
+				"\t} `xml:\"b\"`",
+				"}",
+			),
+		},
+		{
+			name:   "optional_child",
+			xmlStr: `<a><b>x</b></a>`,
+			path:   []string{"a", "c", "d"},
+			attrs: []xml.Attr{
+				{Name: xml.Name{Local: "e"}, Value: "1"},
+			},
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"type A struct {",
+				"\tB string `xml:\"b\"`",
+				"\tC *struct {",
+				"\t\tD struct {",
+				"\t\t\tE int `xml:\"e,attr\"`",
+				"\t\t} `xml:\"d\"`",
+				"\t} `xml:\"c\"`",
+				"}",
+			),
+		},
+		{
+			name:   "optional_attr",
+			xmlStr: `<a><b c="1"/></a>`,
+			path:   []string{"a", "b"},
+			attrs: []xml.Attr{
+				{Name: xml.Name{Local: "d"}, Value: "x"},
+			},
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"type A struct {",
+				"\tB struct {",
+				"\t\tC int     `xml:\"c,attr\"`",
+				"\t\tD *string `xml:\"d,attr\"`",
+				"\t} `xml:\"b\"`",
+				"}",
+			),
+		},
+		{
+			name:   "named_types",
+			xmlStr: `<a><b c="1"/></a>`,
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithNamedTypes(true),
+			},
+			path: []string{"a", "d", "b"},
+			attrs: []xml.Attr{
+				{Name: xml.Name{Local: "e"}, Value: "x"},
+			},
+			expectedStr: joinLines(
+				"// Code generated by go-xmlstruct. DO NOT EDIT.",
+				"",
+				"package main",
+				"",
+				"type A struct {",
+				"\tB B  `xml:\"b\"`",
+				"\tD *D `xml:\"d\"`",
+				"}",
+				"",
+				"type B struct {",
+				"\tC int     `xml:\"c,attr\"`",
+				"\tE *string `xml:\"e,attr\"`",
+				"}",
+				"",
+				"type D struct {",
+				"\tB B `xml:\"b\"`",
+				"}",
+			),
+		},
+		{
+			name:        "empty_path",
+			expectedErr: "empty path",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			generator := xmlstruct.NewGenerator(tc.options...)
+			if tc.xmlStr != "" {
+				assert.NoError(t, generator.ObserveReader(strings.NewReader(tc.xmlStr)))
+			}
+			path := make([]xml.Name, 0, len(tc.path))
+			for _, local := range tc.path {
+				path = append(path, xml.Name{Local: local})
+			}
+			err := generator.ObserveElement(path, tc.charData, tc.attrs)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			actual, err := generator.Generate()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStr, string(actual))
+		})
+	}
+}
+
+func joinLines(lines ...string) string {
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func TestObserveFragments(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithNamedRoot(true),
+	)
+	assert.NoError(t, generator.ObserveFragments(strings.NewReader(joinLines(
+		`<?xml version="1.0"?>`,
+		`<a><b>1</b></a>`,
+		`<?xml version="1.0"?>`,
+		`<a><b>2</b></a>`,
+		`<c>x</c>`,
+	))))
+	actual, err := generator.Generate()
+	assert.NoError(t, err)
+	assert.Equal(t, joinLines(
+		"// Code generated by go-xmlstruct. DO NOT EDIT.",
+		"",
+		"package main",
+		"",
+		`import "encoding/xml"`,
+		"",
+		"type A struct {",
+		"\tXMLName xml.Name `xml:\"a\"`",
+		"\tB       int      `xml:\"b\"`",
+		"}",
+		"",
+		"type C struct {",
+		"\tXMLName  xml.Name `xml:\"c\"`",
+		"\tCharData string   `xml:\",chardata\"`",
+		"}",
+	), string(actual))
+
+	assert.EqualError(t, generator.ObserveFragments(strings.NewReader(`<a/><a><b></a>`)), "offset 14: fragment 1: XML syntax error on line 1: element <b> closed by </a>")
+}
+
+func TestCheckReader(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithEntities(xml.HTMLEntity),
+	)
+	assert.NoError(t, generator.CheckReader(strings.NewReader(`<a>&nbsp;</a>`)))
+	assert.EqualError(t, generator.CheckReader(strings.NewReader(`<a><b>`)), "offset 6: XML syntax error on line 1: unexpected EOF")
+	assert.Equal(t, xmlstruct.NewGenerator().Fingerprint(), generator.Fingerprint())
+}
+
+func TestGenerateTo(t *testing.T) {
+	t.Parallel()
+
+	for _, options := range [][]xmlstruct.GeneratorOption{
+		{xmlstruct.WithFormatSource(false)},
+		{xmlstruct.WithFormatSource(true)},
+		{xmlstruct.WithFormatSource(false), xmlstruct.WithImports(false)},
+	} {
+		generator := xmlstruct.NewGenerator(options...)
+		assert.NoError(t, generator.ObserveReader(strings.NewReader(`<a b="1"><c>2006-01-02T15:04:05Z</c></a>`)))
+
+		expected, err := generator.Generate()
+		assert.NoError(t, err)
+
+		actual := &strings.Builder{}
+		assert.NoError(t, generator.GenerateTo(actual))
+		assert.Equal(t, string(expected), actual.String())
+
+		writtenTo := &strings.Builder{}
+		n, err := generator.WriteTo(writtenTo)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len(expected)), n)
+		assert.Equal(t, string(expected), writtenTo.String())
+	}
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithFormatSource(false),
+		xmlstruct.WithImports(false),
+	)
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(`<a b="1"/>`)))
+	assert.Equal(t, errWrite, generator.GenerateTo(errorWriter{}))
+}
+
+func TestGenerateToIncremental(t *testing.T) {
+	t.Parallel()
+
+	xmlBuilder := &strings.Builder{}
+	xmlBuilder.WriteString("<a>")
+	for i := range 1000 {
+		fmt.Fprintf(xmlBuilder, "<b%d><c>1</c></b%d>", i, i)
+	}
+	xmlBuilder.WriteString("</a>")
+
+	for _, tc := range []struct {
+		name        string
+		options     []xmlstruct.GeneratorOption
+		incremental bool
+	}{
+		{
+			name: "default",
+		},
+		{
+			name: "streaming",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithFormatSource(false),
+				xmlstruct.WithImports(false),
+				xmlstruct.WithPackageName("a"),
+			},
+			incremental: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			options := append([]xmlstruct.GeneratorOption{xmlstruct.WithNamedTypes(true)}, tc.options...)
+			generator := xmlstruct.NewGenerator(options...)
+			assert.NoError(t, generator.ObserveReader(strings.NewReader(xmlBuilder.String())))
+
+			writer := &recordingWriter{}
+			assert.NoError(t, generator.GenerateTo(writer))
+			expected, err := generator.Generate()
+			assert.NoError(t, err)
+			assert.Equal(t, string(expected), writer.String())
+			if tc.incremental {
+				assert.True(t, len(writer.writeLens) > 1)
+				assert.True(t, slices.Max(writer.writeLens) <= 4096)
+			} else {
+				assert.Equal(t, []int{len(expected)}, writer.writeLens)
+			}
+		})
+	}
+}
+
+var errWrite = errors.New("write failed")
+
+// An errorWriter is an io.Writer that always fails.
+type errorWriter struct{}
+
+func (errorWriter) Write([]byte) (int, error) {
+	return 0, errWrite
+}
+
+// A recordingWriter is an io.Writer that records the length of each write.
+type recordingWriter struct {
+	builder   strings.Builder
+	writeLens []int
+}
+
+func (w *recordingWriter) String() string {
+	return w.builder.String()
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.writeLens = append(w.writeLens, len(p))
+	return w.builder.Write(p)
 }
@@ -5,10 +5,10 @@ go 1.22
 require (
 	github.com/alecthomas/assert/v2 v2.4.0
 	golang.org/x/net v0.24.0
+	golang.org/x/text v0.14.0
 )
 
 require (
 	github.com/alecthomas/repr v0.3.0 // indirect
 	github.com/hexops/gotextdiff v1.0.3 // indirect
-	golang.org/x/text v0.14.0 // indirect
 )
@@ -0,0 +1,390 @@
+package xmlstruct
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"maps"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A goSourceObserver seeds a Generator's model from the struct types in a Go
+// source file.
+type goSourceObserver struct {
+	g           *Generator
+	options     observeOptions
+	structTypes map[string]*ast.StructType
+	visiting    map[*ast.StructType]bool
+}
+
+// ObserveGoFile seeds the model from the struct types in the Go source file
+// name. See ObserveGoSource.
+func (g *Generator) ObserveGoFile(name string) error {
+	src, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.observeGoSource(name, src); err != nil {
+		return err
+	}
+	hash := sha256.Sum256(src)
+	g.inputs = append(g.inputs, MetadataInput{
+		Name:   name,
+		SHA256: hex.EncodeToString(hash[:]),
+	})
+	return g.checkpoint()
+}
+
+// ObserveGoSource seeds the model from the struct types in src, the Go source
+// file filename, for example types that were generated earlier and then
+// edited by hand. Documents that are observed afterwards extend the seeded
+// types rather than replacing them, so the generated Go source differs as
+// little as possible from src.
+//
+// Struct types that are not used by other struct types in src are top level
+// elements, named by their XMLName field's tag or, if they do not have one,
+// by their type name with its first rune lowercased. Fields are attributes,
+// chardata, or child elements according to their encoding/xml struct tags.
+// Pointer fields and fields of generic types with a single type parameter,
+// like Optional[T], are optional and slice fields are repeated. Fields of
+// types bool, time.Time, and Go's integer and floating point types have that
+// type and fields of all other types are strings.
+//
+// Field and type names in src that differ from the names that the Generator
+// would otherwise use are kept, unless WithExportNameFunc or
+// WithExportTypeNameFunc is used. As with WithExportRenames, names are renamed
+// by their local name wherever they occur.
+func (g *Generator) ObserveGoSource(filename string, src []byte) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.observeGoSource(filename, src); err != nil {
+		return err
+	}
+	return g.checkpoint()
+}
+
+// observeGoSource seeds the model from the struct types in src, the Go source
+// file filename.
+func (g *Generator) observeGoSource(filename string, src []byte) error {
+	file, err := parser.ParseFile(token.NewFileSet(), filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return err
+	}
+
+	o := &goSourceObserver{
+		g:           g,
+		options:     g.observeOptions(),
+		structTypes: make(map[string]*ast.StructType),
+		visiting:    make(map[*ast.StructType]bool),
+	}
+	var typeNames []string
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			if structType, ok := typeSpec.Type.(*ast.StructType); ok && typeSpec.TypeParams == nil {
+				o.structTypes[typeSpec.Name.Name] = structType
+				typeNames = append(typeNames, typeSpec.Name.Name)
+			}
+		}
+	}
+
+	usedTypeNames := make(map[string]struct{})
+	for _, structType := range o.structTypes {
+		for _, field := range structType.Fields.List {
+			ast.Inspect(field.Type, func(node ast.Node) bool {
+				if ident, ok := node.(*ast.Ident); ok {
+					if o.structTypes[ident.Name] != structType {
+						usedTypeNames[ident.Name] = struct{}{}
+					}
+				}
+				return true
+			})
+		}
+	}
+
+	// Copy the export renames before modifying them, as they may be shared
+	// with the caller of WithExportRenames or with snapshots.
+	g.exportRenames = maps.Clone(g.exportRenames)
+	if g.exportRenames == nil {
+		g.exportRenames = make(map[string]string)
+	}
+	g.exportTypeRenames = maps.Clone(g.exportTypeRenames)
+	if g.exportTypeRenames == nil {
+		g.exportTypeRenames = make(map[string]string)
+	}
+
+	for _, typeName := range typeNames {
+		if _, ok := usedTypeNames[typeName]; ok {
+			continue
+		}
+		structType := o.structTypes[typeName]
+		name, err := rootElementName(typeName, structType)
+		if err != nil {
+			return err
+		}
+		name = o.options.pathNameFunc(nil, name)
+		if name == (xml.Name{}) {
+			continue
+		}
+		typeElement, ok := g.typeElements[name]
+		created := !ok
+		if created {
+			typeElement = newElement(name)
+			typeElement.root = true
+			g.typeElements[name] = typeElement
+		}
+		if _, ok := g.typeOrder[name]; !ok {
+			g.typeOrder[name] = o.options.getOrder()
+		}
+		o.renameType(typeName, name)
+		if err := o.observeStructType(typeElement, structType, []xml.Name{name}, created); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// observeStructType seeds e, with path path, from structType. created is
+// whether e was created by o, in which case its attributes and child elements
+// are not optional unless structType says so.
+func (o *goSourceObserver) observeStructType(e *element, structType *ast.StructType, path []xml.Name, created bool) error {
+	if o.visiting[structType] {
+		return nil
+	}
+	o.visiting[structType] = true
+	defer delete(o.visiting, structType)
+
+	for _, field := range structType.Fields.List {
+		tag := ""
+		if field.Tag != nil {
+			structTag, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return err
+			}
+			tag = reflect.StructTag(structTag).Get("xml")
+		}
+		if tag == "-" {
+			continue
+		}
+		tagName, flags, _ := strings.Cut(tag, ",")
+
+		if len(field.Names) == 0 {
+			if ident, ok := field.Type.(*ast.Ident); ok && tagName == "" {
+				if embeddedStructType, ok := o.structTypes[ident.Name]; ok {
+					if err := o.observeStructType(e, embeddedStructType, path, created); err != nil {
+						return err
+					}
+				}
+			}
+			continue
+		}
+		fieldName := field.Names[0].Name
+		if fieldName == "XMLName" || !ast.IsExported(fieldName) {
+			continue
+		}
+		if tagName == "" {
+			tagName = fieldName
+		}
+
+		typeExpr, optional, repeated := unwrapGoType(field.Type)
+		switch flag, _, _ := strings.Cut(flags, ","); flag {
+		case "attr":
+			if len(path) == 1 && !o.options.topLevelAttributes {
+				continue
+			}
+			attrName := o.options.pathNameFunc(path, parseTagName(tagName))
+			if attrName == (xml.Name{}) {
+				continue
+			}
+			attrValue, ok := e.attrValues[attrName]
+			if !ok {
+				attrValue = &value{
+					name: attrName,
+				}
+				e.attrValues[attrName] = attrValue
+			}
+			attrValue.optional = attrValue.optional || optional || !created
+			attrValue.observeGoType(typeExpr)
+			if exportedName, ok := strings.CutSuffix(fieldName, o.g.attrNameSuffix); ok {
+				o.rename(o.g.resolvedExportNameFunc()(attrName), exportedName, attrName)
+			}
+		case "chardata", "cdata":
+			e.charDataValue.observeGoType(typeExpr)
+		case "":
+			if err := o.observeChildElement(e, tagName, fieldName, typeExpr, path, created, optional, repeated); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// observeChildElement seeds the child element of e, with path path, that is
+// described by a field named fieldName with the tag name tagName and the type
+// typeExpr.
+func (o *goSourceObserver) observeChildElement(e *element, tagName, fieldName string, typeExpr ast.Expr, path []xml.Name, created, optional, repeated bool) error {
+	path = path[:len(path):len(path)]
+	tagNames := strings.Split(tagName, ">")
+	currentElement := e
+	var childName xml.Name
+	for i, tagName := range tagNames {
+		childName = o.options.pathNameFunc(path, parseTagName(tagName))
+		if childName == (xml.Name{}) {
+			return nil
+		}
+		_, isChild := currentElement.childElements[childName]
+		_, isTopLevel := o.options.topLevelElements[childName]
+		childElement := currentElement.childElement(childName, &o.options)
+		last := i == len(tagNames)-1
+		if !isChild && !created || last && optional {
+			currentElement.optionalChildren[childName] = struct{}{}
+		}
+		if last && repeated {
+			currentElement.repeatedChildren[childName] = struct{}{}
+		}
+		created = !isChild && !isTopLevel
+		path = append(path, childName)
+		currentElement = childElement
+	}
+	if exportedName, ok := strings.CutSuffix(fieldName, o.g.elemNameSuffix); ok && len(tagNames) == 1 {
+		o.rename(o.g.resolvedExportNameFunc()(childName), exportedName, childName)
+	}
+
+	switch typeExpr := typeExpr.(type) {
+	case *ast.StructType:
+		return o.observeStructType(currentElement, typeExpr, path, created)
+	case *ast.Ident:
+		if structType, ok := o.structTypes[typeExpr.Name]; ok {
+			o.renameType(typeExpr.Name, childName)
+			return o.observeStructType(currentElement, structType, path, created)
+		}
+	}
+	currentElement.charDataValue.observeGoType(typeExpr)
+	return nil
+}
+
+// rename adds an export rename for name from generatedName to goName, if they
+// differ and name does not already have an export rename.
+func (o *goSourceObserver) rename(generatedName, goName string, name xml.Name) {
+	if generatedName == goName {
+		return
+	}
+	if _, ok := o.g.exportRenames[name.Local]; !ok {
+		o.g.exportRenames[name.Local] = goName
+	}
+}
+
+// renameType adds an export type rename for name to typeName, if the type
+// would otherwise have a different name and name does not already have an
+// export type rename.
+func (o *goSourceObserver) renameType(typeName string, name xml.Name) {
+	if o.g.resolvedExportTypeNameFunc()(name) == typeName {
+		return
+	}
+	if _, ok := o.g.exportTypeRenames[name.Local]; !ok {
+		o.g.exportTypeRenames[name.Local] = typeName
+	}
+}
+
+// observeGoType records a value of the Go type typeExpr as being observed for
+// v.
+func (v *value) observeGoType(typeExpr ast.Expr) {
+	v.observations++
+	switch goTypeString(typeExpr) {
+	case "bool":
+		v.boolCount++
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		v.intCount++
+	case "float32", "float64":
+		v.float64Count++
+	case "time.Time":
+		v.timeCount++
+	default:
+		v.stringCount++
+	}
+}
+
+// rootElementName returns the name of the top level element whose struct type
+// is structType, named typeName.
+func rootElementName(typeName string, structType *ast.StructType) (xml.Name, error) {
+	for _, field := range structType.Fields.List {
+		if len(field.Names) == 0 || field.Names[0].Name != "XMLName" || field.Tag == nil {
+			continue
+		}
+		structTag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			return xml.Name{}, err
+		}
+		if tagName, _, _ := strings.Cut(reflect.StructTag(structTag).Get("xml"), ","); tagName != "" {
+			return parseTagName(tagName), nil
+		}
+	}
+	firstRune, size := utf8.DecodeRuneInString(typeName)
+	if firstRune == utf8.RuneError {
+		return xml.Name{}, fmt.Errorf("%s: invalid type name", typeName)
+	}
+	return xml.Name{Local: string(unicode.ToLower(firstRune)) + typeName[size:]}, nil
+}
+
+// parseTagName returns the name in the encoding/xml struct tag name tagName,
+// which is either a local name or a namespace and a local name separated by a
+// space.
+func parseTagName(tagName string) xml.Name {
+	if space, local, ok := strings.Cut(tagName, " "); ok {
+		return xml.Name{Space: space, Local: local}
+	}
+	return xml.Name{Local: tagName}
+}
+
+// unwrapGoType returns the type of the values of a field of type typeExpr, and
+// whether the field is optional and repeated.
+func unwrapGoType(typeExpr ast.Expr) (ast.Expr, bool, bool) {
+	optional, repeated := false, false
+	for {
+		switch expr := typeExpr.(type) {
+		case *ast.StarExpr:
+			optional = true
+			typeExpr = expr.X
+		case *ast.ArrayType:
+			if ident, ok := expr.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+				return typeExpr, optional, repeated
+			}
+			repeated = true
+			typeExpr = expr.Elt
+		case *ast.IndexExpr:
+			optional = true
+			typeExpr = expr.Index
+		default:
+			return typeExpr, optional, repeated
+		}
+	}
+}
+
+// goTypeString returns the name of the Go type typeExpr, or an empty string if
+// it is not a named type.
+func goTypeString(typeExpr ast.Expr) string {
+	switch expr := typeExpr.(type) {
+	case *ast.Ident:
+		return expr.Name
+	case *ast.SelectorExpr:
+		if pkg, ok := expr.X.(*ast.Ident); ok {
+			return pkg.Name + "." + expr.Sel.Name
+		}
+	}
+	return ""
+}
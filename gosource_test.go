@@ -0,0 +1,69 @@
+package xmlstruct_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestObserveGoSource(t *testing.T) {
+	t.Parallel()
+
+	src := joinLines(
+		`package feed`,
+		``,
+		`import "time"`,
+		``,
+		`type Feed struct {`,
+		"\tXMLName xml.Name `xml:\"feed\"`",
+		"\tID      int      `xml:\"id,attr\"`",
+		"\tTitle   string   `xml:\"title\"`",
+		"\tUpdated time.Time `xml:\"updated\"`",
+		"\tEntries []Entry  `xml:\"entry\"`",
+		`}`,
+		``,
+		`type Entry struct {`,
+		"\tLang    *string `xml:\"lang,attr\"`",
+		"\tSummary string  `xml:\"summary\"`",
+		`}`,
+	)
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithNamedTypes(true),
+		xmlstruct.WithPreserveOrder(true),
+		xmlstruct.WithTopLevelAttributes(true),
+	)
+	assert.NoError(t, generator.ObserveGoSource("feed.go", []byte(src)))
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(joinLines(
+		`<feed id="1">`,
+		`  <title>Example</title>`,
+		`  <updated>2024-01-02T03:04:05Z</updated>`,
+		`  <entry><summary>Summary</summary><score>1.5</score></entry>`,
+		`</feed>`,
+	))))
+	actual, err := generator.Generate()
+	assert.NoError(t, err)
+	assert.Equal(t, joinLines(
+		xmlstruct.DefaultHeader,
+		``,
+		`package main`,
+		``,
+		`import "time"`,
+		``,
+		`type Feed struct {`,
+		"\tID      int       `xml:\"id,attr\"`",
+		"\tTitle   string    `xml:\"title\"`",
+		"\tUpdated time.Time `xml:\"updated\"`",
+		"\tEntries []Entry   `xml:\"entry\"`",
+		`}`,
+		``,
+		`type Entry struct {`,
+		"\tLang    *string `xml:\"lang,attr\"`",
+		"\tSummary string  `xml:\"summary\"`",
+		"\tScore   float64 `xml:\"score\"`",
+		`}`,
+	), string(actual))
+}
@@ -0,0 +1,99 @@
+package xmlstruct
+
+import (
+	"fmt"
+	"strings"
+)
+
+// graphQLDateTimeScalar is the custom GraphQL scalar used for times.
+const graphQLDateTimeScalar = "DateTime"
+
+// GenerateGraphQLSchema returns a GraphQL schema, in the GraphQL Schema
+// Definition Language, describing all the XML documents observed so far.
+//
+// Each element with attributes or child elements becomes an object type.
+// Repeated attributes and elements become lists and optional attributes and
+// elements are nullable. Nested elements that would be anonymous structs in the
+// generated Go source are given their own types. Times use the custom DateTime
+// scalar.
+func (g *Generator) GenerateGraphQLSchema() ([]byte, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	options := g.generateOptions()
+
+	schemaTypes, typeNamesByElement, err := g.schemaTypes(options)
+	if err != nil {
+		return nil, err
+	}
+
+	usesDateTime := false
+	graphQLScalarType := func(v *value) string {
+		kind, tolerant := v.resolvedKind(options)
+		if tolerant {
+			return "String"
+		}
+		switch kind {
+		case boolValueKind:
+			return "Boolean"
+		case intValueKind:
+			return "Int"
+		case float64ValueKind:
+			return "Float"
+		case timeValueKind:
+			usesDateTime = true
+			return graphQLDateTimeScalar
+		default:
+			return "String"
+		}
+	}
+
+	typesBuilder := &strings.Builder{}
+	for _, schemaType := range schemaTypes {
+		if schemaType.scalar {
+			fmt.Fprintf(typesBuilder, "\nscalar %s\n", schemaType.name)
+			continue
+		}
+
+		fields, err := schemaType.element.fields(schemaType.path, options)
+		if err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(typesBuilder, "\ntype %s {\n", schemaType.name)
+		for _, field := range fields {
+			var fieldType string
+			switch field.kind {
+			case attrFieldKind, charDataFieldKind:
+				fieldType = graphQLScalarType(field.value)
+			case elemFieldKind:
+				if typeName, ok := typeNamesByElement[field.element]; ok {
+					fieldType = typeName
+				} else {
+					fieldType = graphQLScalarType(&field.element.charDataValue)
+				}
+			}
+			switch {
+			case field.repeated && field.optional:
+				fieldType = "[" + fieldType + "!]"
+			case field.repeated:
+				fieldType = "[" + fieldType + "!]!"
+			case !field.optional:
+				fieldType += "!"
+			}
+			fmt.Fprintf(typesBuilder, "  %s: %s\n", lowerCamelCase(field.name), fieldType)
+		}
+		fmt.Fprintf(typesBuilder, "}\n")
+	}
+
+	schemaBuilder := &strings.Builder{}
+	if g.header != "" {
+		fmt.Fprintf(schemaBuilder, "%s\n", commentHeader(g.header, "#"))
+	}
+	if usesDateTime {
+		fmt.Fprintf(schemaBuilder, "\nscalar %s\n", graphQLDateTimeScalar)
+	}
+	schemaBuilder.WriteString(typesBuilder.String())
+
+	schema := strings.TrimPrefix(schemaBuilder.String(), "\n")
+	return []byte(schema), nil
+}
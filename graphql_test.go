@@ -0,0 +1,107 @@
+package xmlstruct_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestGenerateGraphQLSchema(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name        string
+		xmlStrs     []string
+		options     []xmlstruct.GeneratorOption
+		expectedStr string
+	}{
+		{
+			name: "simple",
+			xmlStrs: []string{
+				joinLines(
+					`<a id="1">`,
+					`  <b>c</b>`,
+					`  <d when="2024-01-02T03:04:05Z">1.5</d>`,
+					`  <d>2</d>`,
+					`</a>`,
+				),
+			},
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithHeader(""),
+				xmlstruct.WithTopLevelAttributes(true),
+			},
+			expectedStr: joinLines(
+				`scalar DateTime`,
+				``,
+				`type A {`,
+				`  id: Int!`,
+				`  b: String!`,
+				`  d: [D!]!`,
+				`}`,
+				``,
+				`type D {`,
+				`  when: DateTime`,
+				`  charData: Float!`,
+				`}`,
+			),
+		},
+		{
+			name: "optional_and_nested",
+			xmlStrs: []string{
+				`<a><b><c x="y"/></b><e/><e/></a>`,
+				`<a/>`,
+			},
+			expectedStr: joinLines(
+				`# Code generated by go-xmlstruct. DO NOT EDIT.`,
+				``,
+				`type A {`,
+				`  b: B`,
+				`  e: [String!]`,
+				`}`,
+				``,
+				`type B {`,
+				`  c: C!`,
+				`}`,
+				``,
+				`type C {`,
+				`  x: String!`,
+				`}`,
+			),
+		},
+		{
+			name: "named_types",
+			xmlStrs: []string{
+				`<a><b><a/></b></a>`,
+			},
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithHeader(""),
+				xmlstruct.WithNamedTypes(true),
+			},
+			expectedStr: joinLines(
+				`type A {`,
+				`  b: B`,
+				`}`,
+				``,
+				`type B {`,
+				`  a: A!`,
+				`}`,
+			),
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			generator := xmlstruct.NewGenerator(tc.options...)
+			for _, xmlStr := range tc.xmlStrs {
+				assert.NoError(t, generator.ObserveReader(strings.NewReader(xmlStr)))
+			}
+			actual, err := generator.GenerateGraphQLSchema()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStr, string(actual))
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package xmlstruct
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// An IdentityKey describes an attribute whose values identify the elements on
+// which it was observed, like an xs:key or ID attribute.
+type IdentityKey struct {
+	Path         string  // The path of the attribute.
+	Observations int     // The number of observed values.
+	Distinct     int     // The number of distinct observed values.
+	Uniqueness   float64 // Distinct divided by Observations.
+}
+
+// An IdentityKeyRef describes an attribute whose values refer to the values of
+// an IdentityKey, like an xs:keyref or IDREF attribute.
+type IdentityKeyRef struct {
+	Path           string   // The path of the attribute.
+	KeyPath        string   // The path of the referenced key.
+	Observations   int      // The number of observed values.
+	Dangling       int      // The number of observed values that are not values of the key.
+	DanglingValues []string // The distinct dangling values, sorted.
+}
+
+// IdentityConstraints describes the candidate keys and references among the
+// attributes observed in all XML documents.
+type IdentityConstraints struct {
+	Keys    []IdentityKey
+	KeyRefs []IdentityKeyRef
+}
+
+// An identityAttr is an attribute whose values were recorded for identity
+// constraints.
+type identityAttr struct {
+	path  string
+	value *value
+}
+
+// observeIdentityValue records s as a value of v for identity constraints, if
+// enabled by options.
+func (v *value) observeIdentityValue(s string, options *observeOptions) {
+	if !options.identityConstraints {
+		return
+	}
+	if v.identityValues == nil {
+		v.identityValues = make(map[string]int)
+	}
+	v.identityValues[s]++
+}
+
+// identityObservations returns the number of values of v recorded for
+// identity constraints.
+func (v *value) identityObservations() int {
+	observations := 0
+	for _, count := range v.identityValues {
+		observations += count
+	}
+	return observations
+}
+
+// isIdentityKey returns whether v was observed at least twice and the
+// fraction of its values that are distinct is at least keyUniqueness.
+func (v *value) isIdentityKey(keyUniqueness float64) bool {
+	observations := v.identityObservations()
+	return observations >= 2 && float64(len(v.identityValues)) >= keyUniqueness*float64(observations)
+}
+
+// IdentityConstraints returns the attributes in all the XML documents observed
+// so far whose values are candidate keys and the attributes whose values are
+// candidate references to them, each sorted by path. Attribute values are only
+// recorded if WithIdentityConstraints is set.
+//
+// An attribute is a candidate key if it was observed at least twice and the
+// fraction of its values that are distinct is at least the threshold set with
+// WithKeyUniquenessThreshold. Another attribute is a candidate reference to
+// the key if at least half of its distinct values are values of the key,
+// which may have been observed in a different document. Attributes are not
+// references to keys with the same name, as identifiers of different elements
+// often share values. If an attribute is a candidate reference to several keys
+// then the key that resolves most of its values is chosen. If two candidate
+// keys are candidate references to each other, then only the reference that
+// resolves the larger fraction of its distinct values is returned.
+func (g *Generator) IdentityConstraints() (*IdentityConstraints, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	options := g.generateOptions()
+	identityAttrs, err := g.identityAttrs(options)
+	if err != nil {
+		return nil, err
+	}
+
+	identityConstraints := &IdentityConstraints{}
+	var keyAttrs []*identityAttr
+	for _, identityAttr := range identityAttrs {
+		if !identityAttr.value.isIdentityKey(g.keyUniqueness) {
+			continue
+		}
+		keyAttrs = append(keyAttrs, identityAttr)
+		observations := identityAttr.value.identityObservations()
+		identityConstraints.Keys = append(identityConstraints.Keys, IdentityKey{
+			Path:         identityAttr.path,
+			Observations: observations,
+			Distinct:     len(identityAttr.value.identityValues),
+			Uniqueness:   float64(len(identityAttr.value.identityValues)) / float64(observations),
+		})
+	}
+
+	var keyRefs []*IdentityKeyRef
+	resolvedFractions := make(map[[2]string]float64)
+	for _, identityAttr := range identityAttrs {
+		var keyRef *IdentityKeyRef
+		resolved, resolvedFraction := 0, 0.0
+		for _, keyAttr := range keyAttrs {
+			if keyAttr.value.name.Local == identityAttr.value.name.Local {
+				continue
+			}
+			candidate, candidateResolved, candidateResolvedFraction := newIdentityKeyRef(identityAttr, keyAttr)
+			if candidate != nil && candidateResolved > resolved {
+				keyRef, resolved, resolvedFraction = candidate, candidateResolved, candidateResolvedFraction
+			}
+		}
+		if keyRef != nil {
+			keyRefs = append(keyRefs, keyRef)
+			resolvedFractions[[2]string{keyRef.Path, keyRef.KeyPath}] = resolvedFraction
+		}
+	}
+	for _, keyRef := range keyRefs {
+		resolvedFraction := resolvedFractions[[2]string{keyRef.Path, keyRef.KeyPath}]
+		if reverseResolvedFraction, ok := resolvedFractions[[2]string{keyRef.KeyPath, keyRef.Path}]; ok && reverseResolvedFraction > resolvedFraction {
+			continue
+		}
+		identityConstraints.KeyRefs = append(identityConstraints.KeyRefs, *keyRef)
+	}
+
+	return identityConstraints, nil
+}
+
+// newIdentityKeyRef returns the IdentityKeyRef from refAttr to keyAttr, the
+// number of values of refAttr that are values of keyAttr, and the fraction of
+// distinct values of refAttr that are values of keyAttr, or nil if refAttr is
+// not a candidate reference to keyAttr.
+func newIdentityKeyRef(refAttr, keyAttr *identityAttr) (*IdentityKeyRef, int, float64) {
+	resolvedDistinct, resolved, dangling := 0, 0, 0
+	var danglingValues []string
+	for s, count := range refAttr.value.identityValues {
+		if _, ok := keyAttr.value.identityValues[s]; ok {
+			resolvedDistinct++
+			resolved += count
+		} else {
+			dangling += count
+			danglingValues = append(danglingValues, s)
+		}
+	}
+	if resolvedDistinct == 0 || 2*resolvedDistinct < len(refAttr.value.identityValues) {
+		return nil, 0, 0
+	}
+	slices.Sort(danglingValues)
+	return &IdentityKeyRef{
+		Path:           refAttr.path,
+		KeyPath:        keyAttr.path,
+		Observations:   resolved + dangling,
+		Dangling:       dangling,
+		DanglingValues: danglingValues,
+	}, resolved, float64(resolvedDistinct) / float64(len(refAttr.value.identityValues))
+}
+
+// identityAttrs returns the attributes whose values were recorded for
+// identity constraints, sorted by path.
+func (g *Generator) identityAttrs(options *generateOptions) ([]*identityAttr, error) {
+	schemaTypes, _, err := g.schemaTypes(options)
+	if err != nil {
+		return nil, err
+	}
+
+	var identityAttrs []*identityAttr
+	for _, schemaType := range schemaTypes {
+		if schemaType.scalar {
+			continue
+		}
+		fields, err := schemaType.element.fields(schemaType.path, options)
+		if err != nil {
+			return nil, err
+		}
+		for _, field := range fields {
+			if field.kind != attrFieldKind || len(field.value.identityValues) == 0 {
+				continue
+			}
+			identityAttrs = append(identityAttrs, &identityAttr{
+				path:  field.path,
+				value: field.value,
+			})
+		}
+	}
+
+	slices.SortStableFunc(identityAttrs, func(a, b *identityAttr) int {
+		return strings.Compare(a.path, b.path)
+	})
+	return identityAttrs, nil
+}
+
+// writeIndexFuncs writes, for each candidate key attribute of the struct
+// generated for e with the named type typeName, a function that indexes a
+// slice of them by the attribute.
+func writeIndexFuncs(w io.Writer, typeName string, e *element, keyUniqueness float64, options *generateOptions) error {
+	if !e.isStruct(options) {
+		return nil
+	}
+	fields, err := e.fields(e.name.Local, options)
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		if field.kind != attrFieldKind || !field.value.isIdentityKey(keyUniqueness) {
+			continue
+		}
+		fieldType := field.goFieldType(options)
+		if fieldType.slice || fieldType.mapType {
+			continue
+		}
+		funcName := "Index" + typeName + "By" + field.name
+		fmt.Fprintf(w, "\n// %s returns pointers to the elements of s by their %s.\n", funcName, field.name)
+		fmt.Fprintf(w, "func %s(s []%s) map[%s]*%s {\n", funcName, typeName, fieldType.name, typeName)
+		fmt.Fprintf(w, "\tindex := make(map[%s]*%s, len(s))\n", fieldType.name, typeName)
+		fmt.Fprintf(w, "\tfor i := range s {\n")
+		if fieldType.pointer {
+			fmt.Fprintf(w, "\t\tif s[i].%s != nil {\n", field.name)
+			fmt.Fprintf(w, "\t\t\tindex[*s[i].%s] = &s[i]\n", field.name)
+			fmt.Fprintf(w, "\t\t}\n")
+		} else {
+			fmt.Fprintf(w, "\t\tindex[s[i].%s] = &s[i]\n", field.name)
+		}
+		fmt.Fprintf(w, "\t}\n")
+		fmt.Fprintf(w, "\treturn index\n")
+		fmt.Fprintf(w, "}\n")
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package xmlstruct_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestIdentityConstraints(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithIdentityConstraints(true),
+	)
+	for _, xmlStr := range []string{
+		`<catalog><item id="a" kind="x"/><item id="b" kind="x"/><order item="a" ref="o1"/></catalog>`,
+		`<catalog><item id="c" kind="y"/><order item="a" ref="o2"/><order item="z" ref="o3"/></catalog>`,
+	} {
+		assert.NoError(t, generator.ObserveReader(strings.NewReader(xmlStr)))
+	}
+
+	identityConstraints, err := generator.IdentityConstraints()
+	assert.NoError(t, err)
+	assert.Equal(t, &xmlstruct.IdentityConstraints{
+		Keys: []xmlstruct.IdentityKey{
+			{
+				Path:         "catalog/item/@id",
+				Observations: 3,
+				Distinct:     3,
+				Uniqueness:   1,
+			},
+			{
+				Path:         "catalog/order/@ref",
+				Observations: 3,
+				Distinct:     3,
+				Uniqueness:   1,
+			},
+		},
+		KeyRefs: []xmlstruct.IdentityKeyRef{
+			{
+				Path:           "catalog/order/@item",
+				KeyPath:        "catalog/item/@id",
+				Observations:   3,
+				Dangling:       1,
+				DanglingValues: []string{"z"},
+			},
+		},
+	}, identityConstraints)
+}
+
+func TestIndexFuncs(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithIdentityConstraints(true),
+		xmlstruct.WithIndexFuncs(true),
+		xmlstruct.WithNamedTypes(true),
+	)
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(
+		`<catalog><item id="1" kind="x"/><item id="2" kind="x"/><item id="3"/></catalog>`,
+	)))
+
+	actual, err := generator.Generate()
+	assert.NoError(t, err)
+	assert.Equal(t, joinLines(
+		xmlstruct.DefaultHeader,
+		"",
+		"package main",
+		"",
+		"type Catalog struct {",
+		"\tItem []Item `xml:\"item\"`",
+		"}",
+		"",
+		"type Item struct {",
+		"\tID   int     `xml:\"id,attr\"`",
+		"\tKind *string `xml:\"kind,attr\"`",
+		"}",
+		"",
+		"// IndexItemByID returns pointers to the elements of s by their ID.",
+		"func IndexItemByID(s []Item) map[int]*Item {",
+		"\tindex := make(map[int]*Item, len(s))",
+		"\tfor i := range s {",
+		"\t\tindex[s[i].ID] = &s[i]",
+		"\t}",
+		"\treturn index",
+		"}",
+	), string(actual))
+}
@@ -72,3 +72,19 @@ func TestXSD(t *testing.T) {
 		}
 	}
 }
+
+func TestXSDAnyElements(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithAnyElements(xmlstruct.MatchPath("sequence")),
+		xmlstruct.WithNamedTypes(true),
+		xmlstruct.WithPackageName("xsd"),
+	)
+	assert.NoError(t, generator.ObserveFile("testdata/xacml-core-v3-schema-wd-17.xsd"))
+
+	actualSource, err := generator.Generate()
+	assert.NoError(t, err)
+	assert.Contains(t, string(actualSource), "\tAny       *Any         `xml:\"any\"`\n")
+	assert.Contains(t, string(actualSource), "\tAny_      []AnyElement `xml:\",any\"`\n")
+}
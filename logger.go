@@ -0,0 +1,47 @@
+package xmlstruct
+
+import (
+	"encoding/xml"
+	"log/slog"
+	"strings"
+)
+
+// WithLogger sets the logger to which the generator logs, at debug level, the
+// time taken to observe each file, the elements skipped by element filters,
+// and the decisions made when inferring fields, for example fields excluded
+// because they are deprecated or rare, and values whose type conflicts were
+// resolved. By default, nothing is logged.
+func WithLogger(logger *slog.Logger) GeneratorOption {
+	return func(g *Generator) {
+		g.logger = logger
+	}
+}
+
+// logSkippedElement logs that the element name, a child of the elements in
+// path, was skipped.
+func (options *observeOptions) logSkippedElement(path []xml.Name, name xml.Name) {
+	if options.logger == nil {
+		return
+	}
+	localNames := make([]string, 0, len(path)+1)
+	for _, pathName := range path {
+		localNames = append(localNames, pathName.Local)
+	}
+	localNames = append(localNames, name.Local)
+	options.logger.Debug("skipped element", "path", strings.Join(localNames, "/"))
+}
+
+// logDecision logs a decision made when inferring the field with path path.
+// Each decision is logged once per generation, even though fields are
+// inferred several times.
+func (options *generateOptions) logDecision(msg, path string, args ...any) {
+	if options.logger == nil {
+		return
+	}
+	key := msg + "\x00" + path
+	if _, ok := options.loggedDecisions[key]; ok {
+		return
+	}
+	options.loggedDecisions[key] = struct{}{}
+	options.logger.Debug(msg, append([]any{"path", path}, args...)...)
+}
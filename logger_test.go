@@ -0,0 +1,62 @@
+package xmlstruct_test
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestLogger(t *testing.T) {
+	t.Parallel()
+
+	buffer := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(buffer, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+			switch attr.Key {
+			case slog.TimeKey, "duration":
+				return slog.Attr{}
+			default:
+				return attr
+			}
+		},
+	}))
+
+	name := filepath.Join(t.TempDir(), "a.xml")
+	assert.NoError(t, os.WriteFile(name, []byte(joinLines(
+		`<a>`,
+		`  <b>1</b><b>2</b><b>3</b><b>x</b>`,
+		`  <c skip="true"/>`,
+		`  <d>1</d>`,
+		`</a>`,
+	)), 0o666))
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithDeprecated(xmlstruct.MatchPath("a/d"), ""),
+		xmlstruct.WithDeprecatedBuildTag("legacy"),
+		xmlstruct.WithElementFilter(xmlstruct.SkipElementsWithAttr("c", "skip", "true")),
+		xmlstruct.WithLogger(logger),
+		xmlstruct.WithTypeConfidenceThreshold(0.75),
+		xmlstruct.WithTypeConflictPolicy(xmlstruct.TypeConflictPolicyKeep),
+	)
+	assert.NoError(t, generator.ObserveFile(name))
+	_, err := generator.Generate()
+	assert.NoError(t, err)
+	_, err = generator.Generate()
+	assert.NoError(t, err)
+
+	assert.Equal(t, joinLines(
+		`level=DEBUG msg="skipped element" path=a/c`,
+		`level=DEBUG msg="observed file" name=`+name+` documents=1`,
+		`level=DEBUG msg="excluded deprecated field" path=a/d`,
+		`level=DEBUG msg="resolved type conflict" path=a/b type=int observations=4`,
+		`level=DEBUG msg="excluded deprecated field" path=a/d`,
+		`level=DEBUG msg="resolved type conflict" path=a/b type=int observations=4`,
+	), buffer.String())
+}
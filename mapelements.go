@@ -0,0 +1,89 @@
+package xmlstruct
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// mapElementKeyAttrName returns the local name of the key attribute of the map
+// element e and whether e is a map element.
+func (options *generateOptions) mapElementKeyAttrName(e *element) (string, bool) {
+	keyAttrName, ok := options.mapElements[e.name.Local]
+	return keyAttrName, ok
+}
+
+// checkMapElement returns an error if the map element e, with key attribute
+// keyAttrName, cannot be represented as a map from keys to chardata without
+// losing information.
+func checkMapElement(e *element, keyAttrName string) error {
+	if len(e.childElements) != 0 {
+		return fmt.Errorf("%s: map element has child elements", e.name.Local)
+	}
+	for attrName := range e.attrValues {
+		if attrName.Local != keyAttrName {
+			return fmt.Errorf("%s/@%s: map element has other attributes", e.name.Local, attrName.Local)
+		}
+	}
+	keyAttrValue, ok := e.attrValues[xml.Name{Space: e.name.Space, Local: keyAttrName}]
+	if !ok {
+		keyAttrValue, ok = e.attrValues[xml.Name{Local: keyAttrName}]
+	}
+	if !ok || keyAttrValue.optional || keyAttrValue.repeated {
+		return fmt.Errorf("%s/@%s: map element key attribute is not always present", e.name.Local, keyAttrName)
+	}
+	return nil
+}
+
+// mapGoFieldType returns the Go type of a field for the map element e with key
+// attribute keyAttrName and adds the declaration of the map type and its
+// methods to options.
+func (options *generateOptions) mapGoFieldType(e *element, keyAttrName string) goFieldType {
+	typeName := options.exportTypeNameFunc(e.name) + "Map"
+	if _, ok := options.declarations[typeName]; !ok {
+		options.importPackageNames["encoding/xml"] = ""
+		options.importPackageNames["sort"] = ""
+		entryType := fmt.Sprintf("struct {\n\t\tKey   string `xml:%q`\n\t\tValue string `xml:\",chardata\"`\n\t}", keyAttrName+",attr")
+		declarationBuilder := &strings.Builder{}
+		fmt.Fprintf(declarationBuilder, "// %s is a map of %s elements' chardata keyed by their %s attribute.\n", typeName, e.name.Local, keyAttrName)
+		fmt.Fprintf(declarationBuilder, "type %s map[string]string\n", typeName)
+		fmt.Fprintf(declarationBuilder, "\n")
+		fmt.Fprintf(declarationBuilder, "// UnmarshalXML implements encoding/xml.Unmarshaler.\n")
+		fmt.Fprintf(declarationBuilder, "func (m *%s) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {\n", typeName)
+		fmt.Fprintf(declarationBuilder, "\tvar entry %s\n", entryType)
+		fmt.Fprintf(declarationBuilder, "\tif err := d.DecodeElement(&entry, &start); err != nil {\n")
+		fmt.Fprintf(declarationBuilder, "\t\treturn err\n")
+		fmt.Fprintf(declarationBuilder, "\t}\n")
+		fmt.Fprintf(declarationBuilder, "\tif *m == nil {\n")
+		fmt.Fprintf(declarationBuilder, "\t\t*m = make(%s)\n", typeName)
+		fmt.Fprintf(declarationBuilder, "\t}\n")
+		fmt.Fprintf(declarationBuilder, "\t(*m)[entry.Key] = entry.Value\n")
+		fmt.Fprintf(declarationBuilder, "\treturn nil\n")
+		fmt.Fprintf(declarationBuilder, "}\n")
+		fmt.Fprintf(declarationBuilder, "\n")
+		fmt.Fprintf(declarationBuilder, "// MarshalXML implements encoding/xml.Marshaler. Entries are marshaled in\n")
+		fmt.Fprintf(declarationBuilder, "// key order.\n")
+		fmt.Fprintf(declarationBuilder, "func (m %s) MarshalXML(e *xml.Encoder, start xml.StartElement) error {\n", typeName)
+		fmt.Fprintf(declarationBuilder, "\tkeys := make([]string, 0, len(m))\n")
+		fmt.Fprintf(declarationBuilder, "\tfor key := range m {\n")
+		fmt.Fprintf(declarationBuilder, "\t\tkeys = append(keys, key)\n")
+		fmt.Fprintf(declarationBuilder, "\t}\n")
+		fmt.Fprintf(declarationBuilder, "\tsort.Strings(keys)\n")
+		fmt.Fprintf(declarationBuilder, "\tfor _, key := range keys {\n")
+		fmt.Fprintf(declarationBuilder, "\t\tentry := %s{\n", entryType)
+		fmt.Fprintf(declarationBuilder, "\t\t\tKey:   key,\n")
+		fmt.Fprintf(declarationBuilder, "\t\t\tValue: m[key],\n")
+		fmt.Fprintf(declarationBuilder, "\t\t}\n")
+		fmt.Fprintf(declarationBuilder, "\t\tif err := e.EncodeElement(entry, start); err != nil {\n")
+		fmt.Fprintf(declarationBuilder, "\t\t\treturn err\n")
+		fmt.Fprintf(declarationBuilder, "\t\t}\n")
+		fmt.Fprintf(declarationBuilder, "\t}\n")
+		fmt.Fprintf(declarationBuilder, "\treturn nil\n")
+		fmt.Fprintf(declarationBuilder, "}\n")
+		options.declarations[typeName] = declarationBuilder.String()
+	}
+	return goFieldType{
+		name:    typeName,
+		mapType: true,
+	}
+}
@@ -0,0 +1,154 @@
+package xmlstruct
+
+import (
+	"encoding/json"
+	"runtime/debug"
+)
+
+// MetadataFilename is the conventional name of the file containing the
+// Metadata of generated source, in the same directory as the generated source.
+const MetadataFilename = ".xmlstruct-meta.json"
+
+// modulePath is the module path of go-xmlstruct.
+const modulePath = "github.com/twpayne/go-xmlstruct"
+
+// Metadata describes how generated source was produced, for reproducibility
+// audits.
+type Metadata struct {
+	// ToolVersion is the version of go-xmlstruct, if known.
+	ToolVersion string `json:"toolVersion"`
+	// Options are the Generator's options. Options that are functions, like
+	// name functions, element filters, and the matchers of import mappings,
+	// are not included.
+	Options map[string]any `json:"options"`
+	// Inputs are the files observed with ObserveFile, ObserveFragmentsFile,
+	// and ObserveFS, in the order in which they were observed.
+	Inputs []MetadataInput `json:"inputs"`
+	// Fingerprint is the fingerprint of the inferred model, as returned by
+	// Generator.Fingerprint.
+	Fingerprint string `json:"fingerprint"`
+}
+
+// A MetadataInput is an observed file.
+type MetadataInput struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+}
+
+// Metadata returns the metadata of the source that g generates.
+func (g *Generator) Metadata() *Metadata {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.metadata()
+}
+
+// metadata returns the metadata of the source that g generates.
+func (g *Generator) metadata() *Metadata {
+	inputs := make([]MetadataInput, len(g.inputs))
+	copy(inputs, g.inputs)
+	return &Metadata{
+		ToolVersion: toolVersion(),
+		Options:     g.metadataOptions(),
+		Inputs:      inputs,
+		Fingerprint: g.fingerprint(),
+	}
+}
+
+// GenerateMetadata returns the metadata of the source that g generates as
+// indented JSON, to be written to a file named MetadataFilename next to the
+// generated source.
+func (g *Generator) GenerateMetadata() ([]byte, error) {
+	data, err := json.MarshalIndent(g.Metadata(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// metadataOptions returns g's options that are not functions, keyed by name.
+func (g *Generator) metadataOptions() map[string]any {
+	return map[string]any{
+		"anonymizationEnumThreshold":   g.anonymizeEnumThreshold,
+		"attrDefaults":                 g.attrDefaults,
+		"attrMapThreshold":             g.attrMapThreshold,
+		"attrNameSuffix":               g.attrNameSuffix,
+		"benchmarks":                   g.benchmarks,
+		"buildTags":                    g.buildTags,
+		"cardinalityPrecedence":        g.cardinalityPrecedence,
+		"charDataFieldName":            g.charDataFieldName,
+		"charDataFieldNameFromElement": g.charDataElementName,
+		"charsetPrecedence":            g.charsetPrecedence,
+		"commonFieldExtraction":        g.commonFieldExtraction,
+		"compactTypes":                 g.compactTypes,
+		"deepCopyGenMarkers":           g.deepCopyGenMarkers,
+		"deepCopyMethods":              g.deepCopyMethods,
+		"deprecatedBuildTag":           g.deprecatedBuildTag,
+		"elemNameSuffix":               g.elemNameSuffix,
+		"emptyElements":                g.emptyElements,
+		"entities":                     g.entities,
+		"exampleTags":                  g.exampleTags,
+		"exportRenames":                g.exportRenames,
+		"exportTypeRenames":            g.exportTypeRenames,
+		"fallbackCharsets":             g.fallbackCharsets,
+		"formatSource":                 g.formatSource,
+		"generatedFileSuffix":          g.generatedFileSuffix,
+		"header":                       g.header,
+		"identityConstraints":          g.identityConstraints,
+		"importMappings":               g.metadataImportMappings(),
+		"imports":                      g.imports,
+		"indexFuncs":                   g.indexFuncs,
+		"intType":                      g.intType,
+		"keyUniquenessThreshold":       g.keyUniqueness,
+		"mapElements":                  g.mapElements,
+		"minOccurrenceRate":            g.minOccurrenceRate,
+		"namedRoot":                    g.namedRoot,
+		"namedTypes":                   g.namedTypes,
+		"numberFormat":                 g.numberFormat,
+		"packageName":                  g.packageName,
+		"pointerPolicy":                g.generatePointerPolicy(),
+		"preserveLeadingZeros":         g.preserveLeadingZeros,
+		"preserveOrder":                g.preserveOrder,
+		"preserveWhitespaceCharData":   g.preserveWhitespace,
+		"rootRegistry":                 g.rootRegistry,
+		"stripBOM":                     g.stripBOM,
+		"timeLayout":                   g.timeLayout,
+		"timeZonePolicy":               g.timeZonePolicy,
+		"topLevelAttributes":           g.topLevelAttributes,
+		"typeConfidenceThreshold":      g.typeConfidence,
+		"typeConflictPolicy":           g.typeConflictPolicy,
+		"useRawToken":                  g.useRawToken,
+		"valueCollation":               g.valueCollation,
+		"valueExamples":                g.valueExamples,
+	}
+}
+
+// metadataImportMappings returns g's import mappings without their matchers,
+// which are functions.
+func (g *Generator) metadataImportMappings() []map[string]string {
+	importMappings := make([]map[string]string, 0, len(g.importMappings))
+	for _, importMapping := range g.importMappings {
+		importMappings = append(importMappings, map[string]string{
+			"importPath": importMapping.importPath,
+			"goType":     importMapping.goType,
+		})
+	}
+	return importMappings
+}
+
+// toolVersion returns the version of go-xmlstruct in the running binary, or
+// the empty string if it is not known.
+func toolVersion() string {
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	if buildInfo.Main.Path == modulePath {
+		return buildInfo.Main.Version
+	}
+	for _, dep := range buildInfo.Deps {
+		if dep.Path == modulePath {
+			return dep.Version
+		}
+	}
+	return ""
+}
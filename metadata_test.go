@@ -0,0 +1,60 @@
+package xmlstruct_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestGenerateMetadata(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	data := []byte(`<a><b>1</b></a>`)
+	name := filepath.Join(dir, "a.xml")
+	assert.NoError(t, os.WriteFile(name, data, 0o666))
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithBuildTags("linux"),
+		xmlstruct.WithImportMapping("github.com/shopspring/decimal", "decimal.Decimal", xmlstruct.MatchPath("b")),
+		xmlstruct.WithPackageName("a"),
+	)
+	assert.NoError(t, generator.ObserveGoSource("a.go", []byte(joinLines(
+		"package a",
+		"",
+		"type Root struct {",
+		"\tXMLName xml.Name `xml:\"a\"`",
+		"}",
+	))))
+	assert.NoError(t, generator.ObserveFile(name))
+
+	metadataData, err := generator.GenerateMetadata()
+	assert.NoError(t, err)
+	var metadata xmlstruct.Metadata
+	assert.NoError(t, json.Unmarshal(metadataData, &metadata))
+
+	hash := sha256.Sum256(data)
+	assert.Equal(t, []xmlstruct.MetadataInput{
+		{
+			Name:   name,
+			SHA256: hex.EncodeToString(hash[:]),
+		},
+	}, metadata.Inputs)
+	assert.Equal(t, generator.Fingerprint(), metadata.Fingerprint)
+	assert.Equal(t, any("a"), metadata.Options["packageName"])
+	assert.Equal(t, any([]any{"linux"}), metadata.Options["buildTags"])
+	assert.Equal(t, any(map[string]any{"a": "Root"}), metadata.Options["exportTypeRenames"])
+	assert.Equal(t, any([]any{
+		map[string]any{
+			"importPath": "github.com/shopspring/decimal",
+			"goType":     "decimal.Decimal",
+		},
+	}), metadata.Options["importMappings"])
+}
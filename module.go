@@ -0,0 +1,231 @@
+package xmlstruct
+
+import (
+	"fmt"
+	"go/format"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// moduleGoVersion is the Go version declared in generated go.mod files.
+const moduleGoVersion = "1.22"
+
+// majorVersionRx matches the major version suffix of a module path.
+var majorVersionRx = regexp.MustCompile(`\Av[0-9]+\z`)
+
+// GenerateModule writes a complete, standalone Go module with module path
+// modulePath for all the XML documents observed so far to the directory dir,
+// creating it if needed. The module contains:
+//
+//   - go.mod, declaring the module.
+//   - doc.go, the package documentation.
+//   - types.gen.go, the generated types.
+//   - decode.gen.go, a Decode function for each top level element.
+//
+// If WithBenchmarks is set then the module also contains
+// benchmarks.gen_test.go and the sample files in testdata, as returned by
+// GenerateBenchmarks.
+//
+// If a build tag is set with WithDeprecatedBuildTag then types.gen.go does not
+// include deprecated fields and the module also contains types_<tag>.gen.go,
+// which does.
+//
+// The .gen.go suffix of the generated files can be changed with
+// WithGeneratedFileSuffix. Any build tags set with WithBuildTags apply to the
+// generated files, but not to doc.go.
+//
+// The package name is derived from modulePath unless it is set with
+// WithPackageName to something other than main. If WithImportMapping is used
+// then the module's requirements should be added with go mod tidy.
+func (g *Generator) GenerateModule(dir, modulePath string) error {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if !strings.HasSuffix(g.generatedFileSuffix, ".go") {
+		return fmt.Errorf("%s: generated file suffix does not end in .go", g.generatedFileSuffix)
+	}
+
+	buildConstraint, err := g.buildConstraint()
+	if err != nil {
+		return err
+	}
+
+	options := g.generateOptions()
+	if options.packageName == "" || options.packageName == "main" {
+		packageName, err := modulePackageName(modulePath)
+		if err != nil {
+			return err
+		}
+		options.packageName = packageName
+	}
+
+	types, err := g.generate(options)
+	if err != nil {
+		return err
+	}
+
+	var rootTypeNames []string
+	for _, typeElement := range g.sortedTypeElements(options) {
+		if typeElement.root {
+			rootTypeNames = append(rootTypeNames, options.exportTypeNameFunc(typeElement.name))
+		}
+	}
+
+	files := []struct {
+		name     string
+		contents []byte
+	}{
+		{
+			name:     "go.mod",
+			contents: []byte("module " + modulePath + "\n\ngo " + moduleGoVersion + "\n"),
+		},
+		{
+			name:     "doc.go",
+			contents: g.formatModuleSource(moduleDocSource(options.header, options.packageName, rootTypeNames)),
+		},
+		{
+			name:     "types" + g.generatedFileSuffix,
+			contents: types,
+		},
+		{
+			name:     "decode" + g.generatedFileSuffix,
+			contents: g.formatModuleSource(moduleDecodeSource(options.header, buildConstraint, options.packageName, rootTypeNames)),
+		},
+	}
+
+	if g.benchmarks {
+		benchmarks, sampleFiles, err := g.generateBenchmarks(options)
+		if err != nil {
+			return err
+		}
+		files = append(files, struct {
+			name     string
+			contents []byte
+		}{
+			name:     "benchmarks" + strings.TrimSuffix(g.generatedFileSuffix, ".go") + "_test.go",
+			contents: benchmarks,
+		})
+		for _, sampleFile := range sampleFiles {
+			files = append(files, struct {
+				name     string
+				contents []byte
+			}{
+				name:     sampleFile.Name,
+				contents: sampleFile.Contents,
+			})
+		}
+	}
+
+	if g.deprecatedBuildTag != "" {
+		deprecatedOptions := g.generateOptions()
+		deprecatedOptions.packageName = options.packageName
+		deprecatedOptions.deprecatedFields = true
+		deprecatedTypes, err := g.generate(deprecatedOptions)
+		if err != nil {
+			return err
+		}
+		files = append(files, struct {
+			name     string
+			contents []byte
+		}{
+			name:     "types_" + g.deprecatedBuildTag + g.generatedFileSuffix,
+			contents: deprecatedTypes,
+		})
+	}
+
+	for _, file := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(file.name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0o777); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filename, file.contents, 0o666); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatModuleSource returns source, formatted if g formats source.
+func (g *Generator) formatModuleSource(source string) []byte {
+	if g.formatSource {
+		if formattedSource, err := format.Source([]byte(source)); err == nil {
+			return formattedSource
+		}
+	}
+	return []byte(source)
+}
+
+// moduleDocSource returns the source of the doc.go file of a generated module.
+func moduleDocSource(header, packageName string, rootTypeNames []string) string {
+	sourceBuilder := &strings.Builder{}
+	if header != "" {
+		fmt.Fprintf(sourceBuilder, "%s\n\n", header)
+	}
+	fmt.Fprintf(sourceBuilder, "// Package %s contains Go types for XML documents.\n", packageName)
+	if len(rootTypeNames) != 0 {
+		fmt.Fprintf(sourceBuilder, "//\n")
+		fmt.Fprintf(sourceBuilder, "// The types of the top level elements are:\n")
+		fmt.Fprintf(sourceBuilder, "//\n")
+		for _, rootTypeName := range rootTypeNames {
+			fmt.Fprintf(sourceBuilder, "//   - [%s], decoded with [Decode%s].\n", rootTypeName, rootTypeName)
+		}
+	}
+	fmt.Fprintf(sourceBuilder, "package %s\n", packageName)
+	return sourceBuilder.String()
+}
+
+// moduleDecodeSource returns the source of the decode.gen.go file of a
+// generated module.
+func moduleDecodeSource(header, buildConstraint, packageName string, rootTypeNames []string) string {
+	sourceBuilder := &strings.Builder{}
+	writeSourceHeader(sourceBuilder, header, buildConstraint)
+	fmt.Fprintf(sourceBuilder, "package %s\n", packageName)
+	if len(rootTypeNames) == 0 {
+		return sourceBuilder.String()
+	}
+	fmt.Fprintf(sourceBuilder, "\nimport (\n")
+	fmt.Fprintf(sourceBuilder, "\t\"encoding/xml\"\n")
+	fmt.Fprintf(sourceBuilder, "\t\"io\"\n")
+	fmt.Fprintf(sourceBuilder, ")\n")
+	for _, rootTypeName := range rootTypeNames {
+		fmt.Fprintf(sourceBuilder, "\n// Decode%s returns the %s decoded from r.\n", rootTypeName, rootTypeName)
+		fmt.Fprintf(sourceBuilder, "func Decode%s(r io.Reader) (*%s, error) {\n", rootTypeName, rootTypeName)
+		fmt.Fprintf(sourceBuilder, "\tvar v %s\n", rootTypeName)
+		fmt.Fprintf(sourceBuilder, "\tif err := xml.NewDecoder(r).Decode(&v); err != nil {\n")
+		fmt.Fprintf(sourceBuilder, "\t\treturn nil, err\n")
+		fmt.Fprintf(sourceBuilder, "\t}\n")
+		fmt.Fprintf(sourceBuilder, "\treturn &v, nil\n")
+		fmt.Fprintf(sourceBuilder, "}\n")
+	}
+	return sourceBuilder.String()
+}
+
+// modulePackageName returns the package name for modulePath. It is the last
+// element of modulePath, ignoring any major version suffix, with any go-
+// prefix or .go suffix removed, lowercased, and with all characters that are
+// not letters or digits removed.
+func modulePackageName(modulePath string) (string, error) {
+	base := path.Base(modulePath)
+	if majorVersionRx.MatchString(base) {
+		base = path.Base(path.Dir(modulePath))
+	}
+	base = strings.TrimPrefix(base, "go-")
+	base = strings.TrimSuffix(base, ".go")
+	packageName := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r):
+			return unicode.ToLower(r)
+		case unicode.IsDigit(r):
+			return r
+		default:
+			return -1
+		}
+	}, base)
+	if packageName == "" || unicode.IsDigit([]rune(packageName)[0]) {
+		return "", fmt.Errorf("%s: cannot derive package name", modulePath)
+	}
+	return packageName, nil
+}
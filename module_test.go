@@ -0,0 +1,135 @@
+package xmlstruct_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestGenerateModule(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator()
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(`<order><id>1</id></order>`)))
+
+	dir := filepath.Join(t.TempDir(), "orders")
+	assert.NoError(t, generator.GenerateModule(dir, "example.com/go-orders/v2"))
+
+	for name, expected := range map[string]string{
+		"go.mod": joinLines(
+			"module example.com/go-orders/v2",
+			"",
+			"go 1.22",
+		),
+		"doc.go": joinLines(
+			"// Code generated by go-xmlstruct. DO NOT EDIT.",
+			"",
+			"// Package orders contains Go types for XML documents.",
+			"//",
+			"// The types of the top level elements are:",
+			"//",
+			"//   - [Order], decoded with [DecodeOrder].",
+			"package orders",
+		),
+		"types.gen.go": joinLines(
+			"// Code generated by go-xmlstruct. DO NOT EDIT.",
+			"",
+			"package orders",
+			"",
+			"type Order struct {",
+			"\tID int `xml:\"id\"`",
+			"}",
+		),
+		"decode.gen.go": joinLines(
+			"// Code generated by go-xmlstruct. DO NOT EDIT.",
+			"",
+			"package orders",
+			"",
+			"import (",
+			"\t\"encoding/xml\"",
+			"\t\"io\"",
+			")",
+			"",
+			"// DecodeOrder returns the Order decoded from r.",
+			"func DecodeOrder(r io.Reader) (*Order, error) {",
+			"\tvar v Order",
+			"\tif err := xml.NewDecoder(r).Decode(&v); err != nil {",
+			"\t\treturn nil, err",
+			"\t}",
+			"\treturn &v, nil",
+			"}",
+		),
+	} {
+		actual, err := os.ReadFile(filepath.Join(dir, name))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, string(actual))
+	}
+}
+
+func TestGenerateModuleBenchmarks(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithBenchmarks(true),
+	)
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(`<order><id>1</id></order>`)))
+
+	dir := filepath.Join(t.TempDir(), "orders")
+	assert.NoError(t, generator.GenerateModule(dir, "example.com/orders"))
+
+	benchmarks, err := os.ReadFile(filepath.Join(dir, "benchmarks.gen_test.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(benchmarks), `readBenchmarkSample(b, "testdata/benchmarkOrder.xml")`)
+	sample, err := os.ReadFile(filepath.Join(dir, "testdata", "benchmarkOrder.xml"))
+	assert.NoError(t, err)
+	assert.Equal(t, `<order><id>1</id></order>`, string(sample))
+}
+
+func TestGenerateModuleInvalidModulePath(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator()
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(`<a/>`)))
+	assert.EqualError(t, generator.GenerateModule(t.TempDir(), "example.com/123"), "example.com/123: cannot derive package name")
+}
+
+func TestGenerateModuleBuildTagsAndSuffix(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithBuildTags("xml"),
+		xmlstruct.WithGeneratedFileSuffix("_gen.go"),
+	)
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(`<a/>`)))
+
+	dir := t.TempDir()
+	assert.NoError(t, generator.GenerateModule(dir, "example.com/a"))
+
+	types, err := os.ReadFile(filepath.Join(dir, "types_gen.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, joinLines(
+		"// Code generated by go-xmlstruct. DO NOT EDIT.",
+		"",
+		"//go:build xml",
+		"",
+		"package a",
+		"",
+		"type A struct{}",
+	), string(types))
+
+	decode, err := os.ReadFile(filepath.Join(dir, "decode_gen.go"))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(decode), "// Code generated by go-xmlstruct. DO NOT EDIT.\n\n//go:build xml\n\npackage a\n"))
+}
+
+func TestGenerateModuleInvalidGeneratedFileSuffix(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator(xmlstruct.WithGeneratedFileSuffix(".gen"))
+	assert.EqualError(t, generator.GenerateModule(t.TempDir(), "example.com/a"), ".gen: generated file suffix does not end in .go")
+}
@@ -0,0 +1,135 @@
+package xmlstruct
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// localizedNumberRx matches numbers after they have been normalized from a
+// localized NumberFormat.
+var localizedNumberRx = regexp.MustCompile(`\A[+-]?[0-9]+(?:\.[0-9]+)?(?:[eE][+-]?[0-9]+)?\z`)
+
+// A NumberFormat describes how numbers are formatted in the observed XML
+// documents. The zero value matches Go's strconv package: a dot as the decimal
+// separator, no thousands separator, and exponents allowed.
+type NumberFormat struct {
+	// DecimalSeparator is the decimal separator. Zero means a dot.
+	DecimalSeparator rune
+	// ThousandsSeparator is the thousands separator, or zero for none. If it
+	// is set then digits must be grouped in threes.
+	ThousandsSeparator rune
+	// DisallowExponents disallows scientific notation like 1e3, so such
+	// values are inferred to be strings.
+	DisallowExponents bool
+}
+
+// decimalSeparator returns f's decimal separator.
+func (f NumberFormat) decimalSeparator() rune {
+	if f.DecimalSeparator == 0 {
+		return '.'
+	}
+	return f.DecimalSeparator
+}
+
+// localized returns whether numbers in f cannot be parsed by strconv, in which
+// case localized wrapper types are generated.
+func (f NumberFormat) localized() bool {
+	return f.decimalSeparator() != '.' || f.ThousandsSeparator != 0
+}
+
+// normalize returns s converted to a format that can be parsed by strconv and
+// whether s is a number in f.
+func (f NumberFormat) normalize(s string) (string, bool) {
+	if f.DisallowExponents && strings.ContainsAny(s, "eE") {
+		return "", false
+	}
+	if !f.localized() {
+		return s, true
+	}
+
+	integerPart, fractionalPart, hasFractionalPart := strings.Cut(s, string(f.decimalSeparator()))
+	if f.ThousandsSeparator != 0 && strings.ContainsRune(integerPart, f.ThousandsSeparator) {
+		groups := strings.Split(integerPart, string(f.ThousandsSeparator))
+		first := strings.TrimLeft(groups[0], "+-")
+		if len(first) == 0 || len(first) > 3 {
+			return "", false
+		}
+		for _, group := range groups[1:] {
+			if len(group) != 3 {
+				return "", false
+			}
+		}
+		integerPart = strings.Join(groups, "")
+	}
+	// Any remaining dot is neither the decimal nor the thousands separator.
+	if f.decimalSeparator() != '.' && strings.ContainsRune(integerPart+fractionalPart, '.') {
+		return "", false
+	}
+	normalized := integerPart
+	if hasFractionalPart {
+		normalized += "." + fractionalPart
+	}
+	if !localizedNumberRx.MatchString(normalized) {
+		return "", false
+	}
+	return normalized, true
+}
+
+// localizedGoFieldType returns the Go type for numbers of kind in the
+// localized format options.numberFormat, adding its declaration to options.
+func (options *generateOptions) localizedGoFieldType(kind valueKind) string {
+	typeName, underlyingType, parse := "LocalizedInt", options.intType, "strconv.ParseInt(s, 10, 64)"
+	format := "strconv.FormatInt(int64(x), 10)"
+	if kind == float64ValueKind {
+		typeName, underlyingType, parse = "LocalizedFloat64", "float64", "strconv.ParseFloat(s, 64)"
+		format = "strconv.FormatFloat(float64(x), 'f', -1, 64)"
+	}
+	if _, ok := options.declarations[typeName]; ok {
+		return typeName
+	}
+
+	options.importPackageNames["strconv"] = ""
+	options.importPackageNames["strings"] = ""
+	decimalSeparator := options.numberFormat.decimalSeparator()
+	thousandsSeparator := options.numberFormat.ThousandsSeparator
+
+	declarationBuilder := &strings.Builder{}
+	fmt.Fprintf(declarationBuilder, "// %s is a number of type %s formatted with %s as the decimal separator", typeName, underlyingType, strconv.QuoteRune(decimalSeparator))
+	if thousandsSeparator != 0 {
+		fmt.Fprintf(declarationBuilder, "\n// and %s as the thousands separator", strconv.QuoteRune(thousandsSeparator))
+	}
+	fmt.Fprintf(declarationBuilder, ".\n")
+	fmt.Fprintf(declarationBuilder, "type %s %s\n", typeName, underlyingType)
+	fmt.Fprintf(declarationBuilder, "\n")
+	fmt.Fprintf(declarationBuilder, "// UnmarshalText implements encoding.TextUnmarshaler.\n")
+	fmt.Fprintf(declarationBuilder, "func (x *%s) UnmarshalText(text []byte) error {\n", typeName)
+	fmt.Fprintf(declarationBuilder, "\ts := strings.TrimSpace(string(text))\n")
+	if thousandsSeparator != 0 {
+		fmt.Fprintf(declarationBuilder, "\ts = strings.ReplaceAll(s, %q, \"\")\n", string(thousandsSeparator))
+	}
+	if decimalSeparator != '.' {
+		fmt.Fprintf(declarationBuilder, "\ts = strings.Replace(s, %q, \".\", 1)\n", string(decimalSeparator))
+	}
+	fmt.Fprintf(declarationBuilder, "\tv, err := %s\n", parse)
+	fmt.Fprintf(declarationBuilder, "\tif err != nil {\n")
+	fmt.Fprintf(declarationBuilder, "\t\treturn err\n")
+	fmt.Fprintf(declarationBuilder, "\t}\n")
+	fmt.Fprintf(declarationBuilder, "\t*x = %s(v)\n", typeName)
+	fmt.Fprintf(declarationBuilder, "\treturn nil\n")
+	fmt.Fprintf(declarationBuilder, "}\n")
+	fmt.Fprintf(declarationBuilder, "\n")
+	fmt.Fprintf(declarationBuilder, "// MarshalText implements encoding.TextMarshaler. Thousands are not\n")
+	fmt.Fprintf(declarationBuilder, "// separated.\n")
+	fmt.Fprintf(declarationBuilder, "func (x %s) MarshalText() ([]byte, error) {\n", typeName)
+	if kind == float64ValueKind && decimalSeparator != '.' {
+		fmt.Fprintf(declarationBuilder, "\treturn []byte(strings.Replace(%s, \".\", %q, 1)), nil\n", format, string(decimalSeparator))
+	} else {
+		fmt.Fprintf(declarationBuilder, "\treturn []byte(%s), nil\n", format)
+	}
+	fmt.Fprintf(declarationBuilder, "}\n")
+	options.declarations[typeName] = declarationBuilder.String()
+
+	return typeName
+}
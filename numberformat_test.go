@@ -0,0 +1,43 @@
+package xmlstruct
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestNumberFormatNormalize(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name         string
+		numberFormat NumberFormat
+		s            string
+		expected     string
+		expectedOK   bool
+	}{
+		{name: "default_float", s: "1.5", expected: "1.5", expectedOK: true},
+		{name: "default_exponent", s: "1e3", expected: "1e3", expectedOK: true},
+		{name: "default_disallow_exponents", numberFormat: NumberFormat{DisallowExponents: true}, s: "1e3"},
+		{name: "comma_int", numberFormat: NumberFormat{DecimalSeparator: ','}, s: "1234", expected: "1234", expectedOK: true},
+		{name: "comma_float", numberFormat: NumberFormat{DecimalSeparator: ','}, s: "1,5", expected: "1.5", expectedOK: true},
+		{name: "comma_dot", numberFormat: NumberFormat{DecimalSeparator: ','}, s: "1.5"},
+		{name: "comma_dot_and_comma", numberFormat: NumberFormat{DecimalSeparator: ','}, s: "1.234,5"},
+		{name: "comma_dot_in_fraction", numberFormat: NumberFormat{DecimalSeparator: ','}, s: "1,2.5"},
+		{name: "comma_space_thousands", numberFormat: NumberFormat{DecimalSeparator: ',', ThousandsSeparator: ' '}, s: "1 234,5", expected: "1234.5", expectedOK: true},
+		{name: "comma_space_thousands_dot", numberFormat: NumberFormat{DecimalSeparator: ',', ThousandsSeparator: ' '}, s: "1.5"},
+		{name: "comma_dot_thousands", numberFormat: NumberFormat{DecimalSeparator: ',', ThousandsSeparator: '.'}, s: "1.234,5", expected: "1234.5", expectedOK: true},
+		{name: "comma_dot_thousands_int", numberFormat: NumberFormat{DecimalSeparator: ',', ThousandsSeparator: '.'}, s: "-1.234.567", expected: "-1234567", expectedOK: true},
+		{name: "comma_dot_thousands_bad_group", numberFormat: NumberFormat{DecimalSeparator: ',', ThousandsSeparator: '.'}, s: "1.5"},
+		{name: "comma_dot_thousands_dot_in_fraction", numberFormat: NumberFormat{DecimalSeparator: ',', ThousandsSeparator: '.'}, s: "1,2.5"},
+		{name: "dot_comma_thousands", numberFormat: NumberFormat{ThousandsSeparator: ','}, s: "1,234.5", expected: "1234.5", expectedOK: true},
+		{name: "dot_comma_thousands_bad_group", numberFormat: NumberFormat{ThousandsSeparator: ','}, s: "1,5"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			actual, actualOK := tc.numberFormat.normalize(tc.s)
+			assert.Equal(t, tc.expectedOK, actualOK)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
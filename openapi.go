@@ -0,0 +1,235 @@
+package xmlstruct
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"time"
+)
+
+// An openAPIObject is a JSON object whose members are marshaled in order.
+type openAPIObject []openAPIMember
+
+// An openAPIMember is a member of an openAPIObject.
+type openAPIMember struct {
+	key   string
+	value any
+}
+
+// GenerateOpenAPIComponents returns the components section of an OpenAPI 3.1
+// document, in JSON, which is also valid YAML, with a schema for each type
+// needed to describe all the XML documents observed so far.
+//
+// Schemas have the same names as the types in the generated Go source, and
+// their properties are named like the fields of the generated Go source in
+// lowerCamelCase. xml objects record the element and attribute names, and
+// whether properties are attributes. Chardata of elements that also have
+// attributes or child elements is marked with the x-text extension. If the
+// Generator generates compact types then repeated elements in a container
+// element are wrapped arrays, and the container element has no schema. Nested
+// elements that would be anonymous structs in the generated Go source are
+// given their own schemas.
+func (g *Generator) GenerateOpenAPIComponents() ([]byte, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	options := g.generateOptions()
+
+	schemaTypes, typeNamesByElement, err := g.schemaTypes(options)
+	if err != nil {
+		return nil, err
+	}
+
+	referencedElements := make(map[*element]struct{})
+	wrapperElements := make(map[*element]struct{})
+	elementSchema := func(e *element) openAPIObject {
+		if typeName, ok := typeNamesByElement[e]; ok {
+			referencedElements[e] = struct{}{}
+			return openAPIObject{{"$ref", "#/components/schemas/" + typeName}}
+		}
+		return openAPIScalarSchema(&e.charDataValue, options)
+	}
+
+	schemas := make(openAPIObject, 0, len(schemaTypes))
+	schemaElements := make([]*element, 0, len(schemaTypes))
+	for _, schemaType := range schemaTypes {
+		schemaElements = append(schemaElements, schemaType.element)
+		if schemaType.scalar {
+			schema := openAPIScalarSchema(&schemaType.element.charDataValue, options)
+			schema = append(schema, openAPIMember{"xml", openAPIXML(schemaType.element.name, "", false, false)})
+			schemas = append(schemas, openAPIMember{schemaType.name, schema})
+			continue
+		}
+
+		fields, err := schemaType.element.fields(schemaType.path, options)
+		if err != nil {
+			return nil, err
+		}
+
+		var properties openAPIObject
+		var required []string
+		for _, field := range fields {
+			propertyName := lowerCamelCase(field.name)
+			var property openAPIObject
+			switch field.kind {
+			case attrFieldKind:
+				property = openAPIScalarSchema(field.value, options)
+				if field.repeated {
+					property = openAPIObject{{"type", "array"}, {"items", property}}
+				}
+				property = append(property, openAPIMember{"xml", openAPIXML(field.value.name, propertyName, true, false)})
+			case charDataFieldKind:
+				property = openAPIScalarSchema(field.value, options)
+				property = append(property, openAPIMember{"xml", openAPIObject{{"x-text", true}}})
+			case elemFieldKind:
+				wrappedElement := field.element
+				if options.compactTypes && field.element.isContainer() {
+					for _, childElement := range field.element.childElements {
+						if _, repeated := field.element.repeatedChildren[childElement.name]; repeated && childElement != field.element {
+							wrappedElement = childElement
+						}
+					}
+				}
+				switch {
+				case wrappedElement != field.element:
+					wrapperElements[field.element] = struct{}{}
+					items := elementSchema(wrappedElement)
+					if xmlObject := openAPIXML(wrappedElement.name, propertyName, false, false); len(xmlObject) > 0 {
+						items = append(items, openAPIMember{"xml", xmlObject})
+					}
+					property = openAPIObject{
+						{"type", "array"},
+						{"items", items},
+						{"xml", openAPIXML(field.element.name, "", false, true)},
+					}
+				case field.repeated:
+					items := elementSchema(field.element)
+					if xmlObject := openAPIXML(field.element.name, propertyName, false, false); len(xmlObject) > 0 {
+						items = append(items, openAPIMember{"xml", xmlObject})
+					}
+					property = openAPIObject{{"type", "array"}, {"items", items}}
+				default:
+					property = elementSchema(field.element)
+					if xmlObject := openAPIXML(field.element.name, propertyName, false, false); len(xmlObject) > 0 {
+						property = append(property, openAPIMember{"xml", xmlObject})
+					}
+				}
+			default:
+				continue
+			}
+			properties = append(properties, openAPIMember{propertyName, property})
+			if !field.optional {
+				required = append(required, propertyName)
+			}
+		}
+
+		schema := openAPIObject{{"type", "object"}}
+		if len(properties) > 0 {
+			schema = append(schema, openAPIMember{"properties", properties})
+		}
+		if len(required) > 0 {
+			schema = append(schema, openAPIMember{"required", required})
+		}
+		schema = append(schema, openAPIMember{"xml", openAPIXML(schemaType.element.name, "", false, false)})
+		schemas = append(schemas, openAPIMember{schemaType.name, schema})
+	}
+
+	// Omit the schemas of wrapper elements, which are described by their
+	// wrapped arrays, unless they are referenced elsewhere.
+	usedSchemas := schemas[:0]
+	for i, schema := range schemas {
+		if _, ok := wrapperElements[schemaElements[i]]; ok {
+			if _, ok := referencedElements[schemaElements[i]]; !ok {
+				continue
+			}
+		}
+		usedSchemas = append(usedSchemas, schema)
+	}
+
+	data, err := json.MarshalIndent(openAPIObject{
+		{"components", openAPIObject{
+			{"schemas", usedSchemas},
+		}},
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// openAPIScalarSchema returns the OpenAPI schema of values of v.
+func openAPIScalarSchema(v *value, options *generateOptions) openAPIObject {
+	kind, tolerant := v.resolvedKind(options)
+	switch {
+	case tolerant:
+		return openAPIObject{{"type", "string"}}
+	case kind == boolValueKind:
+		return openAPIObject{{"type", "boolean"}}
+	case (kind == intValueKind || kind == float64ValueKind) && options.numberFormat.localized():
+		return openAPIObject{{"type", "string"}}
+	case kind == intValueKind:
+		switch options.intType {
+		case "int32":
+			return openAPIObject{{"type", "integer"}, {"format", "int32"}}
+		case "int", "int64":
+			return openAPIObject{{"type", "integer"}, {"format", "int64"}}
+		default:
+			return openAPIObject{{"type", "integer"}}
+		}
+	case kind == float64ValueKind:
+		return openAPIObject{{"type", "number"}, {"format", "double"}}
+	case kind == timeValueKind:
+		switch options.timeLayout {
+		case DefaultTimeLayout, time.RFC3339, time.RFC3339Nano:
+			return openAPIObject{{"type", "string"}, {"format", "date-time"}}
+		case time.DateOnly:
+			return openAPIObject{{"type", "string"}, {"format", "date"}}
+		default:
+			return openAPIObject{{"type", "string"}}
+		}
+	default:
+		return openAPIObject{{"type", "string"}}
+	}
+}
+
+// openAPIXML returns an OpenAPI xml object for name. The name is omitted if it
+// is propertyName.
+func openAPIXML(name xml.Name, propertyName string, attribute, wrapped bool) openAPIObject {
+	var xmlObject openAPIObject
+	if name.Local != propertyName {
+		xmlObject = append(xmlObject, openAPIMember{"name", name.Local})
+	}
+	if name.Space != "" {
+		xmlObject = append(xmlObject, openAPIMember{"namespace", name.Space})
+	}
+	if attribute {
+		xmlObject = append(xmlObject, openAPIMember{"attribute", true})
+	}
+	if wrapped {
+		xmlObject = append(xmlObject, openAPIMember{"wrapped", true})
+	}
+	return xmlObject
+}
+
+// MarshalJSON implements encoding/json.Marshaler.
+func (o openAPIObject) MarshalJSON() ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	buffer.WriteByte('{')
+	for i, member := range o {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		key, err := json.Marshal(member.key)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(key)
+		buffer.WriteByte(':')
+		value, err := json.Marshal(member.value)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(value)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
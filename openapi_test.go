@@ -0,0 +1,147 @@
+package xmlstruct_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestGenerateOpenAPIComponents(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name        string
+		xmlStrs     []string
+		options     []xmlstruct.GeneratorOption
+		expectedStr string
+	}{
+		{
+			name: "simple",
+			xmlStrs: []string{
+				`<a id="1"><b when="2024-01-02T03:04:05Z">1.5</b><b>2</b><c-d>true</c-d></a>`,
+				`<a id="2"/>`,
+			},
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithTopLevelAttributes(true),
+			},
+			expectedStr: joinLines(
+				`{`,
+				`  "components": {`,
+				`    "schemas": {`,
+				`      "A": {`,
+				`        "type": "object",`,
+				`        "properties": {`,
+				`          "id": {`,
+				`            "type": "integer",`,
+				`            "format": "int64",`,
+				`            "xml": {`,
+				`              "attribute": true`,
+				`            }`,
+				`          },`,
+				`          "b": {`,
+				`            "type": "array",`,
+				`            "items": {`,
+				`              "$ref": "#/components/schemas/B"`,
+				`            }`,
+				`          },`,
+				`          "cd": {`,
+				`            "type": "boolean",`,
+				`            "xml": {`,
+				`              "name": "c-d"`,
+				`            }`,
+				`          }`,
+				`        },`,
+				`        "required": [`,
+				`          "id"`,
+				`        ],`,
+				`        "xml": {`,
+				`          "name": "a"`,
+				`        }`,
+				`      },`,
+				`      "B": {`,
+				`        "type": "object",`,
+				`        "properties": {`,
+				`          "when": {`,
+				`            "type": "string",`,
+				`            "format": "date-time",`,
+				`            "xml": {`,
+				`              "attribute": true`,
+				`            }`,
+				`          },`,
+				`          "charData": {`,
+				`            "type": "number",`,
+				`            "format": "double",`,
+				`            "xml": {`,
+				`              "x-text": true`,
+				`            }`,
+				`          }`,
+				`        },`,
+				`        "required": [`,
+				`          "charData"`,
+				`        ],`,
+				`        "xml": {`,
+				`          "name": "b"`,
+				`        }`,
+				`      }`,
+				`    }`,
+				`  }`,
+				`}`,
+			),
+		},
+		{
+			name: "compact_types_wrapped",
+			xmlStrs: []string{
+				`<a><items><item>x</item><item>y</item></items></a>`,
+			},
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithCompactTypes(true),
+			},
+			expectedStr: joinLines(
+				`{`,
+				`  "components": {`,
+				`    "schemas": {`,
+				`      "A": {`,
+				`        "type": "object",`,
+				`        "properties": {`,
+				`          "item": {`,
+				`            "type": "array",`,
+				`            "items": {`,
+				`              "type": "string"`,
+				`            },`,
+				`            "xml": {`,
+				`              "name": "items",`,
+				`              "wrapped": true`,
+				`            }`,
+				`          }`,
+				`        },`,
+				`        "required": [`,
+				`          "item"`,
+				`        ],`,
+				`        "xml": {`,
+				`          "name": "a"`,
+				`        }`,
+				`      }`,
+				`    }`,
+				`  }`,
+				`}`,
+			),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			generator := xmlstruct.NewGenerator(tc.options...)
+			for _, xmlStr := range tc.xmlStrs {
+				assert.NoError(t, generator.ObserveReader(strings.NewReader(xmlStr)))
+			}
+			actual, err := generator.GenerateOpenAPIComponents()
+			assert.NoError(t, err)
+			assert.True(t, json.Valid(actual))
+			assert.Equal(t, tc.expectedStr, string(actual))
+		})
+	}
+}
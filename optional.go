@@ -0,0 +1,143 @@
+package xmlstruct
+
+import (
+	"fmt"
+	"strings"
+)
+
+// optionalPackagePath is the import path of the optional package.
+const optionalPackagePath = "github.com/twpayne/go-xmlstruct/optional"
+
+// An OptionalScalarWrapper is a generic type that is used for optional scalar
+// fields instead of a pointer.
+type OptionalScalarWrapper int
+
+const (
+	// OptionalScalarWrapperNone does not wrap optional scalar fields, which
+	// use pointers according to PointerPolicy.OptionalScalars.
+	OptionalScalarWrapperNone OptionalScalarWrapper = iota
+	// OptionalScalarWrapperGeneric wraps optional scalar fields in a generated
+	// generic Optional[T] type with Value and Valid fields.
+	OptionalScalarWrapperGeneric
+	// OptionalScalarWrapperSQLNull wraps optional scalar fields in a generated
+	// generic Null[T] type that embeds database/sql.Null[T], so it has V and
+	// Valid fields and can also be used with database/sql.
+	OptionalScalarWrapperSQLNull
+	// OptionalScalarWrapperPackage wraps optional scalar fields in the
+	// Optional[T] type of the github.com/twpayne/go-xmlstruct/optional
+	// package, so no wrapper type is generated.
+	OptionalScalarWrapperPackage
+)
+
+// optionalScalarGoFieldType returns fieldType, the type of an optional scalar
+// field, as a pointer or wrapped according to options.pointerPolicy.
+func (options *generateOptions) optionalScalarGoFieldType(fieldType goFieldType) goFieldType {
+	var typeName, valueField string
+	switch options.pointerPolicy.OptionalScalarWrapper {
+	case OptionalScalarWrapperGeneric:
+		typeName, valueField = "Optional", "Value"
+	case OptionalScalarWrapperSQLNull:
+		typeName, valueField = "Null", "V"
+	case OptionalScalarWrapperPackage:
+		options.importPackageNames[optionalPackagePath] = ""
+		fieldType.wrappedName = fieldType.name
+		fieldType.wrappedValueField = "Value"
+		fieldType.name = "optional.Optional[" + fieldType.name + "]"
+		return fieldType
+	default:
+		fieldType.pointer = options.pointerPolicy.OptionalScalars
+		return fieldType
+	}
+	if _, ok := options.declarations[typeName]; !ok {
+		options.declarations[typeName] = options.optionalScalarWrapperDeclaration(typeName, valueField)
+	}
+	fieldType.wrappedName = fieldType.name
+	fieldType.wrappedValueField = valueField
+	fieldType.name = typeName + "[" + fieldType.name + "]"
+	return fieldType
+}
+
+// optionalScalarWrapperDeclaration returns the declaration of the generic
+// optional scalar wrapper type typeName, whose value is in the field
+// valueField, and its methods, and adds the packages that it uses to options.
+func (options *generateOptions) optionalScalarWrapperDeclaration(typeName, valueField string) string {
+	options.importPackageNames["bytes"] = ""
+	options.importPackageNames["encoding/xml"] = ""
+
+	declarationBuilder := &strings.Builder{}
+	if options.pointerPolicy.OptionalScalarWrapper == OptionalScalarWrapperSQLNull {
+		options.importPackageNames["database/sql"] = ""
+		fmt.Fprintf(declarationBuilder, "// %s is an optional XML attribute or element value of type T that can also\n", typeName)
+		fmt.Fprintf(declarationBuilder, "// be used with database/sql.\n")
+		fmt.Fprintf(declarationBuilder, "type %s[T any] struct {\n", typeName)
+		fmt.Fprintf(declarationBuilder, "\tsql.Null[T]\n")
+		fmt.Fprintf(declarationBuilder, "}\n")
+	} else {
+		fmt.Fprintf(declarationBuilder, "// %s is an optional XML attribute or element value of type T.\n", typeName)
+		fmt.Fprintf(declarationBuilder, "type %s[T any] struct {\n", typeName)
+		fmt.Fprintf(declarationBuilder, "\t%s T\n", valueField)
+		fmt.Fprintf(declarationBuilder, "\tValid bool\n")
+		fmt.Fprintf(declarationBuilder, "}\n")
+	}
+	fmt.Fprintf(declarationBuilder, "\n")
+	fmt.Fprintf(declarationBuilder, "// UnmarshalXML implements encoding/xml.Unmarshaler.\n")
+	fmt.Fprintf(declarationBuilder, "func (o *%s[T]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {\n", typeName)
+	fmt.Fprintf(declarationBuilder, "\tif err := d.DecodeElement(&o.%s, &start); err != nil {\n", valueField)
+	fmt.Fprintf(declarationBuilder, "\t\treturn err\n")
+	fmt.Fprintf(declarationBuilder, "\t}\n")
+	fmt.Fprintf(declarationBuilder, "\to.Valid = true\n")
+	fmt.Fprintf(declarationBuilder, "\treturn nil\n")
+	fmt.Fprintf(declarationBuilder, "}\n")
+	fmt.Fprintf(declarationBuilder, "\n")
+	fmt.Fprintf(declarationBuilder, "// UnmarshalXMLAttr implements encoding/xml.UnmarshalerAttr. The value is\n")
+	fmt.Fprintf(declarationBuilder, "// unmarshaled by encoding/xml, as if it were not wrapped.\n")
+	fmt.Fprintf(declarationBuilder, "func (o *%s[T]) UnmarshalXMLAttr(attr xml.Attr) error {\n", typeName)
+	fmt.Fprintf(declarationBuilder, "\tbuffer := &bytes.Buffer{}\n")
+	fmt.Fprintf(declarationBuilder, "\tbuffer.WriteString(`<v v=\"`)\n")
+	fmt.Fprintf(declarationBuilder, "\tif err := xml.EscapeText(buffer, []byte(attr.Value)); err != nil {\n")
+	fmt.Fprintf(declarationBuilder, "\t\treturn err\n")
+	fmt.Fprintf(declarationBuilder, "\t}\n")
+	fmt.Fprintf(declarationBuilder, "\tbuffer.WriteString(`\"/>`)\n")
+	fmt.Fprintf(declarationBuilder, "\tvar v struct {\n")
+	fmt.Fprintf(declarationBuilder, "\t\tValue T `xml:\"v,attr\"`\n")
+	fmt.Fprintf(declarationBuilder, "\t}\n")
+	fmt.Fprintf(declarationBuilder, "\tif err := xml.Unmarshal(buffer.Bytes(), &v); err != nil {\n")
+	fmt.Fprintf(declarationBuilder, "\t\treturn err\n")
+	fmt.Fprintf(declarationBuilder, "\t}\n")
+	fmt.Fprintf(declarationBuilder, "\to.%s, o.Valid = v.Value, true\n", valueField)
+	fmt.Fprintf(declarationBuilder, "\treturn nil\n")
+	fmt.Fprintf(declarationBuilder, "}\n")
+	fmt.Fprintf(declarationBuilder, "\n")
+	fmt.Fprintf(declarationBuilder, "// MarshalXML implements encoding/xml.Marshaler. Invalid values are omitted.\n")
+	fmt.Fprintf(declarationBuilder, "func (o %s[T]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {\n", typeName)
+	fmt.Fprintf(declarationBuilder, "\tif !o.Valid {\n")
+	fmt.Fprintf(declarationBuilder, "\t\treturn nil\n")
+	fmt.Fprintf(declarationBuilder, "\t}\n")
+	fmt.Fprintf(declarationBuilder, "\treturn e.EncodeElement(o.%s, start)\n", valueField)
+	fmt.Fprintf(declarationBuilder, "}\n")
+	fmt.Fprintf(declarationBuilder, "\n")
+	fmt.Fprintf(declarationBuilder, "// MarshalXMLAttr implements encoding/xml.MarshalerAttr. Invalid values are\n")
+	fmt.Fprintf(declarationBuilder, "// omitted. The value is marshaled by encoding/xml, as if it were not wrapped.\n")
+	fmt.Fprintf(declarationBuilder, "func (o %s[T]) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {\n", typeName)
+	fmt.Fprintf(declarationBuilder, "\tif !o.Valid {\n")
+	fmt.Fprintf(declarationBuilder, "\t\treturn xml.Attr{}, nil\n")
+	fmt.Fprintf(declarationBuilder, "\t}\n")
+	fmt.Fprintf(declarationBuilder, "\tdata, err := xml.Marshal(struct {\n")
+	fmt.Fprintf(declarationBuilder, "\t\tXMLName xml.Name `xml:\"v\"`\n")
+	fmt.Fprintf(declarationBuilder, "\t\tValue   T        `xml:\"v,attr\"`\n")
+	fmt.Fprintf(declarationBuilder, "\t}{\n")
+	fmt.Fprintf(declarationBuilder, "\t\tValue: o.%s,\n", valueField)
+	fmt.Fprintf(declarationBuilder, "\t})\n")
+	fmt.Fprintf(declarationBuilder, "\tif err != nil {\n")
+	fmt.Fprintf(declarationBuilder, "\t\treturn xml.Attr{}, err\n")
+	fmt.Fprintf(declarationBuilder, "\t}\n")
+	fmt.Fprintf(declarationBuilder, "\tvar v struct {\n")
+	fmt.Fprintf(declarationBuilder, "\t\tValue string `xml:\"v,attr\"`\n")
+	fmt.Fprintf(declarationBuilder, "\t}\n")
+	fmt.Fprintf(declarationBuilder, "\tif err := xml.Unmarshal(data, &v); err != nil {\n")
+	fmt.Fprintf(declarationBuilder, "\t\treturn xml.Attr{}, err\n")
+	fmt.Fprintf(declarationBuilder, "\t}\n")
+	fmt.Fprintf(declarationBuilder, "\treturn xml.Attr{Name: name, Value: v.Value}, nil\n")
+	fmt.Fprintf(declarationBuilder, "}\n")
+	return declarationBuilder.String()
+}
@@ -0,0 +1,101 @@
+// Package optional provides a generic optional value type that can be
+// unmarshaled from and marshaled to XML attributes and elements.
+//
+// It is the runtime companion of the Go types generated by go-xmlstruct with
+// xmlstruct.OptionalScalarWrapperPackage, so that generated code can avoid
+// pointers to scalars without depending on any third party packages.
+package optional
+
+import (
+	"bytes"
+	"encoding/xml"
+)
+
+// An Optional is an optional value of type T. The zero value is an invalid,
+// that is absent, value.
+type Optional[T any] struct {
+	Value T
+	Valid bool
+}
+
+// New returns a valid Optional containing value.
+func New[T any](value T) Optional[T] {
+	return Optional[T]{
+		Value: value,
+		Valid: true,
+	}
+}
+
+// Get returns o's value and whether it is valid.
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Valid
+}
+
+// ValueOr returns o's value if it is valid, otherwise it returns
+// defaultValue.
+func (o Optional[T]) ValueOr(defaultValue T) T {
+	if !o.Valid {
+		return defaultValue
+	}
+	return o.Value
+}
+
+// UnmarshalXML implements encoding/xml.Unmarshaler.
+func (o *Optional[T]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if err := d.DecodeElement(&o.Value, &start); err != nil {
+		return err
+	}
+	o.Valid = true
+	return nil
+}
+
+// UnmarshalXMLAttr implements encoding/xml.UnmarshalerAttr. The value is
+// unmarshaled by encoding/xml, as if it were not wrapped.
+func (o *Optional[T]) UnmarshalXMLAttr(attr xml.Attr) error {
+	buffer := &bytes.Buffer{}
+	buffer.WriteString(`<v v="`)
+	if err := xml.EscapeText(buffer, []byte(attr.Value)); err != nil {
+		return err
+	}
+	buffer.WriteString(`"/>`)
+	var v struct {
+		Value T `xml:"v,attr"`
+	}
+	if err := xml.Unmarshal(buffer.Bytes(), &v); err != nil {
+		return err
+	}
+	o.Value, o.Valid = v.Value, true
+	return nil
+}
+
+// MarshalXML implements encoding/xml.Marshaler. Invalid values are omitted.
+func (o Optional[T]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if !o.Valid {
+		return nil
+	}
+	return e.EncodeElement(o.Value, start)
+}
+
+// MarshalXMLAttr implements encoding/xml.MarshalerAttr. Invalid values are
+// omitted. The value is marshaled by encoding/xml, as if it were not wrapped.
+func (o Optional[T]) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	if !o.Valid {
+		return xml.Attr{}, nil
+	}
+	data, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"v"`
+		Value   T        `xml:"v,attr"`
+	}{
+		Value: o.Value,
+	})
+	if err != nil {
+		return xml.Attr{}, err
+	}
+	var v struct {
+		Value string `xml:"v,attr"`
+	}
+	if err := xml.Unmarshal(data, &v); err != nil {
+		return xml.Attr{}, err
+	}
+	return xml.Attr{Name: name, Value: v.Value}, nil
+}
@@ -0,0 +1,81 @@
+package optional_test
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct/optional"
+)
+
+type record struct {
+	XMLName xml.Name                     `xml:"record"`
+	ID      optional.Optional[int]       `xml:"id,attr"`
+	Name    optional.Optional[string]    `xml:"name,attr"`
+	Count   optional.Optional[int]       `xml:"count"`
+	When    optional.Optional[time.Time] `xml:"when"`
+}
+
+func TestOptional(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		xmlStr   string
+		expected record
+	}{
+		{
+			name:   "present",
+			xmlStr: `<record id="1" name="a&#34;b"><count>2</count><when>2024-01-02T03:04:05Z</when></record>`,
+			expected: record{
+				XMLName: xml.Name{Local: "record"},
+				ID:      optional.New(1),
+				Name:    optional.New(`a"b`),
+				Count:   optional.New(2),
+				When:    optional.New(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+			},
+		},
+		{
+			name:   "absent",
+			xmlStr: `<record></record>`,
+			expected: record{
+				XMLName: xml.Name{Local: "record"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var actual record
+			assert.NoError(t, xml.Unmarshal([]byte(tc.xmlStr), &actual))
+			assert.Equal(t, tc.expected, actual)
+
+			data, err := xml.Marshal(actual)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.xmlStr, string(data))
+		})
+	}
+}
+
+func TestOptionalUnmarshalXMLAttrError(t *testing.T) {
+	t.Parallel()
+
+	var actual record
+	assert.Error(t, xml.Unmarshal([]byte(`<record id="x"></record>`), &actual))
+}
+
+func TestOptionalGet(t *testing.T) {
+	t.Parallel()
+
+	value, ok := optional.New("a").Get()
+	assert.Equal(t, "a", value)
+	assert.True(t, ok)
+
+	var absent optional.Optional[string]
+	_, ok = absent.Get()
+	assert.False(t, ok)
+	assert.Equal(t, "b", absent.ValueOr("b"))
+	assert.Equal(t, "a", optional.New("a").ValueOr("b"))
+}
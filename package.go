@@ -0,0 +1,243 @@
+package xmlstruct
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A Package combines the Go source generated by several Generators, for
+// example one for each of several XML feeds, into a single package.
+type Package struct {
+	header      string
+	packageName string
+	generators  []*packageGenerator
+}
+
+// A packageGenerator is a Generator added to a Package.
+type packageGenerator struct {
+	prefix    string
+	generator *Generator
+}
+
+// A PackageOption sets an option on a Package.
+type PackageOption func(*Package)
+
+// WithPackageHeader sets the header of the Go source generated by a Package.
+// The headers of the Package's Generators are ignored.
+func WithPackageHeader(header string) PackageOption {
+	return func(p *Package) {
+		p.header = header
+	}
+}
+
+// NewPackage returns a new Package with the given package name and options.
+func NewPackage(packageName string, options ...PackageOption) *Package {
+	p := &Package{
+		header:      DefaultHeader,
+		packageName: packageName,
+	}
+	for _, option := range options {
+		option(p)
+	}
+	return p
+}
+
+// Add adds the Go source generated by g to p. prefix is prepended to the
+// names of g's types whose names are also used by types of other Generators
+// in p, and to all references to them.
+func (p *Package) Add(prefix string, g *Generator) {
+	p.generators = append(p.generators, &packageGenerator{
+		prefix:    prefix,
+		generator: g,
+	})
+}
+
+// A packageSource is the Go source generated by a Generator in a Package.
+type packageSource struct {
+	prefix    string
+	fileSet   *token.FileSet
+	file      *ast.File
+	source    []byte
+	typeNames []string
+}
+
+// Generate returns the Go source of p, which contains the types of all of the
+// Generators added to p. Type names that are used by more than one Generator
+// are prefixed with their Generator's prefix, in declarations, references,
+// and doc comments. The Generators' build tags are ignored.
+func (p *Package) Generate() ([]byte, error) {
+	if p.packageName == "" {
+		return nil, errors.New("empty package name")
+	}
+
+	packageSources := make([]*packageSource, 0, len(p.generators))
+	typeNameCounts := make(map[string]int)
+	importPackageNames := make(map[string]string)
+	for _, packageGenerator := range p.generators {
+		packageSource, err := p.generateSource(packageGenerator)
+		if err != nil {
+			return nil, err
+		}
+		for _, typeName := range packageSource.typeNames {
+			typeNameCounts[typeName]++
+		}
+		for _, importSpec := range packageSource.file.Imports {
+			importPath, err := strconv.Unquote(importSpec.Path.Value)
+			if err != nil {
+				return nil, err
+			}
+			importAlias := ""
+			if importSpec.Name != nil {
+				importAlias = importSpec.Name.Name
+			}
+			if existingImportAlias, ok := importPackageNames[importPath]; ok && existingImportAlias != importAlias {
+				return nil, fmt.Errorf("%s: conflicting import aliases", importPath)
+			}
+			importPackageNames[importPath] = importAlias
+		}
+		packageSources = append(packageSources, packageSource)
+	}
+
+	typeNames := make(map[string]struct{})
+	typesBuilder := &strings.Builder{}
+	for _, packageSource := range packageSources {
+		collidingTypeNames := make(map[string]struct{})
+		for _, typeName := range packageSource.typeNames {
+			if typeNameCounts[typeName] > 1 {
+				collidingTypeNames[typeName] = struct{}{}
+				typeName = packageSource.prefix + typeName
+			}
+			if _, ok := typeNames[typeName]; ok {
+				return nil, fmt.Errorf("%s: duplicate type name", typeName)
+			}
+			typeNames[typeName] = struct{}{}
+		}
+		typesBuilder.WriteString(packageSource.renamedTypes(collidingTypeNames))
+	}
+
+	sourceBuilder := &strings.Builder{}
+	writeSourceHeader(sourceBuilder, p.header, "")
+	fmt.Fprintf(sourceBuilder, "package %s\n", p.packageName)
+	writeImports(sourceBuilder, importPackageNames)
+	sourceBuilder.WriteString(typesBuilder.String())
+
+	source := []byte(sourceBuilder.String())
+	if formattedSource, err := format.Source(source); err == nil {
+		source = formattedSource
+	}
+	return source, nil
+}
+
+// generateSource returns the Go source generated by packageGenerator, parsed.
+func (p *Package) generateSource(packageGenerator *packageGenerator) (*packageSource, error) {
+	g := packageGenerator.generator
+	options := g.generateOptions()
+	options.header = ""
+	options.packageName = p.packageName
+	source, err := g.generate(options)
+	if err != nil {
+		return nil, err
+	}
+
+	fileSet := token.NewFileSet()
+	file, err := parser.ParseFile(fileSet, "", source, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var typeNames []string
+	for _, decl := range file.Decls {
+		if genDecl, ok := decl.(*ast.GenDecl); ok && genDecl.Tok == token.TYPE {
+			for _, spec := range genDecl.Specs {
+				typeNames = append(typeNames, spec.(*ast.TypeSpec).Name.Name)
+			}
+		}
+	}
+
+	return &packageSource{
+		prefix:    packageGenerator.prefix,
+		fileSet:   fileSet,
+		file:      file,
+		source:    source,
+		typeNames: typeNames,
+	}, nil
+}
+
+// renamedTypes returns the source of s's declarations, excluding its package
+// clause and imports, with s's prefix prepended to all identifiers and doc
+// comments that refer to the types in typeNames.
+func (s *packageSource) renamedTypes(typeNames map[string]struct{}) string {
+	start := s.file.Name.End()
+	for _, decl := range s.file.Decls {
+		if genDecl, ok := decl.(*ast.GenDecl); ok && genDecl.Tok == token.IMPORT {
+			start = genDecl.End()
+		}
+	}
+
+	// Identifiers that are not type references, like field names, method
+	// names, and selectors, are not renamed.
+	notTypeReferences := make(map[*ast.Ident]struct{})
+	ast.Inspect(s.file, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Field:
+			for _, name := range node.Names {
+				notTypeReferences[name] = struct{}{}
+			}
+		case *ast.FuncDecl:
+			notTypeReferences[node.Name] = struct{}{}
+		case *ast.KeyValueExpr:
+			if key, ok := node.Key.(*ast.Ident); ok {
+				notTypeReferences[key] = struct{}{}
+			}
+		case *ast.SelectorExpr:
+			notTypeReferences[node.Sel] = struct{}{}
+		}
+		return true
+	})
+
+	var offsets []int
+	ast.Inspect(s.file, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok && ident.Pos() > start {
+			if _, ok := notTypeReferences[ident]; ok {
+				return true
+			}
+			if _, ok := typeNames[ident.Name]; ok {
+				offsets = append(offsets, s.fileSet.Position(ident.Pos()).Offset)
+			}
+		}
+		return true
+	})
+	for _, commentGroup := range s.file.Comments {
+		for _, comment := range commentGroup.List {
+			if comment.Pos() < start {
+				continue
+			}
+			text, ok := strings.CutPrefix(comment.Text, "// ")
+			if !ok {
+				continue
+			}
+			typeName, _, _ := strings.Cut(text, " ")
+			if _, ok := typeNames[typeName]; ok {
+				offsets = append(offsets, s.fileSet.Position(comment.Pos()).Offset+len("// "))
+			}
+		}
+	}
+	slices.Sort(offsets)
+
+	typesBuilder := &strings.Builder{}
+	offset := s.fileSet.Position(start).Offset
+	for _, renameOffset := range offsets {
+		typesBuilder.Write(s.source[offset:renameOffset])
+		typesBuilder.WriteString(s.prefix)
+		offset = renameOffset
+	}
+	typesBuilder.Write(s.source[offset:])
+	return typesBuilder.String()
+}
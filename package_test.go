@@ -0,0 +1,123 @@
+package xmlstruct_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestPackage(t *testing.T) {
+	t.Parallel()
+
+	orders := xmlstruct.NewGenerator(
+		xmlstruct.WithNamedTypes(true),
+		xmlstruct.WithValueExamples(1),
+	)
+	assert.NoError(t, orders.ObserveReader(strings.NewReader(`<order><item id="1"/><date>2024-01-02T03:04:05Z</date></order>`)))
+
+	inventory := xmlstruct.NewGenerator(
+		xmlstruct.WithNamedTypes(true),
+		xmlstruct.WithMapElements(map[string]string{
+			"item": "sku",
+		}),
+	)
+	assert.NoError(t, inventory.ObserveReader(strings.NewReader(`<inventory><item sku="a">1</item></inventory>`)))
+
+	item := xmlstruct.NewGenerator()
+	assert.NoError(t, item.ObserveReader(strings.NewReader(`<item><name>x</name></item>`)))
+
+	p := xmlstruct.NewPackage("models")
+	p.Add("Orders", orders)
+	p.Add("Inventory", inventory)
+	p.Add("Catalog", item)
+	actual, err := p.Generate()
+	assert.NoError(t, err)
+	assert.Equal(t, joinLines(
+		"// Code generated by go-xmlstruct. DO NOT EDIT.",
+		"",
+		"package models",
+		"",
+		"import (",
+		"\t\"encoding/xml\"",
+		"\t\"sort\"",
+		"\t\"time\"",
+		")",
+		"",
+		"type OrdersItem struct {",
+		"\tID int `xml:\"id,attr\"` // Examples: \"1\"",
+		"}",
+		"",
+		"type Order struct {",
+		"\tDate time.Time  `xml:\"date\"` // Examples: \"2024-01-02T03:04:05Z\"",
+		"\tItem OrdersItem `xml:\"item\"`",
+		"}",
+		"",
+		"type Inventory struct {",
+		"\tItem ItemMap `xml:\"item\"`",
+		"}",
+		"",
+		"// ItemMap is a map of item elements' chardata keyed by their sku attribute.",
+		"type ItemMap map[string]string",
+		"",
+		"// UnmarshalXML implements encoding/xml.Unmarshaler.",
+		"func (m *ItemMap) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {",
+		"\tvar entry struct {",
+		"\t\tKey   string `xml:\"sku,attr\"`",
+		"\t\tValue string `xml:\",chardata\"`",
+		"\t}",
+		"\tif err := d.DecodeElement(&entry, &start); err != nil {",
+		"\t\treturn err",
+		"\t}",
+		"\tif *m == nil {",
+		"\t\t*m = make(ItemMap)",
+		"\t}",
+		"\t(*m)[entry.Key] = entry.Value",
+		"\treturn nil",
+		"}",
+		"",
+		"// MarshalXML implements encoding/xml.Marshaler. Entries are marshaled in",
+		"// key order.",
+		"func (m ItemMap) MarshalXML(e *xml.Encoder, start xml.StartElement) error {",
+		"\tkeys := make([]string, 0, len(m))",
+		"\tfor key := range m {",
+		"\t\tkeys = append(keys, key)",
+		"\t}",
+		"\tsort.Strings(keys)",
+		"\tfor _, key := range keys {",
+		"\t\tentry := struct {",
+		"\t\t\tKey   string `xml:\"sku,attr\"`",
+		"\t\t\tValue string `xml:\",chardata\"`",
+		"\t\t}{",
+		"\t\t\tKey:   key,",
+		"\t\t\tValue: m[key],",
+		"\t\t}",
+		"\t\tif err := e.EncodeElement(entry, start); err != nil {",
+		"\t\t\treturn err",
+		"\t\t}",
+		"\t}",
+		"\treturn nil",
+		"}",
+		"",
+		"type CatalogItem struct {",
+		"\tName string `xml:\"name\"`",
+		"}",
+	), string(actual))
+}
+
+func TestPackageDuplicateTypeName(t *testing.T) {
+	t.Parallel()
+
+	a := xmlstruct.NewGenerator()
+	assert.NoError(t, a.ObserveReader(strings.NewReader(`<item/>`)))
+	b := xmlstruct.NewGenerator()
+	assert.NoError(t, b.ObserveReader(strings.NewReader(`<item/>`)))
+
+	p := xmlstruct.NewPackage("models")
+	p.Add("X", a)
+	p.Add("X", b)
+	_, err := p.Generate()
+	assert.EqualError(t, err, "XItem: duplicate type name")
+}
@@ -0,0 +1,74 @@
+package xmlstruct
+
+import (
+	"fmt"
+	"io"
+)
+
+// rootRegistryName is the name of the generated registry of root types.
+const rootRegistryName = "Roots"
+
+// WithRootRegistry sets whether to generate a Roots variable that maps the
+// names of top level elements to functions that return pointers to new values
+// of their types, and a DecodeAny function that decodes a document into a new
+// value of the type of its top level element. This allows heterogeneous
+// documents to be decoded without knowing their types in advance.
+func WithRootRegistry(rootRegistry bool) GeneratorOption {
+	return func(g *Generator) {
+		g.rootRegistry = rootRegistry
+	}
+}
+
+// writeRootRegistry writes the Roots variable and DecodeAny function for the
+// root elements in typeElements to w.
+func writeRootRegistry(w io.Writer, typeElements []*element, typeNames map[string]struct{}, options *generateOptions) error {
+	if _, ok := typeNames[rootRegistryName]; ok {
+		return fmt.Errorf("%s: duplicate type name", rootRegistryName)
+	}
+
+	options.importPackageNames["encoding/xml"] = ""
+	options.importPackageNames["fmt"] = ""
+	options.importPackageNames["io"] = ""
+
+	fmt.Fprintf(w, "\n// %s maps the names of top level elements to functions that return\n", rootRegistryName)
+	fmt.Fprintf(w, "// pointers to new values of their types.\n")
+	fmt.Fprintf(w, "var %s = map[xml.Name]func() any{\n", rootRegistryName)
+	for _, typeElement := range typeElements {
+		if !typeElement.root {
+			continue
+		}
+		typeName := options.exportTypeNameFunc(typeElement.name)
+		fmt.Fprintf(w, "\t{Space: %q, Local: %q}: func() any { return new(%s) },\n", typeElement.name.Space, typeElement.name.Local, typeName)
+	}
+	fmt.Fprintf(w, "}\n")
+
+	fmt.Fprintf(w, "\n// DecodeAny returns a pointer to a new value of the type of the top level\n")
+	fmt.Fprintf(w, "// element of the document read from r, decoded from r. Elements are looked\n")
+	fmt.Fprintf(w, "// up in %s by their name, and then by their local name only.\n", rootRegistryName)
+	fmt.Fprintf(w, "func DecodeAny(r io.Reader) (any, error) {\n")
+	fmt.Fprintf(w, "\tdecoder := xml.NewDecoder(r)\n")
+	fmt.Fprintf(w, "\tfor {\n")
+	fmt.Fprintf(w, "\t\ttoken, err := decoder.Token()\n")
+	fmt.Fprintf(w, "\t\tif err != nil {\n")
+	fmt.Fprintf(w, "\t\t\treturn nil, err\n")
+	fmt.Fprintf(w, "\t\t}\n")
+	fmt.Fprintf(w, "\t\tstartElement, ok := token.(xml.StartElement)\n")
+	fmt.Fprintf(w, "\t\tif !ok {\n")
+	fmt.Fprintf(w, "\t\t\tcontinue\n")
+	fmt.Fprintf(w, "\t\t}\n")
+	fmt.Fprintf(w, "\t\tnewRoot, ok := %s[startElement.Name]\n", rootRegistryName)
+	fmt.Fprintf(w, "\t\tif !ok {\n")
+	fmt.Fprintf(w, "\t\t\tnewRoot, ok = %s[xml.Name{Local: startElement.Name.Local}]\n", rootRegistryName)
+	fmt.Fprintf(w, "\t\t}\n")
+	fmt.Fprintf(w, "\t\tif !ok {\n")
+	fmt.Fprintf(w, "\t\t\treturn nil, fmt.Errorf(\"%%s: unknown top level element\", startElement.Name.Local)\n")
+	fmt.Fprintf(w, "\t\t}\n")
+	fmt.Fprintf(w, "\t\tv := newRoot()\n")
+	fmt.Fprintf(w, "\t\tif err := decoder.DecodeElement(v, &startElement); err != nil {\n")
+	fmt.Fprintf(w, "\t\t\treturn nil, err\n")
+	fmt.Fprintf(w, "\t\t}\n")
+	fmt.Fprintf(w, "\t\treturn v, nil\n")
+	fmt.Fprintf(w, "\t}\n")
+	fmt.Fprintf(w, "}\n")
+	return nil
+}
@@ -0,0 +1,118 @@
+package xmlstruct
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// A schemaType is a named type in a schema language that, unlike Go, does not
+// support anonymous types.
+type schemaType struct {
+	name    string
+	element *element
+	path    string
+	scalar  bool
+}
+
+// schemaTypes returns the named types needed to describe every observed
+// element that is not a simple value, in order, and a map from each element to
+// the name of its type.
+//
+// Top level elements are named with options.exportTypeNameFunc. Nested elements
+// that would be anonymous structs in the generated Go source are named after
+// the element, prefixed with the name of their parent's type if the name is
+// already taken.
+func (g *Generator) schemaTypes(options *generateOptions) ([]*schemaType, map[*element]string, error) {
+	var schemaTypes []*schemaType
+	typeNamesByElement := make(map[*element]string)
+	elementsByTypeName := make(map[string]*element)
+
+	var addNestedTypes func(*element, string, string) error
+	addNestedTypes = func(e *element, path, parentTypeName string) error {
+		for _, childElement := range e.sortedChildElements(options) {
+			if childElement.isSimple() {
+				continue
+			}
+			if _, ok := typeNamesByElement[childElement]; ok {
+				continue
+			}
+			if _, ok := options.namedTypes[childElement.name]; ok {
+				continue
+			}
+			typeName := options.exportTypeNameFunc(childElement.name)
+			if _, ok := elementsByTypeName[typeName]; ok {
+				typeName = parentTypeName + typeName
+			}
+			if _, ok := elementsByTypeName[typeName]; ok {
+				return fmt.Errorf("%s: duplicate type name", typeName)
+			}
+			typeNamesByElement[childElement] = typeName
+			elementsByTypeName[typeName] = childElement
+			childPath := path + "/" + childElement.name.Local
+			schemaTypes = append(schemaTypes, &schemaType{
+				name:    typeName,
+				element: childElement,
+				path:    childPath,
+			})
+			if err := addNestedTypes(childElement, childPath, typeName); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	typeElements := g.sortedTypeElements(options)
+	for _, typeElement := range typeElements {
+		typeName := options.exportTypeNameFunc(typeElement.name)
+		if _, ok := elementsByTypeName[typeName]; ok {
+			return nil, nil, fmt.Errorf("%s: duplicate type name", typeName)
+		}
+		typeNamesByElement[typeElement] = typeName
+		elementsByTypeName[typeName] = typeElement
+	}
+	for _, typeElement := range typeElements {
+		typeName := typeNamesByElement[typeElement]
+		schemaTypes = append(schemaTypes, &schemaType{
+			name:    typeName,
+			element: typeElement,
+			path:    typeElement.name.Local,
+			scalar:  typeElement.isSimple(),
+		})
+		if err := addNestedTypes(typeElement, typeElement.name.Local, typeName); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return schemaTypes, typeNamesByElement, nil
+}
+
+// lowerCamelCase returns name, which is assumed to be in UpperCamelCase, in
+// lowerCamelCase. Leading initialisms are lowercased, so ID becomes id and
+// URLName becomes urlName.
+func lowerCamelCase(name string) string {
+	runes := []rune(name)
+	upper := 0
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+	if upper > 1 && upper < len(runes) && unicode.IsLower(runes[upper]) {
+		upper--
+	}
+	for i := range upper {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// commentHeader returns header, a Go comment, with each // comment prefix
+// replaced by commentPrefix.
+func commentHeader(header, commentPrefix string) string {
+	lines := strings.Split(header, "\n")
+	for i, line := range lines {
+		if comment, ok := strings.CutPrefix(line, "//"); ok {
+			lines[i] = commentPrefix + comment
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,211 @@
+package xmlstruct
+
+import (
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// schematronNamespace is the namespace of ISO Schematron schemas.
+const schematronNamespace = "http://purl.oclc.org/dsdl/schematron"
+
+// schematronEscaper escapes XML text and double-quoted attribute values.
+var schematronEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&quot;",
+)
+
+// A schematronAssert is a Schematron assertion.
+type schematronAssert struct {
+	test    string
+	message string
+}
+
+// GenerateSchematron returns an ISO Schematron schema asserting the invariants
+// observed in all the XML documents observed so far, for validating documents
+// before they are decoded into the generated Go types.
+//
+// For each element, the schema asserts that attributes and child elements that
+// were always present are present, that child elements that were never
+// repeated are not repeated, and that optional attributes that were always
+// accompanied by other optional attributes are accompanied by them. If
+// WithValueExamples is used and every distinct value of an attribute or of an
+// element's chardata was recorded as an example, then the schema also asserts
+// that values are one of the observed values.
+//
+// Elements are matched by their local name, and also by their namespace if
+// the Generator's NameFunc preserves namespaces. If the Generator generates
+// named types then rules match elements wherever they occur, otherwise they
+// match elements by their path from the top level element.
+func (g *Generator) GenerateSchematron() ([]byte, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	schemaBuilder := &strings.Builder{}
+	fmt.Fprintf(schemaBuilder, "%s", xml.Header)
+	if g.header != "" {
+		fmt.Fprintf(schemaBuilder, "<!--\n%s\n-->\n", strings.ReplaceAll(commentHeader(g.header, ""), "--", "- -"))
+	}
+	fmt.Fprintf(schemaBuilder, "<schema xmlns=%q queryBinding=\"xslt\">\n", schematronNamespace)
+	fmt.Fprintf(schemaBuilder, "  <pattern>\n")
+
+	typeElements := mapValues(g.typeElements)
+	slices.SortFunc(typeElements, func(a, b *element) int {
+		return compareNames(a.name, b.name)
+	})
+	if g.namedTypes {
+		for _, typeElement := range typeElements {
+			writeSchematronRule(schemaBuilder, schematronNameTest(typeElement.name), typeElement)
+		}
+	} else {
+		var writeRules func(string, *element)
+		writeRules = func(context string, e *element) {
+			writeSchematronRule(schemaBuilder, context, e)
+			childNames := mapKeys(e.childElements)
+			slices.SortFunc(childNames, compareNames)
+			for _, childName := range childNames {
+				writeRules(context+"/"+schematronNameTest(childName), e.childElements[childName])
+			}
+		}
+		for _, typeElement := range typeElements {
+			writeRules("/"+schematronNameTest(typeElement.name), typeElement)
+		}
+	}
+
+	fmt.Fprintf(schemaBuilder, "  </pattern>\n")
+	fmt.Fprintf(schemaBuilder, "</schema>\n")
+	return []byte(schemaBuilder.String()), nil
+}
+
+// writeSchematronRule writes a Schematron rule with context for the invariants
+// of e to w. Nothing is written if e has no invariants.
+func writeSchematronRule(w *strings.Builder, context string, e *element) {
+	asserts := e.schematronAsserts()
+	if len(asserts) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "    <rule context=\"%s\">\n", schematronEscape(context))
+	for _, assert := range asserts {
+		fmt.Fprintf(w, "      <assert test=\"%s\">%s</assert>\n", schematronEscape(assert.test), schematronEscape(assert.message))
+	}
+	fmt.Fprintf(w, "    </rule>\n")
+}
+
+// schematronAsserts returns the assertions of the invariants observed for e.
+func (e *element) schematronAsserts() []schematronAssert {
+	var asserts []schematronAssert
+
+	attrNames := mapKeys(e.attrValues)
+	slices.SortFunc(attrNames, compareNames)
+	for _, attrName := range attrNames {
+		attrValue := e.attrValues[attrName]
+		attrTest := "@" + schematronNameTest(attrName)
+		if !attrValue.optional {
+			asserts = append(asserts, schematronAssert{
+				test:    attrTest,
+				message: fmt.Sprintf("%s must have attribute %s", e.name.Local, attrName.Local),
+			})
+		} else {
+			otherAttrNames := mapKeys(e.attrCoOccurrences[attrName])
+			slices.SortFunc(otherAttrNames, compareNames)
+			for _, otherAttrName := range otherAttrNames {
+				if otherAttrValue, ok := e.attrValues[otherAttrName]; !ok || !otherAttrValue.optional {
+					continue
+				}
+				asserts = append(asserts, schematronAssert{
+					test:    "not(" + attrTest + ") or @" + schematronNameTest(otherAttrName),
+					message: fmt.Sprintf("%s with attribute %s must have attribute %s", e.name.Local, attrName.Local, otherAttrName.Local),
+				})
+			}
+		}
+		if valuesTest, ok := attrValue.schematronValuesTest(); ok {
+			asserts = append(asserts, schematronAssert{
+				test:    "not(" + attrTest + "[not(" + valuesTest + ")])",
+				message: fmt.Sprintf("%s attribute %s must be one of the observed values", e.name.Local, attrName.Local),
+			})
+		}
+	}
+
+	childNames := mapKeys(e.childElements)
+	slices.SortFunc(childNames, compareNames)
+	for _, childName := range childNames {
+		childTest := schematronNameTest(childName)
+		if _, optional := e.optionalChildren[childName]; !optional {
+			asserts = append(asserts, schematronAssert{
+				test:    childTest,
+				message: fmt.Sprintf("%s must have child element %s", e.name.Local, childName.Local),
+			})
+		}
+		if _, repeated := e.repeatedChildren[childName]; !repeated {
+			asserts = append(asserts, schematronAssert{
+				test:    "count(" + childTest + ") <= 1",
+				message: fmt.Sprintf("%s must have at most one child element %s", e.name.Local, childName.Local),
+			})
+		}
+	}
+
+	if len(e.childElements) == 0 && e.charDataValue.observations > 0 {
+		if valuesTest, ok := e.charDataValue.schematronValuesTest(); ok {
+			asserts = append(asserts, schematronAssert{
+				test:    "normalize-space(.) = '' or " + valuesTest,
+				message: fmt.Sprintf("%s must be one of the observed values", e.name.Local),
+			})
+		}
+	}
+
+	return asserts
+}
+
+// schematronValuesTest returns an XPath expression that tests whether the
+// context node's value is one of v's values and whether v's examples are all
+// of v's values and can be expressed in XPath.
+func (v *value) schematronValuesTest() (string, bool) {
+	if len(v.examples) == 0 || v.examplesIncomplete {
+		return "", false
+	}
+	examples := mapValues(v.examples)
+	slices.Sort(examples)
+	tests := make([]string, 0, len(examples))
+	for _, example := range examples {
+		if strings.Join(strings.Fields(example), " ") != example {
+			return "", false
+		}
+		literal, ok := xpathLiteral(example)
+		if !ok {
+			return "", false
+		}
+		tests = append(tests, "normalize-space(.) = "+literal)
+	}
+	return strings.Join(tests, " or "), true
+}
+
+// schematronNameTest returns an XPath name test matching name.
+func schematronNameTest(name xml.Name) string {
+	localNameLiteral, _ := xpathLiteral(name.Local)
+	if name.Space == "" {
+		return "*[local-name() = " + localNameLiteral + "]"
+	}
+	spaceLiteral, _ := xpathLiteral(name.Space)
+	return "*[local-name() = " + localNameLiteral + " and namespace-uri() = " + spaceLiteral + "]"
+}
+
+// xpathLiteral returns s as an XPath 1.0 string literal and whether s can be
+// expressed as one, which it cannot if it contains both kinds of quotes.
+func xpathLiteral(s string) (string, bool) {
+	switch {
+	case !strings.Contains(s, "'"):
+		return "'" + s + "'", true
+	case !strings.Contains(s, `"`):
+		return `"` + s + `"`, true
+	default:
+		return "", false
+	}
+}
+
+// schematronEscape returns s escaped for use in XML text and double-quoted
+// attribute values.
+func schematronEscape(s string) string {
+	return schematronEscaper.Replace(s)
+}
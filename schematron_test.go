@@ -0,0 +1,91 @@
+package xmlstruct_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestGenerateSchematron(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name        string
+		xmlStrs     []string
+		options     []xmlstruct.GeneratorOption
+		expectedStr string
+	}{
+		{
+			name: "simple",
+			xmlStrs: []string{
+				`<a><b id="1" unit="m" scale="2">x</b><c/><c/><d/></a>`,
+				`<a><b id="2">y</b><c/></a>`,
+			},
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithHeader(""),
+				xmlstruct.WithValueExamples(2),
+			},
+			expectedStr: joinLines(
+				`<?xml version="1.0" encoding="UTF-8"?>`,
+				`<schema xmlns="http://purl.oclc.org/dsdl/schematron" queryBinding="xslt">`,
+				`  <pattern>`,
+				`    <rule context="/*[local-name() = 'a']">`,
+				`      <assert test="*[local-name() = 'b']">a must have child element b</assert>`,
+				`      <assert test="count(*[local-name() = 'b']) &lt;= 1">a must have at most one child element b</assert>`,
+				`      <assert test="*[local-name() = 'c']">a must have child element c</assert>`,
+				`      <assert test="count(*[local-name() = 'd']) &lt;= 1">a must have at most one child element d</assert>`,
+				`    </rule>`,
+				`    <rule context="/*[local-name() = 'a']/*[local-name() = 'b']">`,
+				`      <assert test="@*[local-name() = 'id']">b must have attribute id</assert>`,
+				`      <assert test="not(@*[local-name() = 'id'][not(normalize-space(.) = '1' or normalize-space(.) = '2')])">b attribute id must be one of the observed values</assert>`,
+				`      <assert test="not(@*[local-name() = 'scale']) or @*[local-name() = 'unit']">b with attribute scale must have attribute unit</assert>`,
+				`      <assert test="not(@*[local-name() = 'scale'][not(normalize-space(.) = '2')])">b attribute scale must be one of the observed values</assert>`,
+				`      <assert test="not(@*[local-name() = 'unit']) or @*[local-name() = 'scale']">b with attribute unit must have attribute scale</assert>`,
+				`      <assert test="not(@*[local-name() = 'unit'][not(normalize-space(.) = 'm')])">b attribute unit must be one of the observed values</assert>`,
+				`      <assert test="normalize-space(.) = '' or normalize-space(.) = 'x' or normalize-space(.) = 'y'">b must be one of the observed values</assert>`,
+				`    </rule>`,
+				`  </pattern>`,
+				`</schema>`,
+			),
+		},
+		{
+			name: "incomplete_values",
+			xmlStrs: []string{
+				`<a><b>1</b><b>2</b><b>3</b></a>`,
+			},
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithValueExamples(2),
+				xmlstruct.WithNamedTypes(true),
+			},
+			expectedStr: joinLines(
+				`<?xml version="1.0" encoding="UTF-8"?>`,
+				`<!--`,
+				` Code generated by go-xmlstruct. DO NOT EDIT.`,
+				`-->`,
+				`<schema xmlns="http://purl.oclc.org/dsdl/schematron" queryBinding="xslt">`,
+				`  <pattern>`,
+				`    <rule context="*[local-name() = 'a']">`,
+				`      <assert test="*[local-name() = 'b']">a must have child element b</assert>`,
+				`    </rule>`,
+				`  </pattern>`,
+				`</schema>`,
+			),
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			generator := xmlstruct.NewGenerator(tc.options...)
+			for _, xmlStr := range tc.xmlStrs {
+				assert.NoError(t, generator.ObserveReader(strings.NewReader(xmlStr)))
+			}
+			actual, err := generator.GenerateSchematron()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStr, string(actual))
+		})
+	}
+}
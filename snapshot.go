@@ -0,0 +1,153 @@
+package xmlstruct
+
+import (
+	"encoding/xml"
+	"maps"
+	"slices"
+)
+
+// Snapshot returns a new Generator with g's options and a copy of the model
+// inferred from all the XML documents observed so far. The snapshot is
+// independent of g: it can generate Go source while g continues to observe XML
+// documents in other goroutines, and documents observed by either do not
+// change the other.
+func (g *Generator) Snapshot() *Generator {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	snapshot := g.cloneOptions()
+	snapshot.inputs = slices.Clone(g.inputs)
+	snapshot.documents = g.documents
+	snapshot.order = g.order
+	snapshot.typeOrder = maps.Clone(g.typeOrder)
+	elementCopies := make(map[*element]*element)
+	for name, typeElement := range g.typeElements {
+		snapshot.typeElements[name] = typeElement.copy(elementCopies)
+	}
+	return snapshot
+}
+
+// cloneOptions returns a new Generator with g's options and no observations.
+// Options that are maps are shared, as they are only replaced, not modified,
+// after the Generator is created.
+func (g *Generator) cloneOptions() *Generator {
+	clone := &Generator{
+		anonymizationKey:       g.anonymizationKey,
+		anonymizeEnumThreshold: g.anonymizeEnumThreshold,
+		anyAttrs:               g.anyAttrs,
+		anyElements:            g.anyElements,
+		astHooks:               g.astHooks,
+		attrDefaults:           g.attrDefaults,
+		attrMapThreshold:       g.attrMapThreshold,
+		attrNameSuffix:         g.attrNameSuffix,
+		benchmarks:             g.benchmarks,
+		buildTags:              g.buildTags,
+		cardinalities:          g.cardinalities,
+		cardinalityPrecedence:  g.cardinalityPrecedence,
+		charDataElementName:    g.charDataElementName,
+		charDataFieldName:      g.charDataFieldName,
+		charsetPrecedence:      g.charsetPrecedence,
+		commonFieldExtraction:  g.commonFieldExtraction,
+		deepCopyGenMarkers:     g.deepCopyGenMarkers,
+		deepCopyMethods:        g.deepCopyMethods,
+		deprecatedBuildTag:     g.deprecatedBuildTag,
+		deprecations:           g.deprecations,
+		elemNameSuffix:         g.elemNameSuffix,
+		elementFilters:         g.elementFilters,
+		entities:               g.entities,
+		entityResolver:         g.entityResolver,
+		exampleRedactFunc:      g.exampleRedactFunc,
+		exampleTags:            g.exampleTags,
+		exportNameFunc:         g.exportNameFunc,
+		exportTypeNameFunc:     g.exportTypeNameFunc,
+		exportRenames:          g.exportRenames,
+		exportTypeRenames:      g.exportTypeRenames,
+		fallbackCharsets:       g.fallbackCharsets,
+		formatSource:           g.formatSource,
+		generatedFileSuffix:    g.generatedFileSuffix,
+		header:                 g.header,
+		identityConstraints:    g.identityConstraints,
+		importMappings:         g.importMappings,
+		imports:                g.imports,
+		indexFuncs:             g.indexFuncs,
+		intType:                g.intType,
+		keyUniqueness:          g.keyUniqueness,
+		logger:                 g.logger,
+		mapElements:            g.mapElements,
+		minOccurrenceRate:      g.minOccurrenceRate,
+		modifyDecoderFunc:      g.modifyDecoderFunc,
+		nameFunc:               g.nameFunc,
+		pathNameFunc:           g.pathNameFunc,
+		namedRoot:              g.namedRoot,
+		namedTypes:             g.namedTypes,
+		numberFormat:           g.numberFormat,
+		compactTypes:           g.compactTypes,
+		packageName:            g.packageName,
+		preserveLeadingZeros:   g.preserveLeadingZeros,
+		preserveOrder:          g.preserveOrder,
+		preserveWhitespace:     g.preserveWhitespace,
+		rootRegistry:           g.rootRegistry,
+		sanitizeIdentifierFunc: g.sanitizeIdentifierFunc,
+		stripBOM:               g.stripBOM,
+		timeLayout:             g.timeLayout,
+		timeZonePolicy:         g.timeZonePolicy,
+		tokenFilters:           g.tokenFilters,
+		topLevelAttributes:     g.topLevelAttributes,
+		typeConfidence:         g.typeConfidence,
+		typeConflictPolicy:     g.typeConflictPolicy,
+		typeOrder:              make(map[xml.Name]int),
+		pointerPolicy:          g.pointerPolicy,
+		useRawToken:            g.useRawToken,
+		valueCollation:         g.valueCollation,
+		valueExamples:          g.valueExamples,
+		valueSanitizer:         g.valueSanitizer,
+		typeElements:           make(map[xml.Name]*element),
+		emptyElements:          g.emptyElements,
+	}
+	clone.usePointersForOptionalFields = g.usePointersForOptionalFields
+	return clone
+}
+
+// copy returns a deep copy of e. copies maps elements that have already been
+// copied to their copies, so that elements that are shared or recursive in e
+// are shared or recursive in the copy.
+func (e *element) copy(copies map[*element]*element) *element {
+	if elementCopy, ok := copies[e]; ok {
+		return elementCopy
+	}
+	elementCopy := &element{
+		attrCoOccurrences: make(map[xml.Name]map[xml.Name]struct{}, len(e.attrCoOccurrences)),
+		attrValues:        make(map[xml.Name]*value, len(e.attrValues)),
+		charDataAbsent:    e.charDataAbsent,
+		charDataValue:     e.charDataValue.copy(),
+		childElements:     make(map[xml.Name]*element, len(e.childElements)),
+		documentCount:     e.documentCount,
+		nestedCount:       e.nestedCount,
+		childOrder:        maps.Clone(e.childOrder),
+		name:              e.name,
+		optionalChildren:  maps.Clone(e.optionalChildren),
+		repeatedChildren:  maps.Clone(e.repeatedChildren),
+		root:              e.root,
+		sample:            e.sample,
+	}
+	copies[e] = elementCopy
+	for attrName, coOccurrences := range e.attrCoOccurrences {
+		elementCopy.attrCoOccurrences[attrName] = maps.Clone(coOccurrences)
+	}
+	for attrName, attrValue := range e.attrValues {
+		attrValueCopy := attrValue.copy()
+		elementCopy.attrValues[attrName] = &attrValueCopy
+	}
+	for childName, childElement := range e.childElements {
+		elementCopy.childElements[childName] = childElement.copy(copies)
+	}
+	return elementCopy
+}
+
+// copy returns a deep copy of v.
+func (v *value) copy() value {
+	valueCopy := *v
+	valueCopy.examples = maps.Clone(v.examples)
+	valueCopy.identityValues = maps.Clone(v.identityValues)
+	return valueCopy
+}
@@ -0,0 +1,70 @@
+package xmlstruct_test
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+
+	options := []xmlstruct.GeneratorOption{
+		xmlstruct.WithNamedTypes(true),
+		xmlstruct.WithPreserveOrder(true),
+		xmlstruct.WithValueExamples(2),
+	}
+	generator := xmlstruct.NewGenerator(options...)
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(`<a x="1"><b><a/></b></a>`)))
+
+	snapshot := generator.Snapshot()
+	fingerprint := snapshot.Fingerprint()
+	assert.Equal(t, generator.Fingerprint(), fingerprint)
+
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(`<a x="y"><b><c/></b></a>`)))
+	assert.NoError(t, snapshot.ObserveReader(strings.NewReader(`<a><d/></a>`)))
+	assert.NotEqual(t, generator.Fingerprint(), fingerprint)
+
+	expectedGenerator := xmlstruct.NewGenerator(options...)
+	assert.NoError(t, expectedGenerator.ObserveReader(strings.NewReader(`<a x="1"><b><a/></b></a>`)))
+	assert.NoError(t, expectedGenerator.ObserveReader(strings.NewReader(`<a><d/></a>`)))
+	expected, err := expectedGenerator.Generate()
+	assert.NoError(t, err)
+	actual, err := snapshot.Generate()
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), string(actual))
+}
+
+func TestGeneratorConcurrentUse(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator()
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			xmlStr := `<a><b` + strconv.Itoa(i) + ` x="` + strconv.Itoa(i) + `"/></a>`
+			assert.NoError(t, generator.ObserveReader(strings.NewReader(xmlStr)))
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := generator.Snapshot().Generate()
+			assert.NoError(t, err)
+			_, err = generator.Generate()
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	actual, err := generator.Generate()
+	assert.NoError(t, err)
+	for i := range 8 {
+		assert.Contains(t, string(actual), "B"+strconv.Itoa(i)+" ")
+	}
+}
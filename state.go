@@ -0,0 +1,338 @@
+package xmlstruct
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+)
+
+// stateVersion is the version of the format written by SaveState.
+const stateVersion = 1
+
+// A state is the serialized model of a Generator.
+type state struct {
+	Version           int               `json:"version"`
+	Order             int               `json:"order"`
+	Documents         int               `json:"documents,omitempty"`
+	Inputs            []MetadataInput   `json:"inputs,omitempty"`
+	ExportRenames     map[string]string `json:"exportRenames,omitempty"`
+	ExportTypeRenames map[string]string `json:"exportTypeRenames,omitempty"`
+	TypeOrder         []stateTypeOrder  `json:"typeOrder,omitempty"`
+	TypeElements      []int             `json:"typeElements"`
+	Elements          []*stateElement   `json:"elements"`
+}
+
+// A stateTypeOrder is the order in which a type was first observed.
+type stateTypeOrder struct {
+	Name  stateName `json:"name"`
+	Order int       `json:"order"`
+}
+
+// A stateName is a serialized xml.Name.
+type stateName struct {
+	Space string `json:"space,omitempty"`
+	Local string `json:"local"`
+}
+
+// A stateElement is a serialized element. Child elements are referred to by
+// their index in state.Elements, so that shared and recursive elements are
+// preserved.
+type stateElement struct {
+	Name        stateName    `json:"name"`
+	Root        bool         `json:"root,omitempty"`
+	NestedCount int          `json:"nestedCount,omitempty"`
+	Documents   int          `json:"documents,omitempty"`
+	Attrs       []stateAttr  `json:"attrs,omitempty"`
+	CharData    *stateValue  `json:"charData,omitempty"`
+	NoCharData  bool         `json:"noCharData,omitempty"`
+	Children    []stateChild `json:"children,omitempty"`
+	Sample      []byte       `json:"sample,omitempty"`
+}
+
+// A stateAttr is a serialized attribute.
+type stateAttr struct {
+	Value         stateValue   `json:"value"`
+	CoOccurrences *[]stateName `json:"coOccurrences,omitempty"`
+}
+
+// A stateChild is a serialized child element.
+type stateChild struct {
+	Name     stateName `json:"name"`
+	Element  int       `json:"element"`
+	Order    *int      `json:"order,omitempty"`
+	Optional bool      `json:"optional,omitempty"`
+	Repeated bool      `json:"repeated,omitempty"`
+}
+
+// A stateValue is a serialized value.
+type stateValue struct {
+	Name               stateName         `json:"name"`
+	Observations       int               `json:"observations"`
+	BoolCount          int               `json:"boolCount,omitempty"`
+	Float64Count       int               `json:"float64Count,omitempty"`
+	IntCount           int               `json:"intCount,omitempty"`
+	StringCount        int               `json:"stringCount,omitempty"`
+	TimeCount          int               `json:"timeCount,omitempty"`
+	Optional           bool              `json:"optional,omitempty"`
+	Repeated           bool              `json:"repeated,omitempty"`
+	Example            string            `json:"example,omitempty"`
+	Examples           map[string]string `json:"examples,omitempty"`
+	ExamplesIncomplete bool              `json:"examplesIncomplete,omitempty"`
+	IdentityValues     map[string]int    `json:"identityValues,omitempty"`
+}
+
+// SaveState writes the model inferred from all the XML documents observed so
+// far to w, so that it can be restored with LoadState, for example to continue
+// observing documents in a later run. Options are not saved.
+func (g *Generator) SaveState(w io.Writer) error {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.saveState(w)
+}
+
+// saveState writes g's model to w. g.mu must be held.
+func (g *Generator) saveState(w io.Writer) error {
+	s := &state{
+		Version:           stateVersion,
+		Order:             g.order,
+		Documents:         g.documents,
+		Inputs:            g.inputs,
+		ExportRenames:     g.exportRenames,
+		ExportTypeRenames: g.exportTypeRenames,
+	}
+
+	typeOrderNames := mapKeys(g.typeOrder)
+	slices.SortFunc(typeOrderNames, compareNames)
+	for _, name := range typeOrderNames {
+		s.TypeOrder = append(s.TypeOrder, stateTypeOrder{
+			Name:  newStateName(name),
+			Order: g.typeOrder[name],
+		})
+	}
+
+	indexes := make(map[*element]int)
+	var addElement func(*element) int
+	addElement = func(e *element) int {
+		if index, ok := indexes[e]; ok {
+			return index
+		}
+		index := len(s.Elements)
+		indexes[e] = index
+		stateElement := &stateElement{
+			Name:        newStateName(e.name),
+			Root:        e.root,
+			NestedCount: e.nestedCount,
+			Documents:   e.documentCount,
+			NoCharData:  e.charDataAbsent,
+			Sample:      e.sample,
+		}
+		s.Elements = append(s.Elements, stateElement)
+
+		attrNames := mapKeys(e.attrValues)
+		slices.SortFunc(attrNames, compareNames)
+		for _, attrName := range attrNames {
+			stateAttr := stateAttr{
+				Value: newStateValue(e.attrValues[attrName]),
+			}
+			if coOccurrences, ok := e.attrCoOccurrences[attrName]; ok {
+				coOccurrenceNames := mapKeys(coOccurrences)
+				slices.SortFunc(coOccurrenceNames, compareNames)
+				stateNames := make([]stateName, 0, len(coOccurrenceNames))
+				for _, coOccurrenceName := range coOccurrenceNames {
+					stateNames = append(stateNames, newStateName(coOccurrenceName))
+				}
+				stateAttr.CoOccurrences = &stateNames
+			}
+			stateElement.Attrs = append(stateElement.Attrs, stateAttr)
+		}
+
+		if e.charDataValue.observations > 0 {
+			charDataValue := newStateValue(&e.charDataValue)
+			stateElement.CharData = &charDataValue
+		}
+
+		childNames := mapKeys(e.childElements)
+		slices.SortFunc(childNames, compareNames)
+		for _, childName := range childNames {
+			_, optional := e.optionalChildren[childName]
+			_, repeated := e.repeatedChildren[childName]
+			stateChild := stateChild{
+				Name:     newStateName(childName),
+				Element:  addElement(e.childElements[childName]),
+				Optional: optional,
+				Repeated: repeated,
+			}
+			if order, ok := e.childOrder[childName]; ok {
+				stateChild.Order = &order
+			}
+			stateElement.Children = append(stateElement.Children, stateChild)
+		}
+		return index
+	}
+
+	typeElementNames := mapKeys(g.typeElements)
+	slices.SortFunc(typeElementNames, compareNames)
+	for _, name := range typeElementNames {
+		s.TypeElements = append(s.TypeElements, addElement(g.typeElements[name]))
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// LoadState replaces the model of g with one read from r that was written by
+// SaveState. g's options are not changed, except that names kept from Go
+// source observed with ObserveGoSource are restored if they are not already
+// set.
+func (g *Generator) LoadState(r io.Reader) error {
+	var s state
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return err
+	}
+	if s.Version != stateVersion {
+		return fmt.Errorf("%d: unsupported state version", s.Version)
+	}
+
+	elements := make([]*element, len(s.Elements))
+	for i, stateElement := range s.Elements {
+		elements[i] = newElement(stateElement.Name.xmlName())
+	}
+	elementAt := func(index int) (*element, error) {
+		if index < 0 || index >= len(elements) {
+			return nil, fmt.Errorf("%d: invalid element index", index)
+		}
+		return elements[index], nil
+	}
+	for i, stateElement := range s.Elements {
+		e := elements[i]
+		e.root = stateElement.Root
+		e.nestedCount = stateElement.NestedCount
+		e.documentCount = stateElement.Documents
+		e.charDataAbsent = stateElement.NoCharData
+		e.sample = stateElement.Sample
+		for _, stateAttr := range stateElement.Attrs {
+			attrValue := stateAttr.Value.value()
+			e.attrValues[attrValue.name] = &attrValue
+			if stateAttr.CoOccurrences != nil {
+				coOccurrences := make(map[xml.Name]struct{}, len(*stateAttr.CoOccurrences))
+				for _, coOccurrenceName := range *stateAttr.CoOccurrences {
+					coOccurrences[coOccurrenceName.xmlName()] = struct{}{}
+				}
+				e.attrCoOccurrences[attrValue.name] = coOccurrences
+			}
+		}
+		if stateElement.CharData != nil {
+			e.charDataValue = stateElement.CharData.value()
+		}
+		for _, stateChild := range stateElement.Children {
+			childName := stateChild.Name.xmlName()
+			childElement, err := elementAt(stateChild.Element)
+			if err != nil {
+				return err
+			}
+			e.childElements[childName] = childElement
+			if stateChild.Order != nil {
+				e.childOrder[childName] = *stateChild.Order
+			}
+			if stateChild.Optional {
+				e.optionalChildren[childName] = struct{}{}
+			}
+			if stateChild.Repeated {
+				e.repeatedChildren[childName] = struct{}{}
+			}
+		}
+	}
+
+	typeElements := make(map[xml.Name]*element, len(s.TypeElements))
+	for _, index := range s.TypeElements {
+		typeElement, err := elementAt(index)
+		if err != nil {
+			return err
+		}
+		typeElements[typeElement.name] = typeElement
+	}
+	typeOrder := make(map[xml.Name]int, len(s.TypeOrder))
+	for _, stateTypeOrder := range s.TypeOrder {
+		typeOrder[stateTypeOrder.Name.xmlName()] = stateTypeOrder.Order
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.order = s.Order
+	g.documents = s.Documents
+	g.inputs = s.Inputs
+	g.typeOrder = typeOrder
+	g.typeElements = typeElements
+	g.exportRenames = mergeRenames(s.ExportRenames, g.exportRenames)
+	g.exportTypeRenames = mergeRenames(s.ExportTypeRenames, g.exportTypeRenames)
+	return nil
+}
+
+// mergeRenames returns a new map containing the renames in saved and
+// current, with the renames in current taking precedence.
+func mergeRenames(saved, current map[string]string) map[string]string {
+	if len(saved) == 0 {
+		return current
+	}
+	renames := maps.Clone(saved)
+	maps.Copy(renames, current)
+	return renames
+}
+
+// newStateName returns the stateName of name.
+func newStateName(name xml.Name) stateName {
+	return stateName{
+		Space: name.Space,
+		Local: name.Local,
+	}
+}
+
+// xmlName returns the xml.Name of n.
+func (n stateName) xmlName() xml.Name {
+	return xml.Name{
+		Space: n.Space,
+		Local: n.Local,
+	}
+}
+
+// newStateValue returns the stateValue of v.
+func newStateValue(v *value) stateValue {
+	return stateValue{
+		Name:               newStateName(v.name),
+		Observations:       v.observations,
+		BoolCount:          v.boolCount,
+		Float64Count:       v.float64Count,
+		IntCount:           v.intCount,
+		StringCount:        v.stringCount,
+		TimeCount:          v.timeCount,
+		Optional:           v.optional,
+		Repeated:           v.repeated,
+		Example:            v.example,
+		Examples:           v.examples,
+		ExamplesIncomplete: v.examplesIncomplete,
+		IdentityValues:     v.identityValues,
+	}
+}
+
+// value returns the value of v.
+func (v *stateValue) value() value {
+	return value{
+		name:               v.Name.xmlName(),
+		observations:       v.Observations,
+		boolCount:          v.BoolCount,
+		float64Count:       v.Float64Count,
+		intCount:           v.IntCount,
+		stringCount:        v.StringCount,
+		timeCount:          v.TimeCount,
+		optional:           v.Optional,
+		repeated:           v.Repeated,
+		example:            v.Example,
+		examples:           v.Examples,
+		examplesIncomplete: v.ExamplesIncomplete,
+		identityValues:     v.IdentityValues,
+	}
+}
@@ -0,0 +1,88 @@
+package xmlstruct_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestSaveAndLoadState(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name    string
+		options []xmlstruct.GeneratorOption
+	}{
+		{
+			name: "default",
+		},
+		{
+			name: "named_types",
+			options: []xmlstruct.GeneratorOption{
+				xmlstruct.WithNamedTypes(true),
+				xmlstruct.WithPreserveOrder(true),
+				xmlstruct.WithValueExamples(2),
+				xmlstruct.WithExampleTags(true),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			generator := xmlstruct.NewGenerator(tc.options...)
+			assert.NoError(t, generator.ObserveReader(strings.NewReader(`<a x="1" y="z"><b><a/></b><c>2024-01-02T03:04:05Z</c><c>x</c></a>`)))
+			assert.NoError(t, generator.ObserveReader(strings.NewReader(`<a x="2"><d>1.5</d></a>`)))
+
+			state := &bytes.Buffer{}
+			assert.NoError(t, generator.SaveState(state))
+
+			loadedGenerator := xmlstruct.NewGenerator(tc.options...)
+			assert.NoError(t, loadedGenerator.LoadState(bytes.NewReader(state.Bytes())))
+			assert.Equal(t, generator.Fingerprint(), loadedGenerator.Fingerprint())
+
+			loadedState := &bytes.Buffer{}
+			assert.NoError(t, loadedGenerator.SaveState(loadedState))
+			assert.Equal(t, state.String(), loadedState.String())
+
+			assert.NoError(t, generator.ObserveReader(strings.NewReader(`<a><b><e/></b></a>`)))
+			assert.NoError(t, loadedGenerator.ObserveReader(strings.NewReader(`<a><b><e/></b></a>`)))
+			expected, err := generator.Generate()
+			assert.NoError(t, err)
+			actual, err := loadedGenerator.Generate()
+			assert.NoError(t, err)
+			assert.Equal(t, string(expected), string(actual))
+		})
+	}
+}
+
+func TestLoadStateErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name        string
+		stateStr    string
+		expectedErr string
+	}{
+		{
+			name:        "unsupported_version",
+			stateStr:    `{"version":0}`,
+			expectedErr: "0: unsupported state version",
+		},
+		{
+			name:        "invalid_element_index",
+			stateStr:    `{"version":1,"typeElements":[1],"elements":[{"name":{"local":"a"}}]}`,
+			expectedErr: "1: invalid element index",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			generator := xmlstruct.NewGenerator()
+			assert.EqualError(t, generator.LoadState(strings.NewReader(tc.stateStr)), tc.expectedErr)
+		})
+	}
+}
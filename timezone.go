@@ -0,0 +1,101 @@
+package xmlstruct
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// A TimeZonePolicy determines the type of times whose layout, set with
+// WithTimeLayout, does not include a time zone offset.
+type TimeZonePolicy int
+
+const (
+	// TimeZonePolicyNone uses time.Time, which only decodes times in RFC 3339
+	// format.
+	TimeZonePolicyNone TimeZonePolicy = iota
+	// TimeZonePolicyUTC uses a generated UTCTime type, which embeds time.Time
+	// and parses times with the layout in UTC.
+	TimeZonePolicyUTC
+	// TimeZonePolicyLocal uses a generated LocalTime type, which embeds
+	// time.Time and parses times with the layout in the local time zone of the
+	// decoding process.
+	TimeZonePolicyLocal
+	// TimeZonePolicyRaw uses a generated RawTime type with a Time field, parsed
+	// with the layout in UTC, and a Raw field that contains the original text,
+	// so that the time zone can be determined later.
+	TimeZonePolicyRaw
+)
+
+// layoutHasTimeZone returns whether the time layout layout includes a time
+// zone offset or abbreviation.
+func layoutHasTimeZone(layout string) bool {
+	return strings.Contains(layout, "Z07") || strings.Contains(layout, "-07") || strings.Contains(layout, "MST")
+}
+
+// timeGoType returns the Go type for times, adding its declaration to
+// options.
+func (options *generateOptions) timeGoType() string {
+	if options.timeZonePolicy == TimeZonePolicyNone || layoutHasTimeZone(options.timeLayout) {
+		options.importPackageNames["time"] = ""
+		return "time.Time"
+	}
+
+	var typeName, location, doc string
+	switch options.timeZonePolicy {
+	case TimeZonePolicyLocal:
+		typeName, location, doc = "LocalTime", "Local", "in the local time zone"
+	case TimeZonePolicyRaw:
+		typeName, location, doc = "RawTime", "UTC", "in UTC, with the original text in Raw"
+	default:
+		typeName, location, doc = "UTCTime", "UTC", "in UTC"
+	}
+	typeName = options.exportTypeNameFunc(xml.Name{Local: typeName})
+	if _, ok := options.declarations[typeName]; ok {
+		return typeName
+	}
+
+	options.importPackageNames["time"] = ""
+	declarationBuilder := &strings.Builder{}
+	fmt.Fprintf(declarationBuilder, "// %s is a time formatted with the layout %q, which does not\n", typeName, options.timeLayout)
+	fmt.Fprintf(declarationBuilder, "// include a time zone offset, %s.\n", doc)
+	fmt.Fprintf(declarationBuilder, "type %s struct {\n", typeName)
+	if options.timeZonePolicy == TimeZonePolicyRaw {
+		fmt.Fprintf(declarationBuilder, "\tTime time.Time\n")
+		fmt.Fprintf(declarationBuilder, "\tRaw  string\n")
+	} else {
+		fmt.Fprintf(declarationBuilder, "\ttime.Time\n")
+	}
+	fmt.Fprintf(declarationBuilder, "}\n")
+	fmt.Fprintf(declarationBuilder, "\n")
+	fmt.Fprintf(declarationBuilder, "// UnmarshalText implements encoding.TextUnmarshaler.\n")
+	fmt.Fprintf(declarationBuilder, "func (x *%s) UnmarshalText(text []byte) error {\n", typeName)
+	fmt.Fprintf(declarationBuilder, "\tt, err := time.ParseInLocation(%q, string(text), time.%s)\n", options.timeLayout, location)
+	fmt.Fprintf(declarationBuilder, "\tif err != nil {\n")
+	fmt.Fprintf(declarationBuilder, "\t\treturn err\n")
+	fmt.Fprintf(declarationBuilder, "\t}\n")
+	if options.timeZonePolicy == TimeZonePolicyRaw {
+		fmt.Fprintf(declarationBuilder, "\t*x = %s{Time: t, Raw: string(text)}\n", typeName)
+	} else {
+		fmt.Fprintf(declarationBuilder, "\tx.Time = t\n")
+	}
+	fmt.Fprintf(declarationBuilder, "\treturn nil\n")
+	fmt.Fprintf(declarationBuilder, "}\n")
+	fmt.Fprintf(declarationBuilder, "\n")
+	if options.timeZonePolicy == TimeZonePolicyRaw {
+		fmt.Fprintf(declarationBuilder, "// MarshalText implements encoding.TextMarshaler. Raw is marshaled if it is\n")
+		fmt.Fprintf(declarationBuilder, "// set.\n")
+		fmt.Fprintf(declarationBuilder, "func (x %s) MarshalText() ([]byte, error) {\n", typeName)
+		fmt.Fprintf(declarationBuilder, "\tif x.Raw != \"\" {\n")
+		fmt.Fprintf(declarationBuilder, "\t\treturn []byte(x.Raw), nil\n")
+		fmt.Fprintf(declarationBuilder, "\t}\n")
+	} else {
+		fmt.Fprintf(declarationBuilder, "// MarshalText implements encoding.TextMarshaler.\n")
+		fmt.Fprintf(declarationBuilder, "func (x %s) MarshalText() ([]byte, error) {\n", typeName)
+	}
+	fmt.Fprintf(declarationBuilder, "\treturn []byte(x.Time.In(time.%s).Format(%q)), nil\n", location, options.timeLayout)
+	fmt.Fprintf(declarationBuilder, "}\n")
+	options.declarations[typeName] = declarationBuilder.String()
+
+	return typeName
+}
@@ -0,0 +1,93 @@
+package xmlstruct
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// A TokenFilter transforms a token read from an XML document before it is
+// observed, for example to rewrite legacy namespaces or to normalize the case
+// of names. It returns nil to drop the token, for example to strip a wrapping
+// envelope by dropping its start and end elements. Start and end elements must
+// be transformed consistently, so that the document remains well-formed.
+type TokenFilter func(xml.Token) xml.Token
+
+// RenameNamespace returns a TokenFilter that replaces the namespace oldSpace of
+// element and attribute names with newSpace. Namespaces are URLs, unless
+// WithUseRawToken is set, in which case they are prefixes.
+func RenameNamespace(oldSpace, newSpace string) TokenFilter {
+	return renameTokenFilter(func(name xml.Name) xml.Name {
+		if name.Space == oldSpace {
+			name.Space = newSpace
+		}
+		return name
+	})
+}
+
+// LowerCaseNames returns a TokenFilter that converts the local names of
+// elements and attributes to lower case.
+func LowerCaseNames() TokenFilter {
+	return renameTokenFilter(func(name xml.Name) xml.Name {
+		name.Local = strings.ToLower(name.Local)
+		return name
+	})
+}
+
+// renameTokenFilter returns a TokenFilter that renames elements and attributes
+// with renameFunc.
+func renameTokenFilter(renameFunc func(xml.Name) xml.Name) TokenFilter {
+	return func(token xml.Token) xml.Token {
+		switch token := token.(type) {
+		case xml.StartElement:
+			attrs := make([]xml.Attr, 0, len(token.Attr))
+			for _, attr := range token.Attr {
+				if attr.Name.Space != "xmlns" && (attr.Name.Space != "" || attr.Name.Local != "xmlns") {
+					attr.Name = renameFunc(attr.Name)
+				}
+				attrs = append(attrs, attr)
+			}
+			return xml.StartElement{
+				Name: renameFunc(token.Name),
+				Attr: attrs,
+			}
+		case xml.EndElement:
+			return xml.EndElement{
+				Name: renameFunc(token.Name),
+			}
+		default:
+			return token
+		}
+	}
+}
+
+// A filteredTokenReader is an xml.TokenReader that reads tokens from a decoder
+// and applies token filters to them.
+type filteredTokenReader struct {
+	decoder      *xml.Decoder
+	useRawToken  bool
+	tokenFilters []TokenFilter
+}
+
+// Token implements xml.TokenReader.Token.
+func (r *filteredTokenReader) Token() (xml.Token, error) {
+FOR:
+	for {
+		var token xml.Token
+		var err error
+		if r.useRawToken {
+			token, err = r.decoder.RawToken()
+		} else {
+			token, err = r.decoder.Token()
+		}
+		if err != nil {
+			return token, err
+		}
+		for _, tokenFilter := range r.tokenFilters {
+			token = tokenFilter(token)
+			if token == nil {
+				continue FOR
+			}
+		}
+		return token, nil
+	}
+}
@@ -0,0 +1,214 @@
+package xmlstruct
+
+import (
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// A TypeConflictPolicy determines the type of values that were not all of the
+// same type, but of which at least the type confidence threshold, set with
+// WithTypeConfidenceThreshold, were of a type more specific than string.
+type TypeConflictPolicy int
+
+const (
+	// TypeConflictPolicyString uses string, so that every observed value can
+	// be decoded.
+	TypeConflictPolicyString TypeConflictPolicy = iota
+	// TypeConflictPolicyKeep uses the type of the conforming values. Values
+	// that do not conform cannot be decoded.
+	TypeConflictPolicyKeep
+	// TypeConflictPolicyTolerant uses a generated wrapper type with a Value
+	// field of the type of the conforming values and a Raw field that contains
+	// values that do not conform.
+	TypeConflictPolicyTolerant
+)
+
+// A TypeConflict describes an attribute or chardata whose observed values were
+// not all of the same type.
+type TypeConflict struct {
+	Path         string  // The path of the attribute or chardata.
+	Type         string  // The Go type of the conforming values.
+	GoType       string  // The generated Go type.
+	Conforming   int     // The number of values of type Type.
+	Observations int     // The number of observed values.
+	Confidence   float64 // Conforming divided by Observations.
+}
+
+// TypeConflicts returns the attributes and chardata in all the XML documents
+// observed so far whose values were not all of the same type, but some of
+// which were of a type more specific than string, sorted by path.
+//
+// Attribute paths are the path of their element followed by a slash and the
+// attribute's local name prefixed with @.
+func (g *Generator) TypeConflicts() ([]TypeConflict, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	options := g.generateOptions()
+	schemaTypes, _, err := g.schemaTypes(options)
+	if err != nil {
+		return nil, err
+	}
+
+	var typeConflicts []TypeConflict
+	addTypeConflict := func(v *value, path string) {
+		kind, conforming := v.majorityKind()
+		if v.kind() != stringValueKind || kind == stringValueKind {
+			return
+		}
+		typeConflicts = append(typeConflicts, TypeConflict{
+			Path:         path,
+			Type:         scalarGoType(kind, options),
+			GoType:       v.goType(path, options),
+			Conforming:   conforming,
+			Observations: v.observations,
+			Confidence:   float64(conforming) / float64(v.observations),
+		})
+	}
+	for _, schemaType := range schemaTypes {
+		if schemaType.scalar {
+			addTypeConflict(&schemaType.element.charDataValue, schemaType.path)
+			continue
+		}
+		fields, err := schemaType.element.fields(schemaType.path, options)
+		if err != nil {
+			return nil, err
+		}
+		for _, field := range fields {
+			switch field.kind {
+			case attrFieldKind:
+				addTypeConflict(field.value, field.path)
+			case charDataFieldKind:
+				if len(field.element.childElements) == 0 {
+					addTypeConflict(field.value, field.path)
+				}
+			case elemFieldKind:
+				fieldElement := field.fieldElement(options)
+				if _, ok := options.namedTypes[fieldElement.name]; ok {
+					continue
+				}
+				if _, ok := options.simpleTypes[fieldElement.name]; ok {
+					continue
+				}
+				if _, ok := options.mapElementKeyAttrName(field.element); ok || fieldElement.isStruct(options) {
+					continue
+				}
+				addTypeConflict(&fieldElement.charDataValue, field.path)
+			}
+		}
+	}
+
+	slices.SortStableFunc(typeConflicts, func(a, b TypeConflict) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return typeConflicts, nil
+}
+
+// majorityKind returns the kind, more specific than string, of the most values
+// observed for v, and the number of values of that kind. It returns
+// stringValueKind if no values were of a more specific kind.
+func (v *value) majorityKind() (valueKind, int) {
+	kind, conforming := stringValueKind, 0
+	for _, candidate := range []struct {
+		kind  valueKind
+		count int
+	}{
+		{kind: intValueKind, count: v.intCount},
+		{kind: float64ValueKind, count: v.intCount + v.float64Count},
+		{kind: boolValueKind, count: v.boolCount},
+		{kind: timeValueKind, count: v.timeCount},
+	} {
+		if candidate.count > conforming {
+			kind, conforming = candidate.kind, candidate.count
+		}
+	}
+	return kind, conforming
+}
+
+// resolvedKind returns the kind of v's Go type and whether it is wrapped in a
+// tolerant wrapper type, according to options' type conflict policy.
+func (v *value) resolvedKind(options *generateOptions) (valueKind, bool) {
+	kind := v.kind()
+	if kind != stringValueKind || options.typeConflictPolicy == TypeConflictPolicyString {
+		return kind, false
+	}
+	majorityKind, conforming := v.majorityKind()
+	if majorityKind == stringValueKind || float64(conforming) < options.typeConfidence*float64(v.observations) {
+		return kind, false
+	}
+	return majorityKind, options.typeConflictPolicy == TypeConflictPolicyTolerant
+}
+
+// tolerantGoType returns the name of the tolerant wrapper type for values of
+// kind, whose Go type is typeName, adding its declaration to options.
+func (options *generateOptions) tolerantGoType(kind valueKind, typeName string) string {
+	_, baseName, _ := strings.Cut(typeName, ".")
+	if baseName == "" {
+		baseName = typeName
+	}
+	runes := []rune(baseName)
+	runes[0] = unicode.ToUpper(runes[0])
+	tolerantTypeName := options.exportTypeNameFunc(xml.Name{Local: "Tolerant" + string(runes)})
+	if _, ok := options.declarations[tolerantTypeName]; ok {
+		return tolerantTypeName
+	}
+
+	var parse, format string
+	switch {
+	case kind == timeValueKind || options.numberFormat.localized() && (kind == intValueKind || kind == float64ValueKind):
+	case kind == boolValueKind:
+		parse, format = "strconv.ParseBool(strings.TrimSpace(string(text)))", "strconv.FormatBool(x.Value)"
+	case kind == intValueKind:
+		parse, format = "strconv.ParseInt(strings.TrimSpace(string(text)), 10, 64)", "strconv.FormatInt(int64(x.Value), 10)"
+	case kind == float64ValueKind:
+		parse, format = "strconv.ParseFloat(strings.TrimSpace(string(text)), 64)", "strconv.FormatFloat(x.Value, 'g', -1, 64)"
+	}
+
+	declarationBuilder := &strings.Builder{}
+	fmt.Fprintf(declarationBuilder, "// %s is a value of type %s that also accepts text that is not\n", tolerantTypeName, typeName)
+	fmt.Fprintf(declarationBuilder, "// a valid %s, which is kept in Raw.\n", typeName)
+	fmt.Fprintf(declarationBuilder, "type %s struct {\n", tolerantTypeName)
+	fmt.Fprintf(declarationBuilder, "\tValue %s\n", typeName)
+	fmt.Fprintf(declarationBuilder, "\tRaw   string\n")
+	fmt.Fprintf(declarationBuilder, "}\n")
+	fmt.Fprintf(declarationBuilder, "\n")
+	fmt.Fprintf(declarationBuilder, "// UnmarshalText implements encoding.TextUnmarshaler.\n")
+	fmt.Fprintf(declarationBuilder, "func (x *%s) UnmarshalText(text []byte) error {\n", tolerantTypeName)
+	if parse == "" {
+		fmt.Fprintf(declarationBuilder, "\tvar v %s\n", typeName)
+		fmt.Fprintf(declarationBuilder, "\tif err := v.UnmarshalText(text); err != nil {\n")
+	} else {
+		options.importPackageNames["strconv"] = ""
+		options.importPackageNames["strings"] = ""
+		fmt.Fprintf(declarationBuilder, "\tv, err := %s\n", parse)
+		fmt.Fprintf(declarationBuilder, "\tif err != nil {\n")
+	}
+	fmt.Fprintf(declarationBuilder, "\t\t*x = %s{Raw: string(text)}\n", tolerantTypeName)
+	fmt.Fprintf(declarationBuilder, "\t\treturn nil\n")
+	fmt.Fprintf(declarationBuilder, "\t}\n")
+	if parse == "" || kind != intValueKind {
+		fmt.Fprintf(declarationBuilder, "\t*x = %s{Value: v}\n", tolerantTypeName)
+	} else {
+		fmt.Fprintf(declarationBuilder, "\t*x = %s{Value: %s(v)}\n", tolerantTypeName, typeName)
+	}
+	fmt.Fprintf(declarationBuilder, "\treturn nil\n")
+	fmt.Fprintf(declarationBuilder, "}\n")
+	fmt.Fprintf(declarationBuilder, "\n")
+	fmt.Fprintf(declarationBuilder, "// MarshalText implements encoding.TextMarshaler. Raw is marshaled if it is\n")
+	fmt.Fprintf(declarationBuilder, "// set.\n")
+	fmt.Fprintf(declarationBuilder, "func (x %s) MarshalText() ([]byte, error) {\n", tolerantTypeName)
+	fmt.Fprintf(declarationBuilder, "\tif x.Raw != \"\" {\n")
+	fmt.Fprintf(declarationBuilder, "\t\treturn []byte(x.Raw), nil\n")
+	fmt.Fprintf(declarationBuilder, "\t}\n")
+	if format == "" {
+		fmt.Fprintf(declarationBuilder, "\treturn x.Value.MarshalText()\n")
+	} else {
+		fmt.Fprintf(declarationBuilder, "\treturn []byte(%s), nil\n", format)
+	}
+	fmt.Fprintf(declarationBuilder, "}\n")
+	options.declarations[tolerantTypeName] = declarationBuilder.String()
+
+	return tolerantTypeName
+}
@@ -0,0 +1,49 @@
+package xmlstruct_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestTypeConflicts(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithTopLevelAttributes(true),
+		xmlstruct.WithTypeConfidenceThreshold(0.75),
+		xmlstruct.WithTypeConflictPolicy(xmlstruct.TypeConflictPolicyKeep),
+	)
+	for _, xmlStr := range []string{
+		`<a x="1" y="true" z="z"><b>1.5</b></a>`,
+		`<a x="2" y="false" z="z"><b>2</b></a>`,
+		`<a x="3" y="maybe" z="z"><b>2.5</b></a>`,
+		`<a x="n/a" y="maybe" z="z"><b>3</b></a>`,
+	} {
+		assert.NoError(t, generator.ObserveReader(strings.NewReader(xmlStr)))
+	}
+
+	typeConflicts, err := generator.TypeConflicts()
+	assert.NoError(t, err)
+	assert.Equal(t, []xmlstruct.TypeConflict{
+		{
+			Path:         "a/@x",
+			Type:         "int",
+			GoType:       "int",
+			Conforming:   3,
+			Observations: 4,
+			Confidence:   0.75,
+		},
+		{
+			Path:         "a/@y",
+			Type:         "bool",
+			GoType:       "string",
+			Conforming:   2,
+			Observations: 4,
+			Confidence:   0.5,
+		},
+	}, typeConflicts)
+}
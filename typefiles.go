@@ -0,0 +1,256 @@
+package xmlstruct
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// declsFileName is the base name of the file that contains generated
+	// declarations that do not belong to a type.
+	declsFileName = "decls"
+
+	// TypeFilesManifestName is the name of the file in which GenerateTypeFiles
+	// records the names of the files that it generated.
+	TypeFilesManifestName = "xmlstruct-typefiles.txt"
+)
+
+// GenerateTypeFiles writes the generated Go source for all the XML documents
+// observed so far to the directory dir, creating it if needed, with each type
+// and its methods in its own file, named after the type in lowercase with the
+// generated file suffix. Only the files whose contents changed are written, so
+// unchanged files keep their modification times.
+//
+// The names of the generated files are recorded in the file
+// TypeFilesManifestName in dir. Files that were recorded by a previous call but
+// are no longer generated, for example because options that rename types
+// changed, are removed. Other files in dir are never removed. The names of the
+// written and removed files are returned.
+//
+// Together with SaveState and LoadState, this regenerates only the types that
+// are changed by new observations.
+func (g *Generator) GenerateTypeFiles(dir string) ([]string, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if !strings.HasSuffix(g.generatedFileSuffix, ".go") {
+		return nil, fmt.Errorf("%s: generated file suffix does not end in .go", g.generatedFileSuffix)
+	}
+
+	options := g.generateOptions()
+	buildConstraint, err := g.buildConstraint(options.deprecatedBuildTags()...)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.packageName == "" {
+		options.packageName = "main"
+	}
+	// Generate the source with imports, so that each file's imports can be
+	// determined, whatever g's options.
+	withImports := g.cloneOptions()
+	withImports.imports = true
+	withImports.typeElements = g.typeElements
+	withImports.typeOrder = g.typeOrder
+	source, err := withImports.generate(options)
+	if err != nil {
+		return nil, err
+	}
+
+	fileSet := token.NewFileSet()
+	file, err := parser.ParseFile(fileSet, "", source, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	type importSpec struct {
+		path  string
+		alias string
+	}
+	importSpecsByName := make(map[string]importSpec)
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+		name, alias := importPackageName(importPath), ""
+		if spec.Name != nil {
+			name, alias = spec.Name.Name, spec.Name.Name
+		}
+		importSpecsByName[name] = importSpec{
+			path:  importPath,
+			alias: alias,
+		}
+	}
+
+	type typeFile struct {
+		decls              []string
+		importPackageNames map[string]string
+	}
+	typeFiles := make(map[string]*typeFile)
+	var fileNames []string
+	for _, decl := range file.Decls {
+		var typeName string
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			if decl.Tok == token.IMPORT {
+				continue
+			}
+			if len(decl.Specs) == 1 {
+				if typeSpec, ok := decl.Specs[0].(*ast.TypeSpec); ok {
+					typeName = typeSpec.Name.Name
+				}
+			}
+		case *ast.FuncDecl:
+			if decl.Recv != nil && len(decl.Recv.List) == 1 {
+				typeName = receiverTypeName(decl.Recv.List[0].Type)
+			}
+		}
+		fileName := declsFileName
+		if typeName != "" {
+			fileName = strings.ToLower(typeName)
+		}
+		tf, ok := typeFiles[fileName]
+		if !ok {
+			tf = &typeFile{
+				importPackageNames: make(map[string]string),
+			}
+			typeFiles[fileName] = tf
+			fileNames = append(fileNames, fileName)
+		}
+
+		start := decl.Pos()
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			if decl.Doc != nil {
+				start = decl.Doc.Pos()
+			}
+		case *ast.FuncDecl:
+			if decl.Doc != nil {
+				start = decl.Doc.Pos()
+			}
+		}
+		tf.decls = append(tf.decls, string(source[fileSet.Position(start).Offset:fileSet.Position(decl.End()).Offset]))
+		ast.Inspect(decl, func(node ast.Node) bool {
+			if selectorExpr, ok := node.(*ast.SelectorExpr); ok {
+				if ident, ok := selectorExpr.X.(*ast.Ident); ok {
+					if spec, ok := importSpecsByName[ident.Name]; ok {
+						tf.importPackageNames[spec.path] = spec.alias
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	if err := os.MkdirAll(dir, 0o777); err != nil {
+		return nil, err
+	}
+	var changedFileNames []string
+	generatedFileNames := make(map[string]struct{}, len(fileNames))
+	for _, fileName := range fileNames {
+		tf := typeFiles[fileName]
+		sourceBuilder := &strings.Builder{}
+		writeSourceHeader(sourceBuilder, options.header, buildConstraint)
+		fmt.Fprintf(sourceBuilder, "package %s\n", options.packageName)
+		writeImports(sourceBuilder, tf.importPackageNames)
+		for _, decl := range tf.decls {
+			fmt.Fprintf(sourceBuilder, "\n%s\n", decl)
+		}
+		contents := g.formatModuleSource(sourceBuilder.String())
+
+		generatedFileNames[fileName+g.generatedFileSuffix] = struct{}{}
+		name := filepath.Join(dir, fileName+g.generatedFileSuffix)
+		if existingContents, err := os.ReadFile(name); err == nil && bytes.Equal(existingContents, contents) {
+			continue
+		}
+		if err := os.WriteFile(name, contents, 0o666); err != nil {
+			return nil, err
+		}
+		changedFileNames = append(changedFileNames, name)
+	}
+
+	removedFileNames, err := g.updateTypeFilesManifest(dir, generatedFileNames)
+	if err != nil {
+		return nil, err
+	}
+	return append(changedFileNames, removedFileNames...), nil
+}
+
+// updateTypeFilesManifest removes the files in dir that are recorded in its
+// manifest but are not in generatedFileNames, records generatedFileNames in the
+// manifest, and returns the names of the removed files.
+func (g *Generator) updateTypeFilesManifest(dir string, generatedFileNames map[string]struct{}) ([]string, error) {
+	manifestName := filepath.Join(dir, TypeFilesManifestName)
+	manifest, err := os.ReadFile(manifestName)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	var removedFileNames []string
+	for _, fileName := range strings.Split(string(manifest), "\n") {
+		if _, ok := generatedFileNames[fileName]; ok {
+			continue
+		}
+		// Only remove files that could have been generated.
+		if fileName == "" || filepath.Base(fileName) != fileName || !strings.HasSuffix(fileName, g.generatedFileSuffix) {
+			continue
+		}
+		name := filepath.Join(dir, fileName)
+		switch err := os.Remove(name); {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return nil, err
+		default:
+			removedFileNames = append(removedFileNames, name)
+		}
+	}
+
+	manifestBuilder := &strings.Builder{}
+	for _, fileName := range sortedKeys(generatedFileNames) {
+		fmt.Fprintf(manifestBuilder, "%s\n", fileName)
+	}
+	if manifestBuilder.String() != string(manifest) {
+		if err := os.WriteFile(manifestName, []byte(manifestBuilder.String()), 0o666); err != nil {
+			return nil, err
+		}
+	}
+	return removedFileNames, nil
+}
+
+// receiverTypeName returns the name of the type of the receiver with type
+// expr, or an empty string if it is not a named type.
+func receiverTypeName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.Name
+	case *ast.StarExpr:
+		return receiverTypeName(expr.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(expr.X)
+	case *ast.IndexListExpr:
+		return receiverTypeName(expr.X)
+	default:
+		return ""
+	}
+}
+
+// importPackageName returns the conventional package name of the package with
+// import path importPath: its last element, ignoring any major version
+// suffix.
+func importPackageName(importPath string) string {
+	base := path.Base(importPath)
+	if majorVersionRx.MatchString(base) {
+		base = path.Base(path.Dir(importPath))
+	}
+	return base
+}
@@ -0,0 +1,109 @@
+package xmlstruct_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+func TestGenerateTypeFiles(t *testing.T) {
+	t.Parallel()
+
+	options := []xmlstruct.GeneratorOption{
+		xmlstruct.WithNamedTypes(true),
+		xmlstruct.WithPackageName("orders"),
+	}
+	generator := xmlstruct.NewGenerator(options...)
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(`<order><id>1</id><item sku="a"/></order>`)))
+
+	dir := t.TempDir()
+	written, err := generator.GenerateTypeFiles(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "item.gen.go"),
+		filepath.Join(dir, "order.gen.go"),
+	}, written)
+
+	for name, expected := range map[string]string{
+		"item.gen.go": joinLines(
+			"// Code generated by go-xmlstruct. DO NOT EDIT.",
+			"",
+			"package orders",
+			"",
+			"type Item struct {",
+			"\tSku string `xml:\"sku,attr\"`",
+			"}",
+		),
+		"order.gen.go": joinLines(
+			"// Code generated by go-xmlstruct. DO NOT EDIT.",
+			"",
+			"package orders",
+			"",
+			"type Order struct {",
+			"\tID   int  `xml:\"id\"`",
+			"\tItem Item `xml:\"item\"`",
+			"}",
+		),
+	} {
+		actual, err := os.ReadFile(filepath.Join(dir, name))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, string(actual))
+	}
+
+	written, err = generator.GenerateTypeFiles(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, nil, written)
+
+	assert.NoError(t, generator.ObserveReader(strings.NewReader(`<order><id>2</id><item sku="b"/><placed>2024-01-02T03:04:05Z</placed></order>`)))
+	written, err = generator.GenerateTypeFiles(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "order.gen.go"),
+	}, written)
+	actual, err := os.ReadFile(filepath.Join(dir, "order.gen.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, joinLines(
+		"// Code generated by go-xmlstruct. DO NOT EDIT.",
+		"",
+		"package orders",
+		"",
+		"import \"time\"",
+		"",
+		"type Order struct {",
+		"\tID     int       `xml:\"id\"`",
+		"\tItem   Item      `xml:\"item\"`",
+		"\tPlaced time.Time `xml:\"placed\"`",
+		"}",
+	), string(actual))
+
+	manifest, err := os.ReadFile(filepath.Join(dir, xmlstruct.TypeFilesManifestName))
+	assert.NoError(t, err)
+	assert.Equal(t, joinLines(
+		"item.gen.go",
+		"order.gen.go",
+	), string(manifest))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "handwritten.gen.go"), []byte("package orders\n"), 0o666))
+	renamingGenerator := xmlstruct.NewGenerator(append(options, xmlstruct.WithExportRenames(map[string]string{
+		"item": "LineItem",
+	}))...)
+	assert.NoError(t, renamingGenerator.ObserveReader(strings.NewReader(`<order><id>1</id><item sku="a"/></order>`)))
+	written, err = renamingGenerator.GenerateTypeFiles(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "lineitem.gen.go"),
+		filepath.Join(dir, "order.gen.go"),
+		filepath.Join(dir, "item.gen.go"),
+	}, written)
+	_, err = os.Stat(filepath.Join(dir, "item.gen.go"))
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+	_, err = os.Stat(filepath.Join(dir, "handwritten.gen.go"))
+	assert.NoError(t, err)
+}
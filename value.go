@@ -3,26 +3,43 @@ package xmlstruct
 import (
 	"encoding/xml"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// A valueKind is the kind of an observed simple value.
+type valueKind int
+
+const (
+	emptyValueKind valueKind = iota
+	boolValueKind
+	intValueKind
+	float64ValueKind
+	timeValueKind
+	stringValueKind
+)
+
 // A value describes an observed simple value, either an attribute value or
 // chardata.
 type value struct {
-	boolCount    int
-	float64Count int
-	intCount     int
-	name         xml.Name
-	observations int
-	optional     bool
-	repeated     bool
-	stringCount  int
-	timeCount    int
+	boolCount          int
+	example            string
+	examples           map[string]string
+	examplesIncomplete bool
+	float64Count       int
+	identityValues     map[string]int
+	intCount           int
+	name               xml.Name
+	observations       int
+	optional           bool
+	repeated           bool
+	stringCount        int
+	timeCount          int
 }
 
-// goType returns the most specific Go type that can represent all of the values
+// kind returns the most specific kind that can represent all of the values
 // observed for v.
-func (v *value) goType(options *generateOptions) string {
+func (v *value) kind() valueKind {
 	distinctTypes := 0
 	if v.boolCount > 0 {
 		distinctTypes++
@@ -39,48 +56,123 @@ func (v *value) goType(options *generateOptions) string {
 	if v.stringCount > 0 {
 		distinctTypes++
 	}
-	prefix := ""
-	if v.repeated {
-		prefix += "[]"
-	}
-	if options.usePointersForOptionalFields && v.optional {
-		prefix += "*"
-	}
 	switch {
 	case distinctTypes == 0:
-		if options.emptyElements {
-			return "struct{}"
-		}
-		return prefix + "string"
+		return emptyValueKind
 	case distinctTypes == 1 && v.boolCount > 0:
-		return prefix + "bool"
+		return boolValueKind
 	case distinctTypes == 1 && v.intCount > 0:
-		return prefix + options.intType
+		return intValueKind
 	case distinctTypes == 1 && v.float64Count > 0:
-		return prefix + "float64"
+		return float64ValueKind
 	case distinctTypes == 1 && v.timeCount > 0:
-		options.importPackageNames["time"] = struct{}{}
-		return prefix + "time.Time"
+		return timeValueKind
 	case distinctTypes == 2 && v.intCount > 0 && v.float64Count > 0:
-		return prefix + "float64"
+		return float64ValueKind
 	default:
-		return prefix + "string"
+		return stringValueKind
 	}
 }
 
+// goType returns the most specific Go type that can represent all of the values
+// observed for v. path is the path of v.
+func (v *value) goType(path string, options *generateOptions) string {
+	fieldType := v.goFieldType(path, options)
+	return fieldType.prefix() + fieldType.name
+}
+
+// goFieldType returns the Go type of a field that can represent all of the
+// values observed for v. path is the path of v.
+func (v *value) goFieldType(path string, options *generateOptions) goFieldType {
+	kind, tolerant := v.resolvedKind(options)
+	fieldType, mapped := options.mappedGoFieldType(path)
+	if !mapped && kind == emptyValueKind && options.emptyElements {
+		return goFieldType{
+			name: "struct{}",
+		}
+	}
+	fieldType.slice = v.repeated
+	if v.repeated {
+		// Without a pointer policy, repeated attributes are optional fields.
+		fieldType.pointer = options.pointerPolicy.RepeatedElements || options.usePointersForOptionalFields && v.optional
+	}
+	if !mapped {
+		fieldType.name = scalarGoType(kind, options)
+		if tolerant {
+			fieldType.name = options.tolerantGoType(kind, fieldType.name)
+		}
+		if options.logger != nil && kind != v.kind() {
+			options.logDecision("resolved type conflict", path, "type", fieldType.name, "observations", v.observations)
+		}
+	}
+	if v.optional && !v.repeated {
+		fieldType = options.optionalScalarGoFieldType(fieldType)
+	}
+	return fieldType
+}
+
+// scalarGoType returns the Go type of values of kind.
+func scalarGoType(kind valueKind, options *generateOptions) string {
+	switch kind {
+	case boolValueKind:
+		return "bool"
+	case intValueKind, float64ValueKind:
+		switch {
+		case options.numberFormat.localized():
+			return options.localizedGoFieldType(kind)
+		case kind == intValueKind:
+			return options.intType
+		default:
+			return "float64"
+		}
+	case timeValueKind:
+		return options.timeGoType()
+	default:
+		return "string"
+	}
+}
+
+// sanitizeValue returns the value to observe for s, the value of the attribute
+// attrName, or the chardata if attrName is the zero xml.Name, of the element
+// with path.
+func (options *observeOptions) sanitizeValue(path []xml.Name, attrName xml.Name, s string) string {
+	if options.valueSanitizer == nil {
+		return s
+	}
+	pathComponents := make([]string, 0, len(path)+1)
+	for _, name := range path {
+		pathComponents = append(pathComponents, name.Local)
+	}
+	if attrName != (xml.Name{}) {
+		pathComponents = append(pathComponents, "@"+attrName.Local)
+	}
+	return options.valueSanitizer(strings.Join(pathComponents, "/"), s)
+}
+
 // observe records s as being observed for v.
 func (v *value) observe(s string, options *observeOptions) {
 	v.observations++
-	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
-		v.intCount++
+	v.observeExample(s, options)
+	number, isNumber := options.numberFormat.normalize(s)
+	leadingZeros := options.preserveLeadingZeros && hasLeadingZeros(s)
+	if _, err := strconv.ParseInt(number, 10, 64); isNumber && err == nil {
+		if leadingZeros {
+			v.stringCount++
+		} else {
+			v.intCount++
+		}
 		return
 	}
 	if _, err := strconv.ParseBool(s); err == nil {
 		v.boolCount++
 		return
 	}
-	if _, err := strconv.ParseFloat(s, 64); err == nil {
-		v.float64Count++
+	if _, err := strconv.ParseFloat(number, 64); isNumber && err == nil {
+		if leadingZeros {
+			v.stringCount++
+		} else {
+			v.float64Count++
+		}
 		return
 	}
 	if options.timeLayout != "" {
@@ -91,3 +183,10 @@ func (v *value) observe(s string, options *observeOptions) {
 	}
 	v.stringCount++
 }
+
+// hasLeadingZeros returns whether s is a number padded with leading zeros, like
+// 007.
+func hasLeadingZeros(s string) bool {
+	s = strings.TrimLeft(s, "+-")
+	return len(s) > 1 && s[0] == '0' && '0' <= s[1] && s[1] <= '9'
+}
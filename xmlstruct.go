@@ -4,29 +4,57 @@ package xmlstruct
 import (
 	"cmp"
 	"encoding/xml"
+	"go/token"
+	"log/slog"
 	"regexp"
 	"slices"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 const (
+	DefaultAnonymizationEnumThreshold   = 0
+	DefaultAttrMapThreshold             = 0
 	DefaultAttrNameSuffix               = ""
+	DefaultBenchmarks                   = false
+	DefaultCardinalityPrecedence        = CardinalityPrecedenceUnion
 	DefaultCharDataFieldName            = "CharData"
+	DefaultCharDataFieldNameFromElement = false
+	DefaultCharsetPrecedence            = CharsetPrecedenceDeclaration
+	DefaultCheckpointInterval           = 100
+	DefaultCommonFieldExtraction        = 0
+	DefaultDeepCopyGenMarkers           = false
+	DefaultDeepCopyMethods              = false
 	DefaultElemNameSuffix               = ""
+	DefaultExampleTags                  = false
 	DefaultFormatSource                 = true
-	DefaultHeader                       = "// This file is automatically generated. DO NOT EDIT."
+	DefaultGeneratedFileSuffix          = ".gen.go"
+	DefaultHeader                       = "// Code generated by go-xmlstruct. DO NOT EDIT."
+	DefaultIdentityConstraints          = false
 	DefaultTopLevelAttributes           = false
 	DefaultImports                      = true
+	DefaultIndexFuncs                   = false
 	DefaultIntType                      = "int"
+	DefaultKeyUniquenessThreshold       = 1.0
+	DefaultMinOccurrenceRate            = 0.0
 	DefaultNamedRoot                    = false
 	DefaultNamedTypes                   = false
 	DefaultCompactTypes                 = false
 	DefaultPackageName                  = "main"
+	DefaultPreserveLeadingZeros         = false
 	DefaultPreserveOrder                = false
+	DefaultPreserveWhitespaceCharData   = false
+	DefaultRootRegistry                 = false
+	DefaultStripBOM                     = false
 	DefaultTimeLayout                   = "2006-01-02T15:04:05Z"
+	DefaultTimeZonePolicy               = TimeZonePolicyNone
+	DefaultTypeConfidenceThreshold      = 0.99
+	DefaultTypeConflictPolicy           = TypeConflictPolicyString
 	DefaultUsePointersForOptionalFields = true
 	DefaultUseRawToken                  = false
+	DefaultValueCollation               = ValueCollation(0)
+	DefaultValueExamples                = 0
 	DefaultEmptyElements                = true
 )
 
@@ -40,42 +68,34 @@ var (
 	}
 
 	kebabOrSnakeCaseWordBoundaryRx = regexp.MustCompile(`[-_]+\pL`)
+	dottedWordBoundaryRx           = regexp.MustCompile(`[-_.:]+\pL`)
 	nonIdentifierRuneRx            = regexp.MustCompile(`[^\pL\pN]`)
 
 	// DefaultExportNameFunc returns name.Local with kebab- and snakecase words
 	// converted to UpperCamelCase and any Id suffix converted to ID.
 	DefaultExportNameFunc = func(name xml.Name) string {
-		localName := kebabOrSnakeCaseWordBoundaryRx.ReplaceAllStringFunc(name.Local, func(s string) string {
-			return strings.ToUpper(s[len(s)-1:])
-		})
-		localName = nonIdentifierRuneRx.ReplaceAllLiteralString(localName, "_")
-		runes := []rune(localName)
-		runes[0] = unicode.ToUpper(runes[0])
-		if len(runes) > 1 && runes[len(runes)-2] == 'I' && runes[len(runes)-1] == 'd' {
-			runes[len(runes)-1] = 'D'
-		}
-		return string(runes)
+		return camelCaseExportName(name.Local, kebabOrSnakeCaseWordBoundaryRx)
 	}
 
 	// DefaultUnexportNameFunc returns name.Local with kebab- and snakecase words
 	// converted to lowerCamelCase
 	// Any ID prefix is converted to id, and any Id suffix converted to ID.
 	DefaultUnexportNameFunc = func(name xml.Name) string {
-		localName := kebabOrSnakeCaseWordBoundaryRx.ReplaceAllStringFunc(name.Local, func(s string) string {
-			return strings.ToUpper(s[len(s)-1:])
-		})
-		localName = nonIdentifierRuneRx.ReplaceAllLiteralString(localName, "_")
-		runes := []rune(localName)
-		runes[0] = unicode.ToLower(runes[0])
-		if len(runes) > 1 {
-			if runes[len(runes)-2] == 'I' && runes[len(runes)-1] == 'd' {
-				runes[len(runes)-1] = 'D'
-			}
-			if runes[0] == 'i' && runes[1] == 'D' {
-				runes[1] = 'd'
-			}
-		}
-		return string(runes)
+		return camelCaseUnexportName(name.Local, kebabOrSnakeCaseWordBoundaryRx)
+	}
+
+	// DottedExportNameFunc is like DefaultExportNameFunc, except that words
+	// separated by dots or colons, like foo.bar, are also converted to
+	// UpperCamelCase.
+	DottedExportNameFunc = func(name xml.Name) string {
+		return camelCaseExportName(name.Local, dottedWordBoundaryRx)
+	}
+
+	// DottedUnexportNameFunc is like DefaultUnexportNameFunc, except that words
+	// separated by dots or colons, like foo.bar, are also converted to
+	// lowerCamelCase.
+	DottedUnexportNameFunc = func(name xml.Name) string {
+		return camelCaseUnexportName(name.Local, dottedWordBoundaryRx)
 	}
 )
 
@@ -95,41 +115,282 @@ var (
 	}
 
 	DefaultNameFunc = IgnoreNamespaceNameFunc
+
+	// DefaultSanitizeIdentifierFunc returns identifier with every rune that is
+	// not valid in a Go identifier replaced by an underscore, prefixed with X if
+	// it does not start with a letter or is a Go keyword or predeclared
+	// identifier.
+	DefaultSanitizeIdentifierFunc = PrefixSanitizeIdentifierFunc("X")
+
+	// DefaultPointerPolicy uses pointers for optional fields.
+	DefaultPointerPolicy = PointerPolicy{
+		OptionalScalars: DefaultUsePointersForOptionalFields,
+		OptionalStructs: DefaultUsePointersForOptionalFields,
+	}
+
+	// predeclaredIdentifiers are Go's predeclared identifiers, which generated
+	// types must not shadow.
+	predeclaredIdentifiers = map[string]struct{}{
+		"any": {}, "bool": {}, "byte": {}, "comparable": {}, "complex64": {},
+		"complex128": {}, "error": {}, "float32": {}, "float64": {}, "int": {},
+		"int8": {}, "int16": {}, "int32": {}, "int64": {}, "rune": {},
+		"string": {}, "uint": {}, "uint8": {}, "uint16": {}, "uint32": {},
+		"uint64": {}, "uintptr": {}, "true": {}, "false": {}, "iota": {},
+		"nil": {}, "append": {}, "cap": {}, "clear": {}, "close": {},
+		"complex": {}, "copy": {}, "delete": {}, "imag": {}, "len": {},
+		"make": {}, "max": {}, "min": {}, "new": {}, "panic": {}, "print": {},
+		"println": {}, "real": {}, "recover": {},
+	}
 )
 
+// PrefixSanitizeIdentifierFunc returns a SanitizeIdentifierFunc that replaces
+// every rune that is not valid in a Go identifier with an underscore and then
+// prepends prefix to identifiers that do not start with a letter, like 1st, or
+// that are Go keywords or predeclared identifiers, like type or string. If the
+// identifier starts with a lowercase letter then the prefix is lowercased and
+// the identifier capitalized, so type becomes xType. When a Generator's export
+// name function unexports names, the prefix of identifiers that do not start
+// with a letter is also lowercased, so 1st becomes x1st.
+func PrefixSanitizeIdentifierFunc(prefix string) SanitizeIdentifierFunc {
+	return func(identifier string) string {
+		identifier = replaceNonIdentifierRunes(identifier)
+		switch {
+		case identifier == "":
+			return prefix
+		case !startsWithLetter(identifier):
+			return prefix + identifier
+		case !isReservedIdentifier(identifier):
+			return identifier
+		case unicode.IsLower([]rune(identifier)[0]):
+			return lowerFirstRune(prefix) + upperFirstRune(identifier)
+		default:
+			return prefix + identifier
+		}
+	}
+}
+
+// SuffixSanitizeIdentifierFunc returns a SanitizeIdentifierFunc that replaces
+// every rune that is not valid in a Go identifier with an underscore and then
+// appends suffix to Go keywords and predeclared identifiers, so type becomes
+// type_ if suffix is _. Identifiers that do not start with a letter are
+// prefixed with X.
+func SuffixSanitizeIdentifierFunc(suffix string) SanitizeIdentifierFunc {
+	return func(identifier string) string {
+		identifier = replaceNonIdentifierRunes(identifier)
+		switch {
+		case identifier == "":
+			return "X"
+		case !startsWithLetter(identifier):
+			return "X" + identifier
+		case isReservedIdentifier(identifier):
+			return identifier + suffix
+		default:
+			return identifier
+		}
+	}
+}
+
+// replaceNonIdentifierRunes returns s with every rune that is not valid in a
+// Go identifier replaced by an underscore.
+func replaceNonIdentifierRunes(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// isReservedIdentifier returns whether identifier is a Go keyword or a
+// predeclared identifier.
+func isReservedIdentifier(identifier string) bool {
+	if token.IsKeyword(identifier) {
+		return true
+	}
+	_, ok := predeclaredIdentifiers[identifier]
+	return ok
+}
+
+// startsWithLetter returns whether s starts with a letter.
+func startsWithLetter(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsLetter(r)
+}
+
+// lowerFirstRune returns s with its first rune lowercased.
+func lowerFirstRune(s string) string {
+	if s == "" {
+		return s
+	}
+	runes := []rune(s)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+// upperFirstRune returns s with its first rune uppercased.
+func upperFirstRune(s string) string {
+	if s == "" {
+		return s
+	}
+	runes := []rune(s)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// camelCaseExportName returns localName with the words separated by
+// wordBoundaryRx converted to UpperCamelCase and any Id suffix converted to ID.
+func camelCaseExportName(localName string, wordBoundaryRx *regexp.Regexp) string {
+	localName = wordBoundaryRx.ReplaceAllStringFunc(localName, func(s string) string {
+		return strings.ToUpper(s[len(s)-1:])
+	})
+	localName = nonIdentifierRuneRx.ReplaceAllLiteralString(localName, "_")
+	runes := []rune(localName)
+	runes[0] = unicode.ToUpper(runes[0])
+	if len(runes) > 1 && runes[len(runes)-2] == 'I' && runes[len(runes)-1] == 'd' {
+		runes[len(runes)-1] = 'D'
+	}
+	return string(runes)
+}
+
+// camelCaseUnexportName returns localName with the words separated by
+// wordBoundaryRx converted to lowerCamelCase, any ID prefix converted to id,
+// and any Id suffix converted to ID.
+func camelCaseUnexportName(localName string, wordBoundaryRx *regexp.Regexp) string {
+	localName = wordBoundaryRx.ReplaceAllStringFunc(localName, func(s string) string {
+		return strings.ToUpper(s[len(s)-1:])
+	})
+	localName = nonIdentifierRuneRx.ReplaceAllLiteralString(localName, "_")
+	runes := []rune(localName)
+	runes[0] = unicode.ToLower(runes[0])
+	if len(runes) > 1 {
+		if runes[len(runes)-2] == 'I' && runes[len(runes)-1] == 'd' {
+			runes[len(runes)-1] = 'D'
+		}
+		if runes[0] == 'i' && runes[1] == 'D' {
+			runes[1] = 'd'
+		}
+	}
+	return string(runes)
+}
+
+// An ExampleRedactFunc returns the value of the example tag of the attribute
+// or chardata at path, given its observed example value. Returning an empty
+// string omits the tag.
+type ExampleRedactFunc func(path, example string) string
+
 // An ExportNameFunc returns the exported Go identifier for the given xml.Name.
 type ExportNameFunc func(xml.Name) string
 
 // A NameFunc modifies xml.Names observed in the XML documents.
 type NameFunc func(xml.Name) xml.Name
 
+// A PathNameFunc modifies xml.Names observed in the XML documents given the
+// names of their ancestor elements, from the top level element to the parent
+// element for elements and to the element itself for attributes, as they
+// appear in the document. Returning the same name in different contexts merges
+// elements or attributes, and returning different names splits them. Returning
+// the zero xml.Name ignores the element or attribute. ancestors must not be
+// modified or retained.
+type PathNameFunc func(ancestors []xml.Name, name xml.Name) xml.Name
+
+// A PointerPolicy determines which fields use pointers, depending on their
+// cardinality and on whether their type is a struct. Scalars are attributes and
+// elements with only chardata.
+type PointerPolicy struct {
+	// OptionalScalars uses pointers for optional scalar fields, like *string.
+	OptionalScalars bool
+	// OptionalStructs uses pointers for optional struct fields, like
+	// *Epilogue.
+	OptionalStructs bool
+	// RepeatedElements uses slices of pointers for repeated fields, like
+	// []*Speech, instead of slices of values, like []Speech.
+	RepeatedElements bool
+	// RequiredStructs uses pointers for struct fields that are always present.
+	RequiredStructs bool
+	// OptionalScalarWrapper, if set, wraps optional scalar fields in a generic
+	// type instead of using pointers, for example Optional[int] instead of
+	// *int. It takes precedence over OptionalScalars.
+	OptionalScalarWrapper OptionalScalarWrapper
+}
+
+// A SanitizeIdentifierFunc returns a valid Go identifier for an identifier
+// returned by an ExportNameFunc.
+type SanitizeIdentifierFunc func(string) string
+
+// A ValueSanitizerFunc returns the value to observe for the attribute or
+// chardata at path, given the value in the XML document. path is the local
+// names of the element and its ancestors as they appear in the document,
+// starting at the top level element and joined by slashes, followed, for
+// attributes, by a slash and the attribute's local name prefixed with @.
+type ValueSanitizerFunc func(path, value string) string
+
 // observeOptions contains options for observing XML documents.
 type observeOptions struct {
-	getOrder           func() int
-	nameFunc           NameFunc
-	timeLayout         string
-	typeOrder          map[xml.Name]int
-	topLevelAttributes bool
-	topLevelElements   map[xml.Name]*element
-	useRawToken        bool
+	elementFilters       []ElementFilter
+	exampleTags          bool
+	getOrder             func() int
+	document             int
+	identityConstraints  bool
+	logger               *slog.Logger
+	numberFormat         NumberFormat
+	pathNameFunc         PathNameFunc
+	preserveLeadingZeros bool
+	preserveWhitespace   bool
+	timeLayout           string
+	typeOrder            map[xml.Name]int
+	topLevelAttributes   bool
+	topLevelElements     map[xml.Name]*element
+	useRawToken          bool
+	valueCollation       ValueCollation
+	valueExamples        int
+	valueSanitizer       ValueSanitizerFunc
 }
 
 // generateOptions contains options for generating Go source.
 type generateOptions struct {
+	anyAttrs                     []FieldMatcher
+	anyElements                  []FieldMatcher
+	attrDefaults                 []*attrDefault
+	attrMapThreshold             int
 	attrNameSuffix               string
+	cardinalities                []*cardinalityDeclaration
+	cardinalityPrecedence        CardinalityPrecedence
+	charDataElementName          bool
 	charDataFieldName            string
+	commonAttrs                  *commonAttrs
+	commonFieldExtraction        int
+	declarations                 map[string]string
+	deprecatedBuildTag           string
+	deprecatedFields             bool
+	deprecations                 []*deprecation
+	documents                    int
 	elemNameSuffix               string
+	exampleRedactFunc            ExampleRedactFunc
+	exampleTags                  bool
 	exportNameFunc               ExportNameFunc
 	exportTypeNameFunc           ExportNameFunc
 	header                       string
-	importPackageNames           map[string]struct{}
+	importMappings               []*importMapping
+	importPackageNames           map[string]string
 	intType                      string
+	loggedDecisions              map[string]struct{}
+	logger                       *slog.Logger
+	mapElements                  map[string]string
+	minOccurrenceRate            float64
 	namedRoot                    bool
 	namedTypes                   map[xml.Name]*element
+	numberFormat                 NumberFormat
 	compactTypes                 bool
+	packageName                  string
 	preserveOrder                bool
 	simpleTypes                  map[xml.Name]struct{}
+	timeLayout                   string
+	timeZonePolicy               TimeZonePolicy
+	typeConfidence               float64
+	typeConflictPolicy           TypeConflictPolicy
+	pointerPolicy                PointerPolicy
 	usePointersForOptionalFields bool
+	valueCollation               ValueCollation
 	emptyElements                bool
 }
 
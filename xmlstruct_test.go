@@ -50,6 +50,10 @@ func TestDefaultExportNameFunc(t *testing.T) {
 			localName: "snake-id",
 			expected:  "SnakeID",
 		},
+		{
+			localName: "dot.case",
+			expected:  "Dot_case",
+		},
 		{
 			localName: "+",
 			expected:  "_",
@@ -118,6 +122,10 @@ func TestDefaultUnexportNameFunc(t *testing.T) {
 			localName: "snake-id",
 			expected:  "snakeID",
 		},
+		{
+			localName: "dot.case",
+			expected:  "dot_case",
+		},
 		{
 			localName: "+",
 			expected:  "_",
@@ -134,3 +142,119 @@ func TestDefaultUnexportNameFunc(t *testing.T) {
 		})
 	}
 }
+
+func TestDottedExportNameFunc(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		localName        string
+		expectedExport   string
+		expectedUnexport string
+	}{
+		{
+			localName:        "kebab-case",
+			expectedExport:   "KebabCase",
+			expectedUnexport: "kebabCase",
+		},
+		{
+			localName:        "dot.case",
+			expectedExport:   "DotCase",
+			expectedUnexport: "dotCase",
+		},
+		{
+			localName:        "colon:case",
+			expectedExport:   "ColonCase",
+			expectedUnexport: "colonCase",
+		},
+		{
+			localName:        "dot.id",
+			expectedExport:   "DotID",
+			expectedUnexport: "dotID",
+		},
+	} {
+		tc := tc
+		t.Run(tc.localName, func(t *testing.T) {
+			t.Parallel()
+
+			xmlName := xml.Name{
+				Local: tc.localName,
+			}
+			assert.Equal(t, tc.expectedExport, DottedExportNameFunc(xmlName))
+			assert.Equal(t, tc.expectedUnexport, DottedUnexportNameFunc(xmlName))
+		})
+	}
+}
+
+func TestDefaultSanitizeIdentifierFunc(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		identifier string
+		expected   string
+	}{
+		{identifier: "Foo", expected: "Foo"},
+		{identifier: "Foo-bar", expected: "Foo_bar"},
+		{identifier: "Foo.bar", expected: "Foo_bar"},
+		{identifier: "Foo:bar", expected: "Foo_bar"},
+		{identifier: "Foo_Bar1", expected: "Foo_Bar1"},
+		{identifier: "Ünïcode", expected: "Ünïcode"},
+		{identifier: "", expected: "X"},
+		{identifier: "1st", expected: "X1st"},
+		{identifier: "_1", expected: "X_1"},
+		{identifier: "type", expected: "xType"},
+		{identifier: "string", expected: "xString"},
+		{identifier: "Type", expected: "Type"},
+	} {
+		tc := tc
+		t.Run(tc.identifier, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, DefaultSanitizeIdentifierFunc(tc.identifier))
+		})
+	}
+}
+
+func TestSuffixSanitizeIdentifierFunc(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		identifier string
+		expected   string
+	}{
+		{identifier: "Foo", expected: "Foo"},
+		{identifier: "1st", expected: "X1st"},
+		{identifier: "type", expected: "type_"},
+		{identifier: "range", expected: "range_"},
+		{identifier: "len", expected: "len_"},
+		{identifier: "x-y", expected: "x_y"},
+	} {
+		tc := tc
+		t.Run(tc.identifier, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, SuffixSanitizeIdentifierFunc("_")(tc.identifier))
+		})
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{pattern: "item/@price", path: "order/item/@price", expected: true},
+		{pattern: "item/@price", path: "order/item/@cost", expected: false},
+		{pattern: "@price", path: "order/item/@price", expected: true},
+		{pattern: "*/@price", path: "order/item/@price", expected: true},
+		{pattern: "/item/@price", path: "order/item/@price", expected: false},
+		{pattern: "/order/*/@price", path: "order/item/@price", expected: true},
+		{pattern: "order/item/@price", path: "item/@price", expected: false},
+		{pattern: "[", path: "[", expected: false},
+	} {
+		t.Run(tc.pattern+"_"+tc.path, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, MatchPath(tc.pattern)(tc.path))
+		})
+	}
+}
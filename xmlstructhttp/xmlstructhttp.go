@@ -0,0 +1,257 @@
+// Package xmlstructhttp feeds XML bodies of HTTP requests and responses into a
+// go-xmlstruct Generator as they flow through a server or proxy, for learning
+// the shape of an undocumented API from live traffic.
+//
+// A typical proxy observes all traffic and serves the current generated source
+// on a separate endpoint:
+//
+//	generator := xmlstruct.NewGenerator(xmlstruct.WithNamedTypes(true))
+//	observer := xmlstructhttp.NewObserver(generator)
+//	proxy := httputil.NewSingleHostReverseProxy(target)
+//	proxy.Transport = observer.RoundTripper(http.DefaultTransport)
+//	http.Handle("/", proxy)
+//	http.Handle("/debug/xmlstruct", observer.SourceHandler())
+//
+// Bodies are observed without changing them. Bodies that are not XML, that are
+// larger than the maximum body size, or that are not valid XML are not
+// observed.
+//
+// Bodies are observed synchronously, in the goroutine that reads or closes
+// them, so observation adds to the latency of requests: each body is decoded
+// twice, once to check it and once to observe it, and observing waits for the
+// Generator's lock, which is held while other bodies are observed and while
+// source is generated. Observe a copy of the traffic instead if this latency is
+// not acceptable.
+package xmlstructhttp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+// DefaultMaxBodySize is the default maximum size of an observed body, in
+// bytes.
+const DefaultMaxBodySize = 10 << 20
+
+// An Observer feeds the XML bodies of HTTP requests and responses into a
+// Generator. It is safe for concurrent use by multiple goroutines.
+type Observer struct {
+	generator    *xmlstruct.Generator
+	maxBodySize  int64
+	errorHandler func(error)
+}
+
+// An Option sets an option on an Observer.
+type Option func(*Observer)
+
+// WithErrorHandler sets a function that is called with errors that occur when
+// observing bodies, for example when a body is not valid XML. Such errors
+// never affect the traffic. By default, errors are ignored.
+func WithErrorHandler(errorHandler func(error)) Option {
+	return func(o *Observer) {
+		o.errorHandler = errorHandler
+	}
+}
+
+// WithMaxBodySize sets the maximum size of an observed body, in bytes. Larger
+// bodies are passed through but not observed.
+func WithMaxBodySize(maxBodySize int64) Option {
+	return func(o *Observer) {
+		o.maxBodySize = maxBodySize
+	}
+}
+
+// NewObserver returns a new Observer that feeds XML bodies into generator.
+func NewObserver(generator *xmlstruct.Generator, options ...Option) *Observer {
+	o := &Observer{
+		generator:    generator,
+		maxBodySize:  DefaultMaxBodySize,
+		errorHandler: func(error) {},
+	}
+	for _, option := range options {
+		option(o)
+	}
+	return o
+}
+
+// Middleware returns a handler that observes the XML bodies of the requests
+// that it receives and of the responses that next writes. Request bodies are
+// observed when next has read them completely or, at the latest, when next
+// returns.
+func (o *Observer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body *bodyReader
+		if r.Body != nil && isXML(r.Header.Get("Content-Type")) {
+			body = o.newBodyReader(r.Body)
+			r.Body = body
+		}
+		rw := &responseWriter{
+			ResponseWriter: w,
+			maxBodySize:    o.maxBodySize,
+		}
+		next.ServeHTTP(rw, r)
+		if body != nil {
+			body.observe()
+		}
+		if !rw.overflowed && isXML(w.Header().Get("Content-Type")) {
+			o.observe(rw.body.Bytes())
+		}
+	})
+}
+
+// RoundTripper returns an http.RoundTripper that observes the XML bodies of
+// the requests that it sends and of the responses that next returns, for use
+// as the Transport of an http.Client or an httputil.ReverseProxy.
+func (o *Observer) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Body != nil && req.Body != http.NoBody && isXML(req.Header.Get("Content-Type")) {
+			req = req.Clone(req.Context())
+			req.Body = o.ObserveBody(req.Body)
+		}
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		if isXML(resp.Header.Get("Content-Type")) {
+			resp.Body = o.ObserveBody(resp.Body)
+		}
+		return resp, nil
+	})
+}
+
+// ObserveBody returns an io.ReadCloser that reads from body and observes what
+// was read when it reaches the end of body or when it is closed, whichever
+// comes first. It can be used to observe XML from any source.
+func (o *Observer) ObserveBody(body io.ReadCloser) io.ReadCloser {
+	return o.newBodyReader(body)
+}
+
+// newBodyReader returns a new bodyReader that reads from body.
+func (o *Observer) newBodyReader(body io.ReadCloser) *bodyReader {
+	return &bodyReader{
+		body:     body,
+		observer: o,
+	}
+}
+
+// SourceHandler returns a handler that responds with the Go source generated
+// from all the XML bodies observed so far.
+func (o *Observer) SourceHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		source, err := o.generator.Generate()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write(source)
+	})
+}
+
+// observe observes the XML document data. data is checked with the
+// Generator's decoder setup first so that truncated or invalid bodies do not
+// add partial elements to the Generator's model.
+func (o *Observer) observe(data []byte) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return
+	}
+	if err := o.generator.CheckReader(bytes.NewReader(data)); err != nil {
+		o.errorHandler(err)
+		return
+	}
+	if err := o.generator.ObserveReader(bytes.NewReader(data)); err != nil {
+		o.errorHandler(err)
+	}
+}
+
+// A bodyReader reads from a body, recording what is read until it exceeds the
+// maximum body size, and observes it at the end of the body or when it is
+// closed. mu protects buffer, overflowed, and observed, as Close may be called
+// concurrently with Read, for example by a server when a request is canceled.
+type bodyReader struct {
+	body        io.ReadCloser
+	observer    *Observer
+	mu          sync.Mutex
+	buffer      bytes.Buffer
+	overflowed  bool
+	observed    bool
+	observeOnce sync.Once
+}
+
+func (r *bodyReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	r.mu.Lock()
+	r.overflowed = r.overflowed || int64(r.buffer.Len()+n) > r.observer.maxBodySize
+	if !r.overflowed && !r.observed {
+		r.buffer.Write(p[:n])
+	}
+	r.mu.Unlock()
+	if errors.Is(err, io.EOF) {
+		r.observe()
+	}
+	return n, err
+}
+
+func (r *bodyReader) Close() error {
+	r.observe()
+	return r.body.Close()
+}
+
+// observe observes what has been read, once. Later reads are not recorded, so
+// the buffer is not modified while it is observed.
+func (r *bodyReader) observe() {
+	r.observeOnce.Do(func() {
+		r.mu.Lock()
+		r.observed = true
+		overflowed := r.overflowed
+		r.mu.Unlock()
+		if !overflowed {
+			r.observer.observe(r.buffer.Bytes())
+		}
+	})
+}
+
+// A responseWriter records what is written to a ResponseWriter until it
+// exceeds the maximum body size.
+type responseWriter struct {
+	http.ResponseWriter
+	maxBodySize int64
+	body        bytes.Buffer
+	overflowed  bool
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	w.overflowed = w.overflowed || int64(w.body.Len()+len(p)) > w.maxBodySize
+	if !w.overflowed {
+		w.body.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// A roundTripperFunc is a function that implements http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// isXML returns whether contentType is an XML media type.
+func isXML(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
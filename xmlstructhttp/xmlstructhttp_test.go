@@ -0,0 +1,233 @@
+package xmlstructhttp_test
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+	"github.com/twpayne/go-xmlstruct/xmlstructhttp"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithHeader(""),
+		xmlstruct.WithNamedTypes(true),
+		xmlstruct.WithPackageName("api"),
+	)
+	var errs []error
+	observer := xmlstructhttp.NewObserver(generator, xmlstructhttp.WithErrorHandler(func(err error) {
+		errs = append(errs, err)
+	}))
+	handler := observer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/soap+xml; charset=utf-8")
+		_, _ = w.Write([]byte(`<response><id>1</id></response>`))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`<request><name>a</name></request>`))
+	req.Header.Set("Content-Type", "text/xml")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.NoError(t, req.Body.Close())
+	assert.Equal(t, `<response><id>1</id></response>`, rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`<ignored/>`))
+	req.Header.Set("Content-Type", "text/plain")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.NoError(t, req.Body.Close())
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`<invalid>`))
+	req.Header.Set("Content-Type", "application/xml")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.NoError(t, req.Body.Close())
+	assert.Equal(t, 1, len(errs))
+
+	rec = httptest.NewRecorder()
+	observer.SourceHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, joinLines(
+		`package api`,
+		``,
+		`type Request struct {`,
+		"\tName string `xml:\"name\"`",
+		`}`,
+		``,
+		`type Response struct {`,
+		"\tID int `xml:\"id\"`",
+		`}`,
+	), rec.Body.String())
+}
+
+func TestMiddlewareServer(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithHeader(""),
+		xmlstruct.WithNamedTypes(true),
+		xmlstruct.WithPackageName("api"),
+	)
+	observer := xmlstructhttp.NewObserver(generator)
+	server := httptest.NewServer(observer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Name string `xml:"name"`
+		}
+		if err := xml.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})))
+	defer server.Close()
+
+	resp, err := server.Client().Post(server.URL, "application/xml", strings.NewReader(`<request><name>a</name></request>`))
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	actualSource, err := generator.Generate()
+	assert.NoError(t, err)
+	assert.Equal(t, joinLines(
+		`package api`,
+		``,
+		`type Request struct {`,
+		"\tName string `xml:\"name\"`",
+		`}`,
+	), string(actualSource))
+}
+
+func TestRoundTripper(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write([]byte(`<response><ok>true</ok></response>`))
+	}))
+	defer server.Close()
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithHeader(""),
+		xmlstruct.WithNamedTypes(true),
+		xmlstruct.WithPackageName("api"),
+	)
+	observer := xmlstructhttp.NewObserver(generator)
+	client := &http.Client{
+		Transport: observer.RoundTripper(http.DefaultTransport),
+	}
+
+	resp, err := client.Post(server.URL, "application/xml", strings.NewReader(`<request><n>1.5</n></request>`))
+	assert.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, `<response><ok>true</ok></response>`, string(body))
+
+	actualSource, err := generator.Generate()
+	assert.NoError(t, err)
+	assert.Equal(t, joinLines(
+		`package api`,
+		``,
+		`type Request struct {`,
+		"\tN float64 `xml:\"n\"`",
+		`}`,
+		``,
+		`type Response struct {`,
+		"\tOk bool `xml:\"ok\"`",
+		`}`,
+	), string(actualSource))
+}
+
+func TestMaxBodySize(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithHeader(""),
+		xmlstruct.WithPackageName("api"),
+	)
+	observer := xmlstructhttp.NewObserver(generator, xmlstructhttp.WithMaxBodySize(16))
+
+	body := observer.ObserveBody(io.NopCloser(strings.NewReader(`<a><b>1</b></a>`)))
+	_, err := io.Copy(io.Discard, body)
+	assert.NoError(t, err)
+	assert.NoError(t, body.Close())
+
+	body = observer.ObserveBody(io.NopCloser(strings.NewReader(`<c><d>1</d></c><!-- too long -->`)))
+	data, err := io.ReadAll(body)
+	assert.NoError(t, err)
+	assert.NoError(t, body.Close())
+	assert.Equal(t, `<c><d>1</d></c><!-- too long -->`, string(data))
+
+	actualSource, err := generator.Generate()
+	assert.NoError(t, err)
+	assert.Equal(t, joinLines(
+		`package api`,
+		``,
+		`type A struct {`,
+		"\tB int `xml:\"b\"`",
+		`}`,
+	), string(actualSource))
+}
+
+func TestObserveBodyEntities(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator(
+		xmlstruct.WithEntities(xml.HTMLEntity),
+		xmlstruct.WithHeader(""),
+		xmlstruct.WithPackageName("api"),
+	)
+	var errs []error
+	observer := xmlstructhttp.NewObserver(generator, xmlstructhttp.WithErrorHandler(func(err error) {
+		errs = append(errs, err)
+	}))
+
+	body := observer.ObserveBody(io.NopCloser(strings.NewReader(`<a><b>&nbsp;x</b></a>`)))
+	_, err := io.Copy(io.Discard, body)
+	assert.NoError(t, err)
+	assert.NoError(t, body.Close())
+	assert.Equal(t, nil, errs)
+
+	actualSource, err := generator.Generate()
+	assert.NoError(t, err)
+	assert.Equal(t, joinLines(
+		`package api`,
+		``,
+		`type A struct {`,
+		"\tB string `xml:\"b\"`",
+		`}`,
+	), string(actualSource))
+}
+
+func TestObserveBodyConcurrentClose(t *testing.T) {
+	t.Parallel()
+
+	generator := xmlstruct.NewGenerator()
+	observer := xmlstructhttp.NewObserver(generator)
+
+	pipeReader, pipeWriter := io.Pipe()
+	body := observer.ObserveBody(pipeReader)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = io.Copy(io.Discard, body)
+	}()
+	_, err := pipeWriter.Write([]byte(`<a><b>1</b>`))
+	assert.NoError(t, err)
+	assert.NoError(t, body.Close())
+	<-done
+}
+
+func joinLines(lines ...string) string {
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,121 @@
+// Package xmlstructtest provides helpers for regression tests of Go types
+// generated by go-xmlstruct from a corpus of sample XML documents.
+//
+// A typical test observes the samples, compares the generated source with a
+// checked-in golden file, and checks that the samples round-trip through the
+// generated types:
+//
+//	func TestFeed(t *testing.T) {
+//		xmlstructtest.AssertGolden(t, "testdata/*.xml", "feed.gen.go", xmlstruct.WithPackageName("feed"))
+//		xmlstructtest.AssertRoundTrip[Feed](t, "testdata/*.xml")
+//	}
+package xmlstructtest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/html/charset"
+
+	"github.com/twpayne/go-xmlstruct"
+)
+
+// ActualSuffix is the suffix of the file to which AssertGolden writes the
+// generated source, so that it can be inspected or copied over the golden file
+// when the change is intended.
+const ActualSuffix = ".actual"
+
+// AssertGolden observes the XML documents in the files matching pattern, in
+// lexical order, with a Generator with options, and asserts that the generated
+// source is equal to the contents of the file goldenFilename. The generated
+// source is also written to goldenFilename with ActualSuffix appended.
+func AssertGolden(tb testing.TB, pattern, goldenFilename string, options ...xmlstruct.GeneratorOption) {
+	tb.Helper()
+
+	generator := xmlstruct.NewGenerator(options...)
+	for _, filename := range globFilenames(tb, pattern) {
+		if err := generator.ObserveFile(filename); err != nil {
+			tb.Fatalf("%s: %v", filename, err)
+		}
+	}
+
+	actualSource, err := generator.Generate()
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	actualFilename := goldenFilename + ActualSuffix
+	if err := os.WriteFile(actualFilename, actualSource, 0o666); err != nil {
+		tb.Fatal(err)
+	}
+
+	expectedSource, err := os.ReadFile(goldenFilename)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if !bytes.Equal(expectedSource, actualSource) {
+		tb.Errorf("%s: generated source differs, see %s", goldenFilename, actualFilename)
+	}
+}
+
+// AssertRoundTrip asserts that every XML document in the files matching
+// pattern can be decoded into a T, and that marshaling it and decoding the
+// result into another T gives an equal value.
+func AssertRoundTrip[T any](tb testing.TB, pattern string) {
+	tb.Helper()
+
+	for _, filename := range globFilenames(tb, pattern) {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			tb.Fatal(err)
+		}
+
+		var value T
+		if err := decode(data, &value); err != nil {
+			tb.Errorf("%s: decode: %v", filename, err)
+			continue
+		}
+
+		marshaledData, err := xml.Marshal(value)
+		if err != nil {
+			tb.Errorf("%s: marshal: %v", filename, err)
+			continue
+		}
+
+		var roundTrippedValue T
+		if err := decode(marshaledData, &roundTrippedValue); err != nil {
+			tb.Errorf("%s: decode marshaled: %v", filename, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(value, roundTrippedValue) {
+			tb.Errorf("%s: value changed after marshaling and decoding", filename)
+		}
+	}
+}
+
+// decode decodes the XML document data into v.
+func decode(data []byte, v any) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = charset.NewReaderLabel
+	return decoder.Decode(v)
+}
+
+// globFilenames returns the names of the files matching pattern, failing tb if
+// there are none.
+func globFilenames(tb testing.TB, pattern string) []string {
+	tb.Helper()
+
+	filenames, err := filepath.Glob(pattern)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if len(filenames) == 0 {
+		tb.Fatalf("%s: no matching files", pattern)
+	}
+	return filenames
+}
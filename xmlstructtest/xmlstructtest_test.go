@@ -0,0 +1,102 @@
+package xmlstructtest_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"github.com/twpayne/go-xmlstruct"
+	"github.com/twpayne/go-xmlstruct/xmlstructtest"
+)
+
+// A recordingTB records errors instead of failing the test.
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (tb *recordingTB) Helper() {}
+
+func (tb *recordingTB) Errorf(format string, args ...any) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertGolden(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a.xml"), []byte(`<a><b>1</b></a>`), 0o666))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "b.xml"), []byte(`<a><b>2</b><c/></a>`), 0o666))
+	goldenFilename := filepath.Join(dir, "a.gen.go")
+	assert.NoError(t, os.WriteFile(goldenFilename, []byte(joinLines(
+		xmlstruct.DefaultHeader,
+		``,
+		`package a`,
+		``,
+		`type A struct {`,
+		"\tB int      `xml:\"b\"`",
+		"\tC struct{} `xml:\"c\"`",
+		`}`,
+	)), 0o666))
+
+	tb := &recordingTB{TB: t}
+	xmlstructtest.AssertGolden(tb, filepath.Join(dir, "*.xml"), goldenFilename, xmlstruct.WithPackageName("a"))
+	assert.Equal(t, nil, tb.errors)
+	expectedSource, err := os.ReadFile(goldenFilename)
+	assert.NoError(t, err)
+	actualSource, err := os.ReadFile(goldenFilename + xmlstructtest.ActualSuffix)
+	assert.NoError(t, err)
+	assert.Equal(t, string(expectedSource), string(actualSource))
+
+	tb = &recordingTB{TB: t}
+	xmlstructtest.AssertGolden(tb, filepath.Join(dir, "*.xml"), goldenFilename, xmlstruct.WithPackageName("b"))
+	assert.Equal(t, []string{
+		goldenFilename + ": generated source differs, see " + goldenFilename + xmlstructtest.ActualSuffix,
+	}, tb.errors)
+}
+
+func TestAssertRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a.xml"), []byte(`<a><b>1</b></a>`), 0o666))
+
+	type A struct {
+		B int `xml:"b"`
+	}
+	tb := &recordingTB{TB: t}
+	xmlstructtest.AssertRoundTrip[A](tb, filepath.Join(dir, "*.xml"))
+	assert.Equal(t, nil, tb.errors)
+
+	type LossyA struct {
+		B        int    `xml:"b"`
+		InnerXML string `xml:",innerxml"`
+	}
+	tb = &recordingTB{TB: t}
+	xmlstructtest.AssertRoundTrip[LossyA](tb, filepath.Join(dir, "*.xml"))
+	assert.Equal(t, []string{
+		filepath.Join(dir, "a.xml") + ": value changed after marshaling and decoding",
+	}, tb.errors)
+}
+
+func TestAssertRoundTripCharset(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a.xml"), []byte("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n<a><b>caf\xe9</b></a>"), 0o666))
+
+	type A struct {
+		B string `xml:"b"`
+	}
+	tb := &recordingTB{TB: t}
+	xmlstructtest.AssertRoundTrip[A](tb, filepath.Join(dir, "*.xml"))
+	assert.Equal(t, nil, tb.errors)
+}
+
+func joinLines(lines ...string) string {
+	return strings.Join(lines, "\n") + "\n"
+}